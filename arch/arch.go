@@ -19,11 +19,15 @@ type Arch struct {
 	// always reserved), but does not include the return PC pushed
 	// on x86 by CALL (because that is added only on a call).
 	MinFrameSize int
+
+	// Regs describes the registers of this architecture, using DWARF
+	// register numbering.
+	Regs *Regs
 }
 
 var (
-	AMD64 = &Arch{Layout{0, 8}, "amd64", 0}
-	I386  = &Arch{Layout{0, 4}, "386", 0}
+	AMD64 = &Arch{Layout{0, 8}, "amd64", 0, amd64Regs}
+	I386  = &Arch{Layout{0, 4}, "386", 0, i386Regs}
 )
 
 // String returns the GOARCH value of a.
@@ -0,0 +1,82 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package arch
+
+import "strconv"
+
+// A Reg is a register number. Register numbers follow the DWARF
+// register numbering of each architecture, which is also used by call
+// frame information.
+type Reg int
+
+// Regs describes the register set of an architecture.
+type Regs struct {
+	// Names gives the name of each register, indexed by Reg. Reg
+	// numbers that don't have an assigned register have an empty name.
+	Names []string
+
+	// SP is the stack pointer register.
+	SP Reg
+
+	// FP is the frame pointer register.
+	FP Reg
+
+	// PC is the register that holds the return address in call frame
+	// information. On architectures without a link register, this is
+	// the program counter.
+	PC Reg
+
+	byName map[string]Reg
+}
+
+func newRegs(names []string, sp, fp, pc Reg) *Regs {
+	r := &Regs{Names: names, SP: sp, FP: fp, PC: pc, byName: make(map[string]Reg)}
+	for i, name := range names {
+		if name != "" {
+			r.byName[name] = Reg(i)
+		}
+	}
+	return r
+}
+
+// Name returns the name of register reg. If reg isn't a known register,
+// it returns a name of the form "r<N>".
+func (r *Regs) Name(reg Reg) string {
+	if reg >= 0 && int(reg) < len(r.Names) && r.Names[reg] != "" {
+		return r.Names[reg]
+	}
+	return "r" + strconv.Itoa(int(reg))
+}
+
+// ByName returns the register with the given name.
+func (r *Regs) ByName(name string) (Reg, bool) {
+	reg, ok := r.byName[name]
+	return reg, ok
+}
+
+// [System V AMD64 psABI, Figure 3.36]
+var amd64Regs = newRegs([]string{
+	"rax", "rdx", "rcx", "rbx", "rsi", "rdi", "rbp", "rsp",
+	"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15",
+	"rip",
+	"xmm0", "xmm1", "xmm2", "xmm3", "xmm4", "xmm5", "xmm6", "xmm7",
+	"xmm8", "xmm9", "xmm10", "xmm11", "xmm12", "xmm13", "xmm14", "xmm15",
+	"st0", "st1", "st2", "st3", "st4", "st5", "st6", "st7",
+	"mm0", "mm1", "mm2", "mm3", "mm4", "mm5", "mm6", "mm7",
+	"rflags", "es", "cs", "ss", "ds", "fs", "gs", "", "",
+	"fs.base", "gs.base",
+}, 7, 6, 16)
+
+// [System V i386 psABI, Table 2.14]
+var i386Regs = newRegs([]string{
+	"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi",
+	"eip", "eflags", "",
+	"st0", "st1", "st2", "st3", "st4", "st5", "st6", "st7",
+	"", "",
+	"xmm0", "xmm1", "xmm2", "xmm3", "xmm4", "xmm5", "xmm6", "xmm7",
+	"mm0", "mm1", "mm2", "mm3", "mm4", "mm5", "mm6", "mm7",
+	"", "", "",
+	"es", "cs", "ss", "ds", "fs", "gs",
+}, 4, 5, 8)
@@ -0,0 +1,45 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package arch
+
+import "testing"
+
+func TestRegs(t *testing.T) {
+	for _, test := range []struct {
+		arch       *Arch
+		sp, fp, pc string
+	}{
+		{AMD64, "rsp", "rbp", "rip"},
+		{I386, "esp", "ebp", "eip"},
+	} {
+		regs := test.arch.Regs
+		if got := regs.Name(regs.SP); got != test.sp {
+			t.Errorf("%s: want SP %s, got %s", test.arch, test.sp, got)
+		}
+		if got := regs.Name(regs.FP); got != test.fp {
+			t.Errorf("%s: want FP %s, got %s", test.arch, test.fp, got)
+		}
+		if got := regs.Name(regs.PC); got != test.pc {
+			t.Errorf("%s: want PC %s, got %s", test.arch, test.pc, got)
+		}
+		for i, name := range regs.Names {
+			if name == "" {
+				continue
+			}
+			if reg, ok := regs.ByName(name); !ok || reg != Reg(i) {
+				t.Errorf("%s: ByName(%s) = %d, %v; want %d", test.arch, name, reg, ok, i)
+			}
+		}
+		if got := regs.Name(1000); got != "r1000" {
+			t.Errorf("%s: want r1000 for unknown register, got %s", test.arch, got)
+		}
+	}
+	if reg, _ := AMD64.Regs.ByName("xmm0"); reg != 17 {
+		t.Errorf("want amd64 xmm0 = 17, got %d", reg)
+	}
+	if reg, _ := I386.Regs.ByName("xmm0"); reg != 21 {
+		t.Errorf("want 386 xmm0 = 21, got %d", reg)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package buildinfo reads the build information the Go linker embeds
+// in Go binaries. This includes the Go toolchain version, the main
+// module and its dependencies, and the build settings.
+//
+// This is similar to the standard debug/buildinfo package, but works
+// on an already open obj.File.
+//
+// Reference: debug/buildinfo and runtime/debug/mod.go in the Go source
+// tree.
+package buildinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+)
+
+// ErrNotFound is returned when an object file doesn't contain Go build
+// information.
+var ErrNotFound = errors.New("Go build information not found")
+
+// A BuildInfo is the build information of a Go binary.
+type BuildInfo struct {
+	// GoVersion is the version of the Go toolchain that built the
+	// binary, such as "go1.21.3".
+	GoVersion string
+
+	// Path is the package path of the main package. It is empty if
+	// the binary wasn't built with module support.
+	Path string
+
+	// Main is the module containing the main package.
+	Main Module
+
+	// Deps lists the module dependencies linked into the binary.
+	Deps []*Module
+
+	// Settings lists the build settings, such as GOOS, GOARCH,
+	// CGO_ENABLED, -tags, and the vcs.* settings describing the
+	// version control state. Go 1.18 and later.
+	Settings []Setting
+}
+
+// A Module is a module linked into a Go binary.
+type Module struct {
+	Path    string  // Module path
+	Version string  // Module version
+	Sum     string  // Checksum, or "" if unknown
+	Replace *Module // Replacement of this module, or nil
+}
+
+// A Setting is a key/value build setting.
+type Setting struct {
+	Key, Value string
+}
+
+// Setting returns the value of the build setting key and whether it is
+// set.
+func (bi *BuildInfo) Setting(key string) (string, bool) {
+	for _, s := range bi.Settings {
+		if s.Key == key {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// magic is the start of the build information blob written by the Go
+// linker since Go 1.13.
+var magic = []byte("\xff Go buildinf:")
+
+// headerSize is the size of the build information header.
+const headerSize = 32
+
+// Read reads the build information of f. It returns ErrNotFound if f
+// doesn't contain build information.
+//
+// The build information is stored in a blob that starts with a 32-byte
+// header. Since Go 1.18, the version and module strings are stored
+// inline after the header. Before that, the header points to the
+// runtime.buildVersion and runtime.modinfo variables.
+func Read(f obj.File) (*BuildInfo, error) {
+	d, err := Find(f)
+	if err != nil {
+		return nil, err
+	}
+	b := d.B
+	ptrSize, flags := int(b[14]), b[15]
+
+	var vers, mod string
+	if flags&2 != 0 {
+		r := obj.NewReader(d)
+		r.SetOffset(headerSize)
+		vers = string(r.UvarintBytes())
+		mod = string(r.UvarintBytes())
+		if err := r.Err(); err != nil {
+			return nil, fmt.Errorf("reading build information: %w", err)
+		}
+	} else {
+		if ptrSize != 4 && ptrSize != 8 {
+			return nil, fmt.Errorf("bad pointer size %d in build information", ptrSize)
+		}
+		var order binary.ByteOrder = binary.LittleEndian
+		if flags&1 != 0 {
+			order = binary.BigEndian
+		}
+		d.Layout = arch.NewLayout(order, ptrSize)
+		r := obj.NewReader(d)
+		r.SetOffset(16)
+		versAddr, modAddr := ptr(f, r), ptr(f, r)
+		if err := r.Err(); err != nil {
+			return nil, fmt.Errorf("reading build information: %w", err)
+		}
+		if vers, err = stringAt(f, d.Layout, versAddr); err != nil {
+			return nil, fmt.Errorf("reading Go version: %w", err)
+		}
+		if mod, err = stringAt(f, d.Layout, modAddr); err != nil {
+			return nil, fmt.Errorf("reading module information: %w", err)
+		}
+	}
+
+	bi := &BuildInfo{GoVersion: vers}
+	// The module information is bracketed by 16-byte sentinels.
+	if len(mod) >= 33 && mod[len(mod)-17] == '\n' {
+		mod = mod[16 : len(mod)-16]
+	} else {
+		mod = ""
+	}
+	if err := bi.parse(mod); err != nil {
+		return nil, err
+	}
+	return bi, nil
+}
+
+// Find returns the data of f's build information blob, which is at
+// least 32 bytes long. It uses the .go.buildinfo section if f has one,
+// and otherwise searches f's data sections.
+func Find(f obj.File) (*obj.Data, error) {
+	if s := f.SectionByName(".go.buildinfo"); s != nil {
+		d, err := s.Data(s.Bounds())
+		if err != nil {
+			return nil, err
+		}
+		if len(d.B) >= headerSize && bytes.HasPrefix(d.B, magic) {
+			return d, nil
+		}
+	}
+	// Search the data sections. The blob is 16-byte aligned.
+	for _, s := range f.SectionsByKind(obj.SectionData) {
+		if !s.Mapped() || s.ZeroInitialized() {
+			continue
+		}
+		d, err := s.Data(s.Bounds())
+		if err != nil {
+			return nil, err
+		}
+		for off := 0; off+headerSize <= len(d.B); off += 16 {
+			if bytes.HasPrefix(d.B[off:], magic) {
+				return &obj.Data{Addr: d.Addr + uint64(off), B: d.B[off:], R: d.R, Layout: d.Layout}, nil
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ptr reads a pointer from r, resolving symbolic relocations using f.
+func ptr(f obj.File, r *obj.Reader) uint64 {
+	sym, val := r.Ptr()
+	if sym != obj.NoSym {
+		val += f.Sym(sym).Value
+	}
+	return val
+}
+
+// stringAt reads the Go string whose header is at addr in f.
+func stringAt(f obj.File, layout arch.Layout, addr uint64) (string, error) {
+	d, err := data(f, addr, 2*uint64(layout.WordSize()))
+	if err != nil {
+		return "", err
+	}
+	d.Layout = layout
+	r := obj.NewReader(d)
+	addr, n := ptr(f, r), r.Word()
+	if n == 0 {
+		return "", nil
+	}
+	if n > 1<<30 {
+		return "", fmt.Errorf("string length %d is too large", n)
+	}
+	if d, err = data(f, addr, n); err != nil {
+		return "", err
+	}
+	return string(d.B), nil
+}
+
+func data(f obj.File, addr, size uint64) (*obj.Data, error) {
+	s := f.ResolveAddr(addr)
+	if s == nil {
+		return nil, &obj.ErrNoData{Detail: fmt.Sprintf("address %#x is not in any section", addr)}
+	}
+	return s.Data(addr, size)
+}
+
+// parse parses the text form of the module information, as recorded
+// in runtime.modinfo, into bi.
+func (bi *BuildInfo) parse(data string) error {
+	var last *Module
+	for lineNum, line := range strings.Split(data, "\n") {
+		lineNum++
+		if line == "" {
+			continue
+		}
+		bad := func(what string) error {
+			return fmt.Errorf("module information line %d: %s: %q", lineNum, what, line)
+		}
+		i := strings.IndexByte(line, '\t')
+		if i < 0 {
+			return bad("missing tab")
+		}
+		key, val := line[:i], line[i+1:]
+		switch key {
+		case "go":
+			// This appears in the output of go version -m, but not
+			// in binaries.
+			bi.GoVersion = val
+		case "path":
+			bi.Path = val
+			last = nil
+		case "mod", "dep", "=>":
+			m, ok := parseModule(val)
+			if !ok {
+				return bad("bad module")
+			}
+			switch key {
+			case "mod":
+				bi.Main = *m
+				last = &bi.Main
+			case "dep":
+				bi.Deps = append(bi.Deps, m)
+				last = m
+			case "=>":
+				if last == nil {
+					return bad("unexpected replacement")
+				}
+				last.Replace = m
+				last = nil
+			}
+		case "build":
+			s, ok := parseSetting(val)
+			if !ok {
+				return bad("bad build setting")
+			}
+			bi.Settings = append(bi.Settings, s)
+		default:
+			return bad("unknown key")
+		}
+	}
+	return nil
+}
+
+// parseModule parses a "path version [sum]" module line.
+func parseModule(s string) (*Module, bool) {
+	f := strings.Split(s, "\t")
+	if len(f) < 2 || len(f) > 3 || f[0] == "" {
+		return nil, false
+	}
+	m := &Module{Path: f[0], Version: f[1]}
+	if len(f) == 3 {
+		m.Sum = f[2]
+	}
+	return m, true
+}
+
+// parseSetting parses a "key=value" build setting. Keys and values
+// that contain special characters are quoted.
+func parseSetting(s string) (Setting, bool) {
+	var key string
+	if strings.HasPrefix(s, `"`) {
+		q, ok := quotedPrefix(s)
+		if !ok {
+			return Setting{}, false
+		}
+		key, _ = strconv.Unquote(q)
+		s = s[len(q):]
+		if !strings.HasPrefix(s, "=") {
+			return Setting{}, false
+		}
+		s = s[1:]
+	} else {
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			return Setting{}, false
+		}
+		key, s = s[:i], s[i+1:]
+	}
+	if key == "" {
+		return Setting{}, false
+	}
+	val := s
+	if strings.HasPrefix(s, `"`) {
+		var err error
+		if val, err = strconv.Unquote(s); err != nil {
+			return Setting{}, false
+		}
+	}
+	return Setting{key, val}, true
+}
+
+// quotedPrefix returns the double-quoted Go string literal at the start
+// of s.
+func quotedPrefix(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildinfo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+const helloGo = `package main
+
+func main() {
+	println("hello")
+}
+`
+
+func TestRead(t *testing.T) {
+	for _, flags := range [][]string{
+		{"-tags=foo,bar"},
+		{"-tags=foo,bar", "-buildmode=pie"},
+		{"-tags=foo,bar", "-ldflags=-s -w"},
+	} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			f := gotest.Open(t, gotest.Build(t, helloGo, flags...))
+			bi, err := Read(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(bi.GoVersion, "go1.") && !strings.HasPrefix(bi.GoVersion, "devel ") {
+				t.Errorf("bad Go version %q", bi.GoVersion)
+			}
+			if bi.Path != "hello" || bi.Main.Path != "hello" {
+				t.Errorf("want path hello and main module hello, got %q and %q", bi.Path, bi.Main.Path)
+			}
+			if len(bi.Deps) != 0 {
+				t.Errorf("want no dependencies, got %v", bi.Deps)
+			}
+			for key, want := range map[string]string{
+				"GOOS":        "linux",
+				"GOARCH":      "amd64",
+				"CGO_ENABLED": "0",
+				"-tags":       "foo,bar",
+			} {
+				if got, ok := bi.Setting(key); !ok || got != want {
+					t.Errorf("want setting %s=%s, got %q (set %v)", key, want, got, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestReadGo117(t *testing.T) {
+	// The Go 1.17 hello world binary from the Go distribution's
+	// debug/buildinfo tests, reduced to its .go.buildinfo, .data, and
+	// .rodata sections. Before Go 1.18, the build information header
+	// points to the version and module strings.
+	z, err := ioutil.ReadFile("testdata/go117.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := obj.Open(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	d, err := Find(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags := d.B[15]; flags&2 != 0 {
+		t.Fatalf("want pointer-based build information, got flags %#x", flags)
+	}
+	bi, err := Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &BuildInfo{
+		GoVersion: "go1.17",
+		Path:      "example.com/go117",
+		Main:      Module{Path: "example.com/go117", Version: "(devel)"},
+	}
+	if !reflect.DeepEqual(bi, want) {
+		t.Errorf("want %+v, got %+v", want, bi)
+	}
+}
+
+func TestParse(t *testing.T) {
+	const data = `path	example.com/cmd/x
+mod	example.com	v1.2.3	h1:main=
+dep	golang.org/x/arch	v0.1.0	h1:arch=
+dep	example.com/old	v1.0.0
+=>	example.com/new	v1.1.0	h1:new=
+dep	example.com/local	v0.0.0
+=>	../local	(devel)	
+build	-compiler=gc
+build	-ldflags="-s -w"
+build	"key with space"=x
+build	vcs.revision=0123abcd
+`
+	var bi BuildInfo
+	if err := bi.parse(data); err != nil {
+		t.Fatal(err)
+	}
+	want := BuildInfo{
+		Path: "example.com/cmd/x",
+		Main: Module{Path: "example.com", Version: "v1.2.3", Sum: "h1:main="},
+		Deps: []*Module{
+			{Path: "golang.org/x/arch", Version: "v0.1.0", Sum: "h1:arch="},
+			{Path: "example.com/old", Version: "v1.0.0",
+				Replace: &Module{Path: "example.com/new", Version: "v1.1.0", Sum: "h1:new="}},
+			{Path: "example.com/local", Version: "v0.0.0",
+				Replace: &Module{Path: "../local", Version: "(devel)"}},
+		},
+		Settings: []Setting{
+			{"-compiler", "gc"},
+			{"-ldflags", "-s -w"},
+			{"key with space", "x"},
+			{"vcs.revision", "0123abcd"},
+		},
+	}
+	if !reflect.DeepEqual(bi, want) {
+		t.Errorf("want %+v, got %+v", want, bi)
+	}
+
+	for _, bad := range []string{
+		"path",
+		"=>\texample.com\tv1.0.0\n",
+		"mod\texample.com\n",
+		"build\tnovalue\n",
+		"build\t\"unterminated=x\n",
+		"bogus\tx\n",
+	} {
+		var bi BuildInfo
+		if err := bi.parse(bad); err == nil {
+			t.Errorf("parsing %q: want error", bad)
+		}
+	}
+}
@@ -0,0 +1,619 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cfi decodes DWARF call frame information, which describes how
+// to unwind the stack from any instruction.
+//
+// Call frame information is stored either in a .eh_frame section, which
+// is loaded at run time to support exception handling, or in a
+// .debug_frame section. The two formats differ in small ways, but both
+// consist of Common Information Entries (CIEs) and Frame Description
+// Entries (FDEs). Each FDE covers a range of PCs and contains a program
+// that, when evaluated, produces a table of Rows giving the rules for
+// recovering the caller's registers at each PC. An FDE may also refer
+// to a language-specific data area (LSDA), such as a C++ exception
+// handling table, which can be decoded with FDE.ReadLSDA.
+//
+// Register numbers follow the DWARF numbering of each architecture, as
+// described by arch.Regs.
+package cfi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+)
+
+// ErrNoCFI is returned when an object file has no call frame
+// information of the requested kind.
+var ErrNoCFI = errors.New("no call frame information")
+
+// A Table is the call frame information from one section of an object
+// file.
+//
+// A Table is safe for concurrent use.
+type Table struct {
+	f    obj.File
+	arch *arch.Arch
+	regs *arch.Regs
+
+	// eh indicates this is a .eh_frame section, rather than
+	// .debug_frame.
+	eh bool
+
+	sect *obj.Section
+	data *obj.Data
+
+	bases ptrBases
+
+	// hdr is the .eh_frame_hdr search table, or nil.
+	hdr *ehFrameHdr
+
+	mu   sync.Mutex
+	cies map[uint64]*CIE
+
+	indexOnce sync.Once
+	index     []*FDE // Sorted by Low
+	indexErr  error
+}
+
+// A CIE is a Common Information Entry, which contains information
+// shared by many FDEs.
+type CIE struct {
+	// Offset is the offset of this CIE in its section.
+	Offset uint64
+
+	// Version is the version number of this CIE's format.
+	Version uint8
+
+	// Augmentation is the augmentation string, which describes
+	// vendor-specific extensions. In .eh_frame sections, this is
+	// typically "zR" or "zPLR".
+	Augmentation string
+
+	// AddressSize is the size of target addresses in bytes.
+	AddressSize uint8
+
+	// CodeAlign and DataAlign are the factors applied to location
+	// advances and to register offsets in the CFA program.
+	CodeAlign uint64
+	DataAlign int64
+
+	// ReturnAddress is the register column that holds the return
+	// address.
+	ReturnAddress arch.Reg
+
+	// Instructions is the initial CFA program, which is executed
+	// before each FDE's program.
+	Instructions []byte
+
+	// FDEEncoding is the encoding of addresses in FDEs that use this
+	// CIE.
+	FDEEncoding PtrEncoding
+
+	// LSDAEncoding is the encoding of the LSDA pointer in FDEs that use
+	// this CIE, or PtrOmit if they don't have LSDA pointers.
+	LSDAEncoding PtrEncoding
+
+	// Personality is the address of the personality routine, or 0 if
+	// there isn't one.
+	Personality uint64
+
+	// SignalFrame indicates FDEs using this CIE describe signal
+	// handler frames. In these frames, the PC is the address of the
+	// faulting instruction, rather than a return address.
+	SignalFrame bool
+
+	// hasAugData is true if the augmentation string starts with "z",
+	// meaning FDEs have augmentation data.
+	hasAugData bool
+}
+
+// An FDE is a Frame Description Entry, which describes how to unwind
+// a range of PCs.
+type FDE struct {
+	// CIE is the CIE this FDE uses.
+	CIE *CIE
+
+	// Offset is the offset of this FDE in its section.
+	Offset uint64
+
+	// Low and High give the range of PCs [Low, High) covered by this
+	// FDE.
+	Low, High uint64
+
+	// LSDA is the address of the language-specific data area, or 0 if
+	// there isn't one.
+	LSDA uint64
+
+	// Instructions is the CFA program of this FDE.
+	Instructions []byte
+
+	t *Table
+}
+
+// Contains reports whether pc is in the range covered by fde.
+func (fde *FDE) Contains(pc uint64) bool {
+	return fde.Low <= pc && pc < fde.High
+}
+
+// Table returns the Table containing fde.
+func (fde *FDE) Table() *Table {
+	return fde.t
+}
+
+// NewEHFrame returns the call frame information from f's .eh_frame
+// section. If f has a .eh_frame_hdr section, FindFDE uses its search
+// table. It returns ErrNoCFI if f has no .eh_frame section.
+func NewEHFrame(f obj.File) (*Table, error) {
+	t, err := newTable(f, ".eh_frame", true)
+	if err != nil {
+		return nil, err
+	}
+	if s := f.SectionByName(".eh_frame_hdr"); s != nil {
+		t.hdr, err = t.readHdr(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// NewDebugFrame returns the call frame information from f's
+// .debug_frame section. It returns ErrNoCFI if f has no .debug_frame
+// section.
+func NewDebugFrame(f obj.File) (*Table, error) {
+	return newTable(f, ".debug_frame", false)
+}
+
+func newTable(f obj.File, name string, eh bool) (*Table, error) {
+	s := f.SectionByName(name)
+	if s == nil {
+		return nil, ErrNoCFI
+	}
+	data, err := s.Data(s.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{f: f, arch: f.Info().Arch, eh: eh, sect: s, data: data, cies: make(map[uint64]*CIE)}
+	if t.arch != nil {
+		t.regs = t.arch.Regs
+	}
+	if s := f.SectionByName(".text"); s != nil {
+		t.bases.text = s.Addr
+	}
+	if s := f.SectionByName(".got"); s != nil {
+		// On i386, data-relative pointers in .eh_frame are relative to
+		// the GOT.
+		t.bases.data = s.Addr
+	}
+	return t, nil
+}
+
+// Section returns the section t was read from.
+func (t *Table) Section() *obj.Section {
+	return t.sect
+}
+
+// Arch returns the architecture of t's object file, or nil if unknown.
+func (t *Table) Arch() *arch.Arch {
+	return t.arch
+}
+
+func (t *Table) wordSize() int {
+	return t.data.Layout.WordSize()
+}
+
+func (t *Table) errorf(off uint64, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %#x: %s", t.sect.Name, off, fmt.Sprintf(format, args...))
+}
+
+// entry is the framing of a CIE or FDE.
+type entry struct {
+	off    uint64 // Offset of the entry
+	end    uint64 // Offset of the next entry
+	idOff  uint64 // Offset of the CIE ID/pointer field
+	id     uint64
+	isCIE  bool
+	offLen int // 4 or 8 for 32- or 64-bit DWARF
+}
+
+// readEntry reads the header of the entry at offset off. It returns
+// ok=false at a terminator or the end of the section.
+func (t *Table) readEntry(off uint64) (e entry, ok bool, err error) {
+	if off >= uint64(len(t.data.B)) {
+		return e, false, nil
+	}
+	r := obj.NewCheckedReader(t.data)
+	r.SetOffset(int(off))
+	e.off = off
+	e.offLen = 4
+	length := uint64(r.Uint32())
+	if length == 0xffffffff {
+		e.offLen = 8
+		length = r.Uint64()
+	} else if length >= 0xfffffff0 {
+		return e, false, t.errorf(off, "reserved length %#x", length)
+	}
+	if r.Err() != nil {
+		return e, false, t.errorf(off, "truncated entry")
+	}
+	if length == 0 {
+		// A zero length terminates .eh_frame.
+		return e, false, nil
+	}
+	start := uint64(r.Addr() - t.data.Addr)
+	if length > uint64(len(t.data.B))-start {
+		return e, false, t.errorf(off, "entry length %#x exceeds section", length)
+	}
+	e.end = start + length
+	e.idOff = start
+	if e.offLen == 4 {
+		e.id = uint64(r.Uint32())
+	} else {
+		e.id = r.Uint64()
+	}
+	if r.Err() != nil {
+		return e, false, t.errorf(off, "truncated entry")
+	}
+	if t.eh {
+		e.isCIE = e.id == 0
+	} else {
+		e.isCIE = e.id == 0xffffffff || e.id == ^uint64(0)
+	}
+	return e, true, nil
+}
+
+// reader returns a checked Reader positioned after e's ID field and
+// limited to e.
+func (t *Table) reader(e entry) *obj.Reader {
+	d := &obj.Data{Addr: t.data.Addr + e.idOff, B: t.data.B[e.idOff:e.end], Layout: t.data.Layout}
+	r := obj.NewCheckedReader(d)
+	r.Skip(e.offLen)
+	return r
+}
+
+// cieOffset returns the offset of the CIE used by FDE entry e.
+func (t *Table) cieOffset(e entry) (uint64, error) {
+	if !t.eh {
+		return e.id, nil
+	}
+	// In .eh_frame, the CIE pointer is relative to the pointer's own
+	// offset.
+	if e.id > e.idOff {
+		return 0, t.errorf(e.off, "bad CIE pointer %#x", e.id)
+	}
+	return e.idOff - e.id, nil
+}
+
+// CIE returns the CIE at offset off in t's section.
+func (t *Table) CIE(off uint64) (*CIE, error) {
+	t.mu.Lock()
+	cie := t.cies[off]
+	t.mu.Unlock()
+	if cie != nil {
+		return cie, nil
+	}
+
+	e, ok, err := t.readEntry(off)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !e.isCIE {
+		return nil, t.errorf(off, "expected CIE")
+	}
+	cie, err = t.parseCIE(e)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.cies[off] = cie
+	t.mu.Unlock()
+	return cie, nil
+}
+
+func (t *Table) parseCIE(e entry) (*CIE, error) {
+	r := t.reader(e)
+	cie := &CIE{Offset: e.off, AddressSize: uint8(t.wordSize()), LSDAEncoding: PtrOmit}
+	cie.Version = r.Uint8()
+	switch cie.Version {
+	case 1, 3:
+	case 4:
+		if t.eh {
+			return nil, t.errorf(e.off, "unsupported CIE version %d", cie.Version)
+		}
+	default:
+		return nil, t.errorf(e.off, "unsupported CIE version %d", cie.Version)
+	}
+	cie.Augmentation = string(r.CString())
+	aug := cie.Augmentation
+	if len(aug) >= 2 && aug[:2] == "eh" {
+		// Old GCC augmentation with a pointer to exception data.
+		r.Skip(t.wordSize())
+		aug = aug[2:]
+	}
+	if cie.Version >= 4 {
+		cie.AddressSize = r.Uint8()
+		if segSize := r.Uint8(); segSize != 0 {
+			return nil, t.errorf(e.off, "unsupported segment selector size %d", segSize)
+		}
+	}
+	cie.CodeAlign = r.ULEB128()
+	cie.DataAlign = r.SLEB128()
+	if cie.Version == 1 {
+		cie.ReturnAddress = arch.Reg(r.Uint8())
+	} else {
+		cie.ReturnAddress = arch.Reg(r.ULEB128())
+	}
+	if r.Err() != nil {
+		return nil, t.errorf(e.off, "truncated CIE")
+	}
+
+	if len(aug) > 0 && aug[0] == 'z' {
+		cie.hasAugData = true
+		augLen := r.ULEB128()
+		augEnd := r.Addr() + augLen
+		for _, c := range aug[1:] {
+			switch c {
+			case 'L':
+				cie.LSDAEncoding = PtrEncoding(r.Uint8())
+			case 'P':
+				enc := PtrEncoding(r.Uint8())
+				p, err := readPtr(r, enc, int(cie.AddressSize), t.bases, t.f)
+				if err != nil {
+					return nil, t.errorf(e.off, "reading personality: %v", err)
+				}
+				cie.Personality = p
+			case 'R':
+				cie.FDEEncoding = PtrEncoding(r.Uint8())
+			case 'S':
+				cie.SignalFrame = true
+			default:
+				// We can skip the rest of the augmentation data, but
+				// can't interpret it.
+			}
+			if r.Addr() > augEnd {
+				return nil, t.errorf(e.off, "augmentation data overflow")
+			}
+		}
+		if augEnd < r.Addr() || augEnd-r.Addr() > uint64(r.Avail()) {
+			return nil, t.errorf(e.off, "bad augmentation data length")
+		}
+		r.Skip(int(augEnd - r.Addr()))
+	} else if aug != "" {
+		return nil, t.errorf(e.off, "unsupported augmentation %q", cie.Augmentation)
+	}
+	cie.Instructions = r.Bytes(r.Avail())
+	if r.Err() != nil {
+		return nil, t.errorf(e.off, "truncated CIE")
+	}
+	return cie, nil
+}
+
+// FDE returns the FDE at offset off in t's section.
+func (t *Table) FDE(off uint64) (*FDE, error) {
+	e, ok, err := t.readEntry(off)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || e.isCIE {
+		return nil, t.errorf(off, "expected FDE")
+	}
+	return t.parseFDE(e)
+}
+
+func (t *Table) parseFDE(e entry) (*FDE, error) {
+	cieOff, err := t.cieOffset(e)
+	if err != nil {
+		return nil, err
+	}
+	cie, err := t.CIE(cieOff)
+	if err != nil {
+		return nil, err
+	}
+
+	r := t.reader(e)
+	fde := &FDE{CIE: cie, Offset: e.off, t: t}
+	addrSize := int(cie.AddressSize)
+	low, err := readPtr(r, cie.FDEEncoding, addrSize, t.bases, t.f)
+	if err != nil {
+		return nil, t.errorf(e.off, "reading FDE start: %v", err)
+	}
+	// The range is just a value, so it uses only the format of the
+	// encoding.
+	size, err := readPtr(r, cie.FDEEncoding.Format(), addrSize, t.bases, t.f)
+	if err != nil {
+		return nil, t.errorf(e.off, "reading FDE range: %v", err)
+	}
+	fde.Low, fde.High = low, low+size
+
+	if cie.hasAugData {
+		augLen := r.ULEB128()
+		augEnd := r.Addr() + augLen
+		if cie.LSDAEncoding != PtrOmit {
+			bases := t.bases
+			bases.fn = fde.Low
+			// An LSDA pointer that's 0 before applying the encoding
+			// means there's no LSDA.
+			r2 := *r
+			if raw, _ := readPtr(&r2, cie.LSDAEncoding.Format(), addrSize, bases, t.f); raw != 0 {
+				fde.LSDA, err = readPtr(r, cie.LSDAEncoding, addrSize, bases, t.f)
+				if err != nil {
+					return nil, t.errorf(e.off, "reading LSDA pointer: %v", err)
+				}
+			}
+		}
+		if augEnd < r.Addr() || augEnd-r.Addr() > uint64(r.Avail()) {
+			return nil, t.errorf(e.off, "bad augmentation data length")
+		}
+		r.Skip(int(augEnd - r.Addr()))
+	}
+	fde.Instructions = r.Bytes(r.Avail())
+	if r.Err() != nil {
+		return nil, t.errorf(e.off, "truncated FDE")
+	}
+	return fde, nil
+}
+
+// FDEs returns all of the FDEs in t, in section order.
+func (t *Table) FDEs() ([]*FDE, error) {
+	var fdes []*FDE
+	off := uint64(0)
+	for {
+		e, ok, err := t.readEntry(off)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		off = e.end
+		if e.isCIE {
+			continue
+		}
+		fde, err := t.parseFDE(e)
+		if err != nil {
+			return nil, err
+		}
+		fdes = append(fdes, fde)
+	}
+	return fdes, nil
+}
+
+// FindFDE returns the FDE covering pc, or nil if there isn't one.
+//
+// If t has a .eh_frame_hdr search table, FindFDE uses it. Otherwise,
+// the first call to FindFDE reads all FDEs in t and indexes them.
+func (t *Table) FindFDE(pc uint64) (*FDE, error) {
+	if t.hdr != nil {
+		return t.hdr.find(t, pc)
+	}
+
+	t.indexOnce.Do(func() {
+		fdes, err := t.FDEs()
+		if err != nil {
+			t.indexErr = err
+			return
+		}
+		// Drop empty FDEs, which are often left behind by the linker
+		// for discarded functions.
+		t.index = fdes[:0]
+		for _, fde := range fdes {
+			if fde.Low < fde.High {
+				t.index = append(t.index, fde)
+			}
+		}
+		sort.SliceStable(t.index, func(i, j int) bool {
+			return t.index[i].Low < t.index[j].Low
+		})
+	})
+	if t.indexErr != nil {
+		return nil, t.indexErr
+	}
+	// Find the last FDE starting at or before pc.
+	i := sort.Search(len(t.index), func(i int) bool {
+		return t.index[i].Low > pc
+	}) - 1
+	if i >= 0 && t.index[i].Contains(pc) {
+		return t.index[i], nil
+	}
+	return nil, nil
+}
+
+// ehFrameHdr is a .eh_frame_hdr binary search table.
+//
+// [LSB 5.0, Exception Frames, The .eh_frame_hdr section]
+type ehFrameHdr struct {
+	data     *obj.Data
+	tableEnc PtrEncoding
+	tableOff int // Offset of the table in data
+	count    uint64
+	entSize  int // Size of one table field
+}
+
+func (t *Table) readHdr(s *obj.Section) (*ehFrameHdr, error) {
+	data, err := s.Data(s.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	bases := t.bases
+	bases.data = s.Addr
+	r := obj.NewCheckedReader(data)
+	version := r.Uint8()
+	ehFramePtrEnc := PtrEncoding(r.Uint8())
+	countEnc := PtrEncoding(r.Uint8())
+	tableEnc := PtrEncoding(r.Uint8())
+	if r.Err() != nil {
+		return nil, fmt.Errorf("%s: truncated header", s.Name)
+	}
+	if version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", s.Name, version)
+	}
+	if _, err := readPtr(r, ehFramePtrEnc, t.wordSize(), bases, t.f); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if countEnc == PtrOmit || tableEnc == PtrOmit {
+		// There's no search table.
+		return nil, nil
+	}
+	count, err := readPtr(r, countEnc, t.wordSize(), bases, t.f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	entSize := tableEnc.size(t.wordSize())
+	if entSize == 0 || tableEnc&PtrIndirect != 0 {
+		// We can't binary search a variable-length table.
+		return nil, nil
+	}
+	h := &ehFrameHdr{data: data, tableEnc: tableEnc, tableOff: len(data.B) - r.Avail(), count: count, entSize: entSize}
+	if count > uint64(r.Avail()/(2*entSize)) {
+		return nil, fmt.Errorf("%s: table exceeds section", s.Name)
+	}
+	return h, nil
+}
+
+func (h *ehFrameHdr) find(t *Table, pc uint64) (*FDE, error) {
+	bases := t.bases
+	bases.data = h.data.Addr
+	r := obj.NewCheckedReader(h.data)
+	var err error
+	read := func(i uint64, field int) uint64 {
+		r.SetOffset(h.tableOff + int(i)*2*h.entSize + field*h.entSize)
+		v, err1 := readPtr(r, h.tableEnc, t.wordSize(), bases, t.f)
+		if err1 != nil && err == nil {
+			err = fmt.Errorf(".eh_frame_hdr: %w", err1)
+		}
+		return v
+	}
+	// Find the last entry starting at or before pc.
+	i := sort.Search(int(h.count), func(i int) bool {
+		return read(uint64(i), 0) > pc
+	}) - 1
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		return nil, nil
+	}
+	fdeAddr := read(uint64(i), 1)
+	if err != nil {
+		return nil, err
+	}
+	if fdeAddr < t.sect.Addr || fdeAddr-t.sect.Addr >= uint64(len(t.data.B)) {
+		return nil, fmt.Errorf(".eh_frame_hdr: FDE address %#x is outside .eh_frame", fdeAddr)
+	}
+	fde, err := t.FDE(fdeAddr - t.sect.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if !fde.Contains(pc) {
+		return nil, nil
+	}
+	return fde, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfi
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+func open(t *testing.T, path string) obj.File {
+	t.Helper()
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fp.Close() })
+	f, err := obj.Open(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(f.Close)
+	return f
+}
+
+func symAddr(t *testing.T, f obj.File, name string) uint64 {
+	t.Helper()
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		if sym := f.Sym(i); sym.Name == name && sym.Kind == obj.SymText {
+			return sym.Value
+		}
+	}
+	t.Fatalf("symbol %s not found", name)
+	return 0
+}
+
+var cfiTests = []struct {
+	path  string
+	debug bool
+	// Rows of the "frame" function.
+	rows []string
+}{
+	{"testdata/frames-amd64", false, []string{
+		"+0 cfa=rsp+8 rip=c-8",
+		"+1 cfa=rsp+16 rbp=c-16 rip=c-8",
+		"+4 cfa=rbp+16 rbp=c-16 rip=c-8",
+		"+31 cfa=rsp+8 rbp=c-16 rip=c-8",
+	}},
+	{"testdata/frames-386", false, []string{
+		"+0 cfa=esp+4 eip=c-4",
+		"+1 cfa=esp+8 ebp=c-8 eip=c-4",
+		"+3 cfa=ebp+8 ebp=c-8 eip=c-4",
+		"+38 cfa=esp+4 eip=c-4",
+	}},
+	{"testdata/frames-debug-amd64", true, []string{
+		"+0 cfa=rsp+8 rip=c-8",
+		"+1 cfa=rsp+16 rbp=c-16 rip=c-8",
+		"+4 cfa=rbp+16 rbp=c-16 rip=c-8",
+		"+31 cfa=rsp+8 rbp=c-16 rip=c-8",
+	}},
+}
+
+func newTestTable(t *testing.T, f obj.File, debug bool) *Table {
+	t.Helper()
+	newTable := NewEHFrame
+	if debug {
+		newTable = NewDebugFrame
+	}
+	tab, err := newTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tab
+}
+
+func TestRows(t *testing.T) {
+	for _, test := range cfiTests {
+		t.Run(test.path, func(t *testing.T) {
+			f := open(t, test.path)
+			tab := newTestTable(t, f, test.debug)
+			pc := symAddr(t, f, "frame")
+			fde, err := tab.FindFDE(pc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fde == nil || fde.Low != pc {
+				t.Fatalf("want FDE starting at %#x, got %+v", pc, fde)
+			}
+			rows, err := fde.Rows()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for i, row := range rows {
+				got = append(got, fmt.Sprintf("+%d %s", row.Low-fde.Low, row.String()))
+				if i > 0 && rows[i-1].High != row.Low {
+					t.Errorf("row %d starts at %#x, but previous row ends at %#x", i, row.Low, rows[i-1].High)
+				}
+			}
+			if !reflect.DeepEqual(got, test.rows) {
+				t.Errorf("want rows:\n%q\ngot:\n%q", test.rows, got)
+			}
+			if rows[0].Low != fde.Low || rows[len(rows)-1].High != fde.High {
+				t.Errorf("rows cover [%#x,%#x), want [%#x,%#x)", rows[0].Low, rows[len(rows)-1].High, fde.Low, fde.High)
+			}
+
+			// Check RowAt at every PC.
+			for _, want := range rows {
+				for pc := want.Low; pc < want.High; pc++ {
+					got, err := fde.RowAt(pc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got.Low != want.Low || got.String() != want.String() {
+						t.Errorf("RowAt(%#x): want %s, got %s", pc, want.String(), got.String())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFindFDE(t *testing.T) {
+	for _, test := range cfiTests {
+		t.Run(test.path, func(t *testing.T) {
+			f := open(t, test.path)
+			tab := newTestTable(t, f, test.debug)
+			if !test.debug && tab.hdr == nil {
+				t.Fatalf("no .eh_frame_hdr search table")
+			}
+			fdes, err := tab.FDEs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fdes) < 3 {
+				t.Fatalf("want at least 3 FDEs, got %d", len(fdes))
+			}
+			for _, want := range fdes {
+				for _, pc := range []uint64{want.Low, want.High - 1} {
+					got, err := tab.FindFDE(pc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got == nil || got.Offset != want.Offset {
+						t.Errorf("FindFDE(%#x): want FDE at offset %#x, got %+v", pc, want.Offset, got)
+					}
+				}
+			}
+			for _, pc := range []uint64{0, fdes[len(fdes)-1].High} {
+				if got, err := tab.FindFDE(pc); got != nil || err != nil {
+					t.Errorf("FindFDE(%#x): want nil, got %+v, %v", pc, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNoCFI(t *testing.T) {
+	f := open(t, "testdata/frames-amd64")
+	if _, err := NewDebugFrame(f); err != ErrNoCFI {
+		t.Errorf("want ErrNoCFI, got %v", err)
+	}
+}
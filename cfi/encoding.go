@@ -0,0 +1,195 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfi
+
+import (
+	"fmt"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// PtrEncoding is a DW_EH_PE pointer encoding, as used by .eh_frame,
+// .eh_frame_hdr, and LSDAs. The low 4 bits give the format of the
+// value, and the next 3 bits say what it's relative to.
+//
+// [LSB 5.0, Exception Frames, DWARF Exception Header Encoding]
+type PtrEncoding uint8
+
+const (
+	PtrAbs     PtrEncoding = 0x00
+	PtrULEB128 PtrEncoding = 0x01
+	PtrUdata2  PtrEncoding = 0x02
+	PtrUdata4  PtrEncoding = 0x03
+	PtrUdata8  PtrEncoding = 0x04
+	PtrSLEB128 PtrEncoding = 0x09
+	PtrSdata2  PtrEncoding = 0x0a
+	PtrSdata4  PtrEncoding = 0x0b
+	PtrSdata8  PtrEncoding = 0x0c
+
+	PtrPCRel   PtrEncoding = 0x10
+	PtrTextRel PtrEncoding = 0x20
+	PtrDataRel PtrEncoding = 0x30
+	PtrFuncRel PtrEncoding = 0x40
+	PtrAligned PtrEncoding = 0x50
+
+	// PtrIndirect indicates the decoded value is the address of the
+	// actual pointer.
+	PtrIndirect PtrEncoding = 0x80
+
+	// PtrOmit indicates the value is not present.
+	PtrOmit PtrEncoding = 0xff
+)
+
+// Format returns the value format of e.
+func (e PtrEncoding) Format() PtrEncoding {
+	return e & 0x0f
+}
+
+// Application returns what e's values are relative to.
+func (e PtrEncoding) Application() PtrEncoding {
+	return e & 0x70
+}
+
+func (e PtrEncoding) String() string {
+	if e == PtrOmit {
+		return "omit"
+	}
+	var s string
+	switch e.Format() {
+	case PtrAbs:
+		s = "absptr"
+	case PtrULEB128:
+		s = "uleb128"
+	case PtrUdata2:
+		s = "udata2"
+	case PtrUdata4:
+		s = "udata4"
+	case PtrUdata8:
+		s = "udata8"
+	case PtrSLEB128:
+		s = "sleb128"
+	case PtrSdata2:
+		s = "sdata2"
+	case PtrSdata4:
+		s = "sdata4"
+	case PtrSdata8:
+		s = "sdata8"
+	default:
+		s = fmt.Sprintf("format(%#x)", uint8(e.Format()))
+	}
+	switch e.Application() {
+	case PtrPCRel:
+		s = "pcrel|" + s
+	case PtrTextRel:
+		s = "textrel|" + s
+	case PtrDataRel:
+		s = "datarel|" + s
+	case PtrFuncRel:
+		s = "funcrel|" + s
+	case PtrAligned:
+		s = "aligned|" + s
+	}
+	if e&PtrIndirect != 0 {
+		s = "indirect|" + s
+	}
+	return s
+}
+
+// size returns the number of bytes in a value of encoding e, or 0 if
+// values are variable length.
+func (e PtrEncoding) size(wordSize int) int {
+	switch e.Format() {
+	case PtrAbs:
+		return wordSize
+	case PtrUdata2, PtrSdata2:
+		return 2
+	case PtrUdata4, PtrSdata4:
+		return 4
+	case PtrUdata8, PtrSdata8:
+		return 8
+	}
+	return 0
+}
+
+// ptrBases gives the base addresses for relative pointer encodings.
+type ptrBases struct {
+	text, data, fn uint64
+}
+
+// readPtr reads a pointer in encoding enc from r. The pc-relative base
+// is the address of the value itself. If enc is indirect, readPtr reads
+// the final pointer from f.
+func readPtr(r *obj.Reader, enc PtrEncoding, wordSize int, bases ptrBases, f obj.File) (uint64, error) {
+	if enc == PtrOmit {
+		return 0, nil
+	}
+	if enc.Application() == PtrAligned {
+		r.Align(wordSize)
+	}
+	pc := r.Addr()
+	var val uint64
+	switch enc.Format() {
+	case PtrAbs:
+		switch wordSize {
+		case 4:
+			val = uint64(r.Uint32())
+		case 8:
+			val = r.Uint64()
+		default:
+			return 0, fmt.Errorf("unsupported address size %d", wordSize)
+		}
+	case PtrULEB128:
+		val = r.ULEB128()
+	case PtrUdata2:
+		val = uint64(r.Uint16())
+	case PtrUdata4:
+		val = uint64(r.Uint32())
+	case PtrUdata8:
+		val = r.Uint64()
+	case PtrSLEB128:
+		val = uint64(r.SLEB128())
+	case PtrSdata2:
+		val = uint64(r.Int16())
+	case PtrSdata4:
+		val = uint64(r.Int32())
+	case PtrSdata8:
+		val = uint64(r.Int64())
+	default:
+		return 0, fmt.Errorf("unsupported pointer encoding %s", enc)
+	}
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
+
+	switch enc.Application() {
+	case 0, PtrAligned:
+	case PtrPCRel:
+		val += pc
+	case PtrTextRel:
+		val += bases.text
+	case PtrDataRel:
+		val += bases.data
+	case PtrFuncRel:
+		val += bases.fn
+	default:
+		return 0, fmt.Errorf("unsupported pointer encoding %s", enc)
+	}
+	if wordSize == 4 {
+		val = uint64(uint32(val))
+	}
+
+	if enc&PtrIndirect != 0 {
+		s := f.ResolveAddr(val)
+		if s == nil {
+			return 0, fmt.Errorf("indirect pointer %#x is not in a mapped section", val)
+		}
+		d, err := s.Data(val, uint64(wordSize))
+		if err != nil {
+			return 0, err
+		}
+		val = d.Layout.Word(d.B)
+	}
+	return val, nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// An LSDA is a decoded language-specific data area in the format used
+// by GCC and LLVM for C++ exceptions, which is typically stored in the
+// .gcc_except_table section. It describes which call sites in a
+// function have landing pads, and which exceptions each landing pad
+// handles.
+//
+// [Itanium C++ ABI: Exception Handling, Level II]
+type LSDA struct {
+	// Addr is the address of the LSDA.
+	Addr uint64
+
+	// LPStart is the base address of landing pads. This is typically
+	// the start of the function.
+	LPStart uint64
+
+	// TTypeEncoding is the encoding of entries in the type table, or
+	// PtrOmit if there is no type table.
+	TTypeEncoding PtrEncoding
+
+	// CallSites lists the call sites of the function, sorted by PC.
+	// PCs that aren't covered by any call site can't throw.
+	CallSites []CallSite
+
+	fde        *FDE
+	data       *obj.Data
+	actionBase uint64 // Address of the action table
+	ttypeBase  uint64 // Address of the end of the type table
+}
+
+// A CallSite describes how exceptions are handled for a range of PCs.
+type CallSite struct {
+	// Low and High give the range of PCs [Low, High) covered by this
+	// call site.
+	Low, High uint64
+
+	// LandingPad is the address of the landing pad, or 0 if exceptions
+	// at this call site simply continue unwinding.
+	LandingPad uint64
+
+	// Actions lists the actions taken at the landing pad, in the order
+	// they're tried. If LandingPad is non-zero but Actions is empty,
+	// the landing pad only runs cleanups.
+	Actions []Action
+}
+
+// An Action is an entry in an LSDA's action table.
+type Action struct {
+	// Filter selects which exceptions this action handles. A positive
+	// filter is a catch clause whose type is Type. Zero is a cleanup.
+	// A negative filter is an exception specification.
+	Filter int64
+
+	// Type is the address of the type information of the exception
+	// type caught when Filter is positive. 0 means the catch clause
+	// catches all exceptions. For C++, this is a std::type_info.
+	Type uint64
+}
+
+// ReadLSDA decodes fde's language-specific data area. It returns nil,
+// nil if fde doesn't have an LSDA.
+func (fde *FDE) ReadLSDA() (*LSDA, error) {
+	if fde.LSDA == 0 {
+		return nil, nil
+	}
+	t := fde.t
+	s := t.f.ResolveAddr(fde.LSDA)
+	if s == nil {
+		return nil, fmt.Errorf("LSDA address %#x is not in a mapped section", fde.LSDA)
+	}
+	data, err := s.Data(fde.LSDA, s.Addr+s.Size-fde.LSDA)
+	if err != nil {
+		return nil, err
+	}
+	l := &LSDA{Addr: fde.LSDA, fde: fde, data: data}
+	if err := l.decode(); err != nil {
+		return nil, fmt.Errorf("LSDA at %#x: %w", fde.LSDA, err)
+	}
+	return l, nil
+}
+
+func (l *LSDA) decode() error {
+	t := l.fde.t
+	wordSize := int(l.fde.CIE.AddressSize)
+	bases := t.bases
+	bases.fn = l.fde.Low
+	r := obj.NewCheckedReader(l.data)
+
+	l.LPStart = l.fde.Low
+	if enc := PtrEncoding(r.Uint8()); enc != PtrOmit {
+		var err error
+		l.LPStart, err = readPtr(r, enc, wordSize, bases, t.f)
+		if err != nil {
+			return fmt.Errorf("reading landing pad base: %w", err)
+		}
+	}
+
+	l.TTypeEncoding = PtrEncoding(r.Uint8())
+	if l.TTypeEncoding != PtrOmit {
+		off := r.ULEB128()
+		l.ttypeBase = r.Addr() + off
+	}
+
+	csEnc := PtrEncoding(r.Uint8())
+	csLen := r.ULEB128()
+	if r.Err() != nil {
+		return r.Err()
+	}
+	if csLen > uint64(r.Avail()) {
+		return fmt.Errorf("call site table length %#x exceeds section", csLen)
+	}
+	csEnd := r.Addr() + csLen
+	l.actionBase = csEnd
+
+	// Call site values are offsets, so they use only the format of the
+	// encoding.
+	for r.Addr() < csEnd {
+		start, err := readPtr(r, csEnc.Format(), wordSize, bases, t.f)
+		if err != nil {
+			return fmt.Errorf("reading call site: %w", err)
+		}
+		length, err := readPtr(r, csEnc.Format(), wordSize, bases, t.f)
+		if err != nil {
+			return fmt.Errorf("reading call site: %w", err)
+		}
+		lp, err := readPtr(r, csEnc.Format(), wordSize, bases, t.f)
+		if err != nil {
+			return fmt.Errorf("reading call site: %w", err)
+		}
+		action := r.ULEB128()
+		if r.Err() != nil {
+			return r.Err()
+		}
+
+		cs := CallSite{Low: l.fde.Low + start, High: l.fde.Low + start + length}
+		if lp != 0 {
+			cs.LandingPad = l.LPStart + lp
+		}
+		if action != 0 {
+			cs.Actions, err = l.actions(action - 1)
+			if err != nil {
+				return err
+			}
+		}
+		l.CallSites = append(l.CallSites, cs)
+	}
+	if !sort.SliceIsSorted(l.CallSites, func(i, j int) bool {
+		return l.CallSites[i].Low < l.CallSites[j].Low
+	}) {
+		return fmt.Errorf("call site table is not sorted")
+	}
+	return nil
+}
+
+// actions decodes the chain of actions starting at offset off in the
+// action table.
+func (l *LSDA) actions(off uint64) ([]Action, error) {
+	r := obj.NewCheckedReader(l.data)
+	addr := l.actionBase + off
+	var actions []Action
+	// Limit the chain length in case of a malformed cycle.
+	for i := 0; i < 1024; i++ {
+		if addr < l.data.Addr || addr >= l.data.Addr+uint64(len(l.data.B)) {
+			return nil, fmt.Errorf("action %#x out of range", addr)
+		}
+		r.SetAddr(addr)
+		a := Action{Filter: r.SLEB128()}
+		nextAddr := r.Addr()
+		next := r.SLEB128()
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		if a.Filter > 0 {
+			var err error
+			a.Type, err = l.typeInfo(a.Filter)
+			if err != nil {
+				return nil, err
+			}
+		}
+		actions = append(actions, a)
+		if next == 0 {
+			return actions, nil
+		}
+		// The next offset is relative to the offset field itself.
+		addr = nextAddr + uint64(next)
+	}
+	return nil, fmt.Errorf("action chain too long")
+}
+
+// typeInfo returns the address of type table entry filter. Entries are
+// indexed backwards from the end of the type table, starting at 1.
+func (l *LSDA) typeInfo(filter int64) (uint64, error) {
+	if l.TTypeEncoding == PtrOmit {
+		return 0, fmt.Errorf("catch clause %d without type table", filter)
+	}
+	size := l.TTypeEncoding.size(int(l.fde.CIE.AddressSize))
+	if size == 0 {
+		return 0, fmt.Errorf("unsupported type table encoding %s", l.TTypeEncoding)
+	}
+	addr := l.ttypeBase - uint64(filter)*uint64(size)
+	if addr < l.data.Addr || addr+uint64(size) > l.data.Addr+uint64(len(l.data.B)) {
+		return 0, fmt.Errorf("type table entry %d out of range", filter)
+	}
+	r := obj.NewCheckedReader(l.data)
+	r.SetAddr(addr)
+	t := l.fde.t
+	// A catch-all clause has a 0 entry, which is 0 regardless of the
+	// encoding.
+	r2 := *r
+	if raw, _ := readPtr(&r2, l.TTypeEncoding.Format(), int(l.fde.CIE.AddressSize), t.bases, t.f); raw == 0 {
+		return 0, nil
+	}
+	return readPtr(r, l.TTypeEncoding, int(l.fde.CIE.AddressSize), t.bases, t.f)
+}
+
+// Find returns the call site containing pc, or nil if pc isn't in any
+// call site.
+func (l *LSDA) Find(pc uint64) *CallSite {
+	i := sort.Search(len(l.CallSites), func(i int) bool {
+		return pc < l.CallSites[i].High
+	})
+	if i < len(l.CallSites) && l.CallSites[i].Low <= pc {
+		return &l.CallSites[i]
+	}
+	return nil
+}
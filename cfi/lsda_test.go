@@ -0,0 +1,97 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+func TestLSDA(t *testing.T) {
+	f := open(t, "testdata/except-amd64")
+	tab := newTestTable(t, f, false)
+
+	var typeInt uint64
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		if sym := f.Sym(i); sym.Name == "_ZTIi" && sym.Kind == obj.SymData {
+			typeInt = sym.Value
+		}
+	}
+	if typeInt == 0 {
+		t.Fatal("symbol _ZTIi not found")
+	}
+
+	// Call sites are given as offsets from the function start. Checked
+	// with objdump.
+	for _, test := range []struct {
+		fn   string
+		want []string
+	}{
+		{"_Z7cleanupv", []string{
+			"[+0x5,+0xa) lp=+0x1a []",
+			"[+0x2a,+0x2f) lp=none []",
+		}},
+		{"_Z8handlersv", []string{
+			"[+0x1,+0x6) lp=+0x8 [{Filter:1 Type:_ZTIi} {Filter:2 Type:0x0}]",
+			"[+0x29,+0x33) lp=none []",
+		}},
+		{"main", nil},
+	} {
+		t.Run(test.fn, func(t *testing.T) {
+			fn := symAddr(t, f, test.fn)
+			fde, err := tab.FindFDE(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l, err := fde.ReadLSDA()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.want == nil {
+				if l != nil {
+					t.Fatalf("want no LSDA, got %+v", l)
+				}
+				return
+			}
+			if l == nil {
+				t.Fatal("want LSDA, got none")
+			}
+			var got []string
+			for _, cs := range l.CallSites {
+				lp := "none"
+				if cs.LandingPad != 0 {
+					lp = fmt.Sprintf("+%#x", cs.LandingPad-fn)
+				}
+				var actions []string
+				for _, a := range cs.Actions {
+					typ := fmt.Sprintf("%#x", a.Type)
+					if a.Type == typeInt {
+						typ = "_ZTIi"
+					}
+					actions = append(actions, fmt.Sprintf("{Filter:%d Type:%s}", a.Filter, typ))
+				}
+				got = append(got, fmt.Sprintf("[+%#x,+%#x) lp=%s %v", cs.Low-fn, cs.High-fn, lp, actions))
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("want call sites:\n%q\ngot:\n%q", test.want, got)
+			}
+
+			for i := range l.CallSites {
+				cs := &l.CallSites[i]
+				for _, pc := range []uint64{cs.Low, cs.High - 1} {
+					if got := l.Find(pc); got != cs {
+						t.Errorf("Find(%#x): want call site %d, got %+v", pc, i, got)
+					}
+				}
+			}
+			if got := l.Find(fn); got != nil {
+				t.Errorf("Find(%#x): want nil, got %+v", fn, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+)
+
+// A Row gives the rules for recovering the caller's frame over a range
+// of PCs.
+type Row struct {
+	// Low and High give the range of PCs [Low, High) this row applies
+	// to.
+	Low, High uint64
+
+	// CFA is the rule for computing the Canonical Frame Address, which
+	// is conventionally the value of the stack pointer in the caller
+	// just before the call instruction.
+	CFA CFARule
+
+	// Regs gives the rules for recovering registers in the caller.
+	// Registers that don't appear in Regs have no rule specified, which
+	// unwinders typically treat like RuleSameValue for callee-saved
+	// registers and RuleUndefined for others.
+	Regs map[arch.Reg]Rule
+
+	regs *arch.Regs
+}
+
+// A CFARule is a rule for computing the Canonical Frame Address.
+type CFARule struct {
+	// If Expr is nil, the CFA is the value of register Reg plus Offset.
+	Reg    arch.Reg
+	Offset int64
+
+	// If Expr is non-nil, the CFA is the result of evaluating the DWARF
+	// expression Expr.
+	Expr []byte
+}
+
+// A Rule is a rule for recovering a register in the caller.
+type Rule struct {
+	Kind RuleKind
+
+	// Offset is the offset from the CFA for RuleOffset and
+	// RuleValOffset.
+	Offset int64
+
+	// Reg is the register for RuleRegister.
+	Reg arch.Reg
+
+	// Expr is the DWARF expression for RuleExpression and
+	// RuleValExpression.
+	Expr []byte
+}
+
+// RuleKind is the kind of a register rule.
+//
+// [DWARF 4, section 6.4.1]
+type RuleKind uint8
+
+const (
+	// RuleUndefined means the register can't be recovered.
+	RuleUndefined RuleKind = iota
+	// RuleSameValue means the register has the same value as in the
+	// callee.
+	RuleSameValue
+	// RuleOffset means the register is saved at address CFA+Offset.
+	RuleOffset
+	// RuleValOffset means the register's value is CFA+Offset.
+	RuleValOffset
+	// RuleRegister means the register is saved in register Reg.
+	RuleRegister
+	// RuleExpression means the register is saved at the address
+	// computed by Expr.
+	RuleExpression
+	// RuleValExpression means the register's value is computed by
+	// Expr.
+	RuleValExpression
+)
+
+func (k RuleKind) String() string {
+	switch k {
+	case RuleUndefined:
+		return "undefined"
+	case RuleSameValue:
+		return "same"
+	case RuleOffset:
+		return "offset"
+	case RuleValOffset:
+		return "val_offset"
+	case RuleRegister:
+		return "register"
+	case RuleExpression:
+		return "expression"
+	case RuleValExpression:
+		return "val_expression"
+	}
+	return fmt.Sprintf("RuleKind(%d)", k)
+}
+
+func (r *Row) regName(reg arch.Reg) string {
+	if r.regs == nil {
+		return fmt.Sprintf("r%d", reg)
+	}
+	return r.regs.Name(reg)
+}
+
+func signed(x int64) string {
+	if x < 0 {
+		return fmt.Sprintf("%d", x)
+	}
+	return fmt.Sprintf("+%d", x)
+}
+
+// String formats r in a form similar to "readelf -wF", such as
+// "cfa=rsp+16 rbp=c-16 rip=c-8".
+func (r *Row) String() string {
+	var b strings.Builder
+	if r.CFA.Expr != nil {
+		b.WriteString("cfa=exp")
+	} else {
+		fmt.Fprintf(&b, "cfa=%s%s", r.regName(r.CFA.Reg), signed(r.CFA.Offset))
+	}
+	regs := make([]arch.Reg, 0, len(r.Regs))
+	for reg := range r.Regs {
+		regs = append(regs, reg)
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i] < regs[j] })
+	for _, reg := range regs {
+		rule := r.Regs[reg]
+		fmt.Fprintf(&b, " %s=", r.regName(reg))
+		switch rule.Kind {
+		case RuleUndefined:
+			b.WriteString("u")
+		case RuleSameValue:
+			b.WriteString("s")
+		case RuleOffset:
+			fmt.Fprintf(&b, "c%s", signed(rule.Offset))
+		case RuleValOffset:
+			fmt.Fprintf(&b, "v%s", signed(rule.Offset))
+		case RuleRegister:
+			b.WriteString(r.regName(rule.Reg))
+		case RuleExpression:
+			b.WriteString("exp")
+		case RuleValExpression:
+			b.WriteString("vexp")
+		}
+	}
+	return b.String()
+}
+
+// DWARF call frame instructions.
+//
+// [DWARF 4, section 7.23]
+const (
+	opAdvanceLoc = 0x40 // High 2 bits; low 6 bits are the delta
+	opOffset     = 0x80 // High 2 bits; low 6 bits are the register
+	opRestore    = 0xc0 // High 2 bits; low 6 bits are the register
+
+	opNop                  = 0x00
+	opSetLoc               = 0x01
+	opAdvanceLoc1          = 0x02
+	opAdvanceLoc2          = 0x03
+	opAdvanceLoc4          = 0x04
+	opOffsetExtended       = 0x05
+	opRestoreExtended      = 0x06
+	opUndefined            = 0x07
+	opSameValue            = 0x08
+	opRegister             = 0x09
+	opRememberState        = 0x0a
+	opRestoreState         = 0x0b
+	opDefCFA               = 0x0c
+	opDefCFARegister       = 0x0d
+	opDefCFAOffset         = 0x0e
+	opDefCFAExpression     = 0x0f
+	opExpression           = 0x10
+	opOffsetExtendedSF     = 0x11
+	opDefCFASF             = 0x12
+	opDefCFAOffsetSF       = 0x13
+	opValOffset            = 0x14
+	opValOffsetSF          = 0x15
+	opValExpression        = 0x16
+	opGNUArgsSize          = 0x2e
+	opGNUNegOffsetExtended = 0x2f
+)
+
+// frameState is the state of the CFA program interpreter.
+type frameState struct {
+	cfa  CFARule
+	regs map[arch.Reg]Rule
+}
+
+func (s frameState) copy() frameState {
+	regs := make(map[arch.Reg]Rule, len(s.regs))
+	for k, v := range s.regs {
+		regs[k] = v
+	}
+	return frameState{s.cfa, regs}
+}
+
+// Rows evaluates fde's CFA program and returns its rows in PC order.
+// The rows cover exactly the PC range of fde.
+func (fde *FDE) Rows() ([]Row, error) {
+	var rows []Row
+	err := fde.run(func(row Row) bool {
+		rows = append(rows, row)
+		return true
+	})
+	return rows, err
+}
+
+// RowAt evaluates fde's CFA program up to pc and returns the row that
+// applies at pc.
+func (fde *FDE) RowAt(pc uint64) (Row, error) {
+	if !fde.Contains(pc) {
+		return Row{}, fmt.Errorf("PC %#x is not in FDE range [%#x,%#x)", pc, fde.Low, fde.High)
+	}
+	var out Row
+	err := fde.run(func(row Row) bool {
+		if pc < row.High {
+			out = row
+			return false
+		}
+		return true
+	})
+	return out, err
+}
+
+// run evaluates fde's CFA program and calls emit for each row until
+// emit returns false.
+func (fde *FDE) run(emit func(Row) bool) error {
+	t := fde.t
+	cie := fde.CIE
+
+	state := frameState{regs: make(map[arch.Reg]Rule)}
+	loc := fde.Low
+	// Run the CIE's initial instructions. These establish the initial
+	// rules that DW_CFA_restore reverts to.
+	if _, err := fde.exec(cie.Instructions, &state, nil, &loc, nil); err != nil {
+		return err
+	}
+	initial := state.copy()
+
+	done, err := fde.exec(fde.Instructions, &state, &initial, &loc, func(newLoc uint64) bool {
+		if newLoc > loc {
+			if !emit(Row{Low: loc, High: newLoc, CFA: state.cfa, Regs: state.copy().regs, regs: t.regs}) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil || done {
+		return err
+	}
+	if loc < fde.High {
+		emit(Row{Low: loc, High: fde.High, CFA: state.cfa, Regs: state.regs, regs: t.regs})
+	}
+	return nil
+}
+
+// exec executes CFA instructions prog. When the location advances, it
+// calls advance with the new location, before updating *loc. If advance
+// returns false, exec stops and returns true. initial is the state to
+// restore registers to, or nil while executing the CIE's instructions.
+func (fde *FDE) exec(prog []byte, state *frameState, initial *frameState, loc *uint64, advance func(uint64) bool) (bool, error) {
+	t := fde.t
+	cie := fde.CIE
+	r := obj.NewCheckedReader(&obj.Data{B: prog, Layout: t.data.Layout})
+	var stack []frameState
+
+	errorf := func(format string, args ...interface{}) error {
+		return t.errorf(fde.Offset, "CFA program: %s", fmt.Sprintf(format, args...))
+	}
+	setLoc := func(newLoc uint64) bool {
+		if advance == nil {
+			// The CIE's instructions shouldn't advance the location, but
+			// if they do, there's nothing to emit.
+			*loc = newLoc
+			return true
+		}
+		if !advance(newLoc) {
+			return false
+		}
+		*loc = newLoc
+		return true
+	}
+	restore := func(reg arch.Reg) error {
+		if initial == nil {
+			return errorf("restore in CIE instructions")
+		}
+		if rule, ok := initial.regs[reg]; ok {
+			state.regs[reg] = rule
+		} else {
+			delete(state.regs, reg)
+		}
+		return nil
+	}
+	reg := func() arch.Reg {
+		return arch.Reg(r.ULEB128())
+	}
+
+	for r.Avail() > 0 {
+		op := r.Uint8()
+		var err error
+		switch op & 0xc0 {
+		case opAdvanceLoc:
+			if !setLoc(*loc + uint64(op&0x3f)*cie.CodeAlign) {
+				return true, nil
+			}
+			continue
+		case opOffset:
+			state.regs[arch.Reg(op&0x3f)] = Rule{Kind: RuleOffset, Offset: int64(r.ULEB128()) * cie.DataAlign}
+			continue
+		case opRestore:
+			if err := restore(arch.Reg(op & 0x3f)); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		switch op {
+		case opNop:
+		case opSetLoc:
+			bases := t.bases
+			bases.fn = fde.Low
+			var newLoc uint64
+			newLoc, err = readPtr(r, cie.FDEEncoding, int(cie.AddressSize), bases, t.f)
+			if err != nil {
+				return false, errorf("%v", err)
+			}
+			if newLoc < *loc {
+				return false, errorf("DW_CFA_set_loc moves backwards from %#x to %#x", *loc, newLoc)
+			}
+			if !setLoc(newLoc) {
+				return true, nil
+			}
+		case opAdvanceLoc1:
+			if !setLoc(*loc + uint64(r.Uint8())*cie.CodeAlign) {
+				return true, nil
+			}
+		case opAdvanceLoc2:
+			if !setLoc(*loc + uint64(r.Uint16())*cie.CodeAlign) {
+				return true, nil
+			}
+		case opAdvanceLoc4:
+			if !setLoc(*loc + uint64(r.Uint32())*cie.CodeAlign) {
+				return true, nil
+			}
+		case opOffsetExtended:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleOffset, Offset: int64(r.ULEB128()) * cie.DataAlign}
+		case opRestoreExtended:
+			if err := restore(reg()); err != nil {
+				return false, err
+			}
+		case opUndefined:
+			state.regs[reg()] = Rule{Kind: RuleUndefined}
+		case opSameValue:
+			state.regs[reg()] = Rule{Kind: RuleSameValue}
+		case opRegister:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleRegister, Reg: reg()}
+		case opRememberState:
+			stack = append(stack, state.copy())
+		case opRestoreState:
+			if len(stack) == 0 {
+				return false, errorf("DW_CFA_restore_state with empty stack")
+			}
+			// The CFA rule is not part of the remembered state in the
+			// DWARF specification, but GCC and LLVM both restore it,
+			// and code generators depend on that.
+			*state = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case opDefCFA:
+			rg := reg()
+			state.cfa = CFARule{Reg: rg, Offset: int64(r.ULEB128())}
+		case opDefCFASF:
+			rg := reg()
+			state.cfa = CFARule{Reg: rg, Offset: r.SLEB128() * cie.DataAlign}
+		case opDefCFARegister:
+			if state.cfa.Expr != nil {
+				return false, errorf("DW_CFA_def_cfa_register with expression CFA")
+			}
+			state.cfa.Reg = reg()
+		case opDefCFAOffset:
+			if state.cfa.Expr != nil {
+				return false, errorf("DW_CFA_def_cfa_offset with expression CFA")
+			}
+			state.cfa.Offset = int64(r.ULEB128())
+		case opDefCFAOffsetSF:
+			if state.cfa.Expr != nil {
+				return false, errorf("DW_CFA_def_cfa_offset_sf with expression CFA")
+			}
+			state.cfa.Offset = r.SLEB128() * cie.DataAlign
+		case opDefCFAExpression:
+			state.cfa = CFARule{Expr: r.Bytes(int(r.ULEB128()))}
+		case opExpression:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleExpression, Expr: r.Bytes(int(r.ULEB128()))}
+		case opValExpression:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleValExpression, Expr: r.Bytes(int(r.ULEB128()))}
+		case opOffsetExtendedSF:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleOffset, Offset: r.SLEB128() * cie.DataAlign}
+		case opValOffset:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleValOffset, Offset: int64(r.ULEB128()) * cie.DataAlign}
+		case opValOffsetSF:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleValOffset, Offset: r.SLEB128() * cie.DataAlign}
+		case opGNUArgsSize:
+			// This only matters for adjusting the stack pointer when
+			// transferring control to a landing pad.
+			r.ULEB128()
+		case opGNUNegOffsetExtended:
+			rg := reg()
+			state.regs[rg] = Rule{Kind: RuleOffset, Offset: -int64(r.ULEB128()) * cie.DataAlign}
+		default:
+			return false, errorf("unknown instruction %#x", op)
+		}
+		if r.Err() != nil {
+			return false, errorf("truncated instruction")
+		}
+	}
+	if r.Err() != nil {
+		return false, errorf("truncated instruction")
+	}
+	return false, nil
+}
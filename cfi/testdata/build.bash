@@ -0,0 +1,14 @@
+#!/usr/bin/bash
+# build.bash builds the test binaries for the cfi package.
+
+set -e
+
+CFLAGS="-O0 -nostdlib -fno-stack-protector -fcf-protection=none"
+
+# .eh_frame with .eh_frame_hdr.
+gcc $CFLAGS -m64 -o frames-amd64 frames.c
+gcc $CFLAGS -m32 -o frames-386 frames.c
+# .debug_frame only.
+gcc $CFLAGS -m64 -g -fno-asynchronous-unwind-tables -o frames-debug-amd64 frames.c
+# C++ exception handling tables.
+g++ -O1 -no-pie -fno-inline -o except-amd64 except.cc
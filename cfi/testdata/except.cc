@@ -0,0 +1,38 @@
+// except.cc has functions with a variety of exception handling call
+// sites for testing LSDA decoding.
+
+struct Guard {
+	~Guard();
+};
+
+void mayThrow();
+void onError();
+
+// Cleanup only.
+void cleanup() {
+	Guard g;
+	mayThrow();
+}
+
+// Typed and catch-all handlers.
+int handlers() {
+	try {
+		mayThrow();
+	} catch (int x) {
+		return x;
+	} catch (...) {
+		onError();
+	}
+	mayThrow();
+	return 0;
+}
+
+// noipa keeps the compiler from seeing that these throw or do nothing.
+__attribute__((noipa)) Guard::~Guard() { onError(); }
+__attribute__((noipa)) void mayThrow() { throw 1; }
+__attribute__((noipa)) void onError() {}
+
+int main() {
+	cleanup();
+	return handlers();
+}
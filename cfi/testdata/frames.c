@@ -0,0 +1,19 @@
+// frames.c is a small program with predictable call frame information.
+
+int counter;
+
+__attribute__((noinline)) int leaf(int x) {
+	return x + counter;
+}
+
+__attribute__((noinline)) int frame(int x) {
+	volatile int buf[4];
+	buf[0] = x;
+	return leaf(buf[0]) + 1;
+}
+
+void _start(void) {
+	counter = frame(1);
+	for (;;)
+		;
+}
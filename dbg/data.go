@@ -20,6 +20,12 @@ type Data struct {
 	cus map[CU]*cuData
 
 	inlineRangesCache sync.Map /*[*dwarf.Entry, imap.Imap]*/
+
+	globals struct {
+		once sync.Once
+		idx  globalIndex
+		err  error
+	}
 }
 
 type cuData struct {
@@ -41,3 +47,30 @@ func New(dw *dwarf.Data) (*Data, error) {
 	}
 	return &Data{dw: dw, cuRanges: cuRanges, cus: cus}, nil
 }
+
+// Name returns the name of the DWARF entry e. If e doesn't have a name,
+// but is a concrete instance of an abstract entry or the definition of
+// a declaration, such as an inlined subroutine, this returns the name
+// of that entry. It returns "" if e has no name.
+func (d *Data) Name(e *dwarf.Entry) string {
+	r := d.dw.Reader()
+	// Limit the depth in case of malformed cycles.
+	for i := 0; i < 8 && e != nil; i++ {
+		if name, ok := e.Val(dwarf.AttrName).(string); ok {
+			return name
+		}
+		off, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			off, ok = e.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		}
+		if !ok {
+			break
+		}
+		r.Seek(off)
+		var err error
+		if e, err = r.Next(); err != nil {
+			break
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dbg
+
+import (
+	"debug/dwarf"
+	"fmt"
+)
+
+// globalIndex indexes the top-level entries of all CUs by name.
+type globalIndex struct {
+	vars   map[string]*dwarf.Entry
+	types  map[string]*dwarf.Entry
+	consts map[string]*dwarf.Entry
+}
+
+// opAddr is the DW_OP_addr location operation.
+const opAddr = 0x03
+
+func (d *Data) globalIndex() (*globalIndex, error) {
+	g := &d.globals
+	g.once.Do(func() {
+		idx := globalIndex{
+			vars:   make(map[string]*dwarf.Entry),
+			types:  make(map[string]*dwarf.Entry),
+			consts: make(map[string]*dwarf.Entry),
+		}
+		r := d.dw.Reader()
+		for {
+			ent, err := r.Next()
+			if err != nil {
+				g.err = err
+				return
+			}
+			if ent == nil {
+				break
+			}
+			if ent.Tag == dwarf.TagCompileUnit || ent.Tag == dwarf.TagPartialUnit {
+				// Enter the CU.
+				continue
+			}
+			if ent.Tag == 0 {
+				// End of a CU's children.
+				continue
+			}
+			if ent.Children {
+				r.SkipChildren()
+			}
+			name, ok := ent.Val(dwarf.AttrName).(string)
+			if !ok {
+				continue
+			}
+			switch ent.Tag {
+			case dwarf.TagVariable:
+				// Skip declarations, which have no location.
+				if _, ok := idx.vars[name]; !ok && ent.Val(dwarf.AttrLocation) != nil {
+					idx.vars[name] = ent
+				}
+			case dwarf.TagConstant:
+				if _, ok := idx.consts[name]; !ok {
+					idx.consts[name] = ent
+				}
+			case dwarf.TagTypedef:
+				if _, ok := idx.types[name]; !ok {
+					idx.types[name] = ent
+				}
+			case dwarf.TagBaseType, dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagEnumerationType,
+				dwarf.TagArrayType, dwarf.TagPointerType, dwarf.TagSubroutineType:
+				// Prefer the type itself over a typedef of the same
+				// name, which Go emits for every named type.
+				if old, ok := idx.types[name]; !ok || old.Tag == dwarf.TagTypedef {
+					idx.types[name] = ent
+				}
+			}
+		}
+		g.idx = idx
+	})
+	return &g.idx, g.err
+}
+
+// Type returns the top-level type named name. If there are several
+// types with the same name, such as a struct type and a typedef of it,
+// Type prefers the non-typedef type.
+func (d *Data) Type(name string) (dwarf.Type, error) {
+	idx, err := d.globalIndex()
+	if err != nil {
+		return nil, err
+	}
+	ent, ok := idx.types[name]
+	if !ok {
+		return nil, fmt.Errorf("DWARF type %s not found", name)
+	}
+	return d.dw.Type(ent.Offset)
+}
+
+// Var returns the address and type of the global variable named name.
+// Only variables whose location is a static address are supported.
+func (d *Data) Var(name string) (addr uint64, typ dwarf.Type, err error) {
+	idx, err := d.globalIndex()
+	if err != nil {
+		return 0, nil, err
+	}
+	ent, ok := idx.vars[name]
+	if !ok {
+		return 0, nil, fmt.Errorf("DWARF variable %s not found", name)
+	}
+	loc, ok := ent.Val(dwarf.AttrLocation).([]byte)
+	r := d.dw.Reader()
+	size := r.AddressSize()
+	if !ok || len(loc) != 1+size || loc[0] != opAddr {
+		return 0, nil, fmt.Errorf("DWARF variable %s does not have a static address", name)
+	}
+	order := r.ByteOrder()
+	switch size {
+	case 4:
+		addr = uint64(order.Uint32(loc[1:]))
+	case 8:
+		addr = order.Uint64(loc[1:])
+	default:
+		return 0, nil, fmt.Errorf("unsupported address size %d", size)
+	}
+	off, ok := ent.Val(dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		return 0, nil, fmt.Errorf("DWARF variable %s has no type", name)
+	}
+	typ, err = d.dw.Type(off)
+	if err != nil {
+		return 0, nil, err
+	}
+	return addr, typ, nil
+}
+
+// Const returns the value of the constant named name.
+func (d *Data) Const(name string) (int64, error) {
+	idx, err := d.globalIndex()
+	if err != nil {
+		return 0, err
+	}
+	ent, ok := idx.consts[name]
+	if !ok {
+		return 0, fmt.Errorf("DWARF constant %s not found", name)
+	}
+	switch v := ent.Val(dwarf.AttrConstValue).(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("DWARF constant %s has unsupported value %v", name, ent.Val(dwarf.AttrConstValue))
+}
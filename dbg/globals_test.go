@@ -0,0 +1,63 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dbg
+
+import (
+	"debug/dwarf"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+const helloGo = `package main
+
+var x = 42
+
+func main() {
+	println(x)
+}
+`
+
+func TestGlobals(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, helloGo))
+	dw, err := f.(obj.AsDebugDwarf).AsDebugDwarf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(dw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, typ, err := d.Var("main.x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sym obj.Sym
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		if s := f.Sym(i); s.Name == "main.x" {
+			sym = s
+		}
+	}
+	if addr != sym.Value || typ.String() != "int" {
+		t.Errorf("want main.x at %#x with type int, got %#x with type %s", sym.Value, addr, typ)
+	}
+	if _, _, err := d.Var("main.missing"); err == nil {
+		t.Errorf("Var(main.missing): want error")
+	}
+
+	typ, err = d.Type("runtime.g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := typ.(*dwarf.StructType); !ok || st.StructName != "runtime.g" {
+		t.Errorf("want struct runtime.g, got %T %s", typ, typ)
+	}
+
+	if v, err := d.Const("runtime._Grunning"); err != nil || v != 2 {
+		t.Errorf("want runtime._Grunning = 2, got %d, %v", v, err)
+	}
+}
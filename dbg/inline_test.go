@@ -5,7 +5,6 @@
 package dbg
 
 import (
-	"debug/dwarf"
 	"fmt"
 	"strings"
 	"testing"
@@ -36,20 +35,9 @@ func TestInlineRanges(t *testing.T) {
 }
 
 func inlineString(i *InlineSite, d *Data) string {
-	dr := d.dw.Reader()
 	var buf strings.Builder
 	for i != nil {
-		// Resolve name. It may be on this entry or its abstract origin.
-		name, ok := i.Entry.Val(dwarf.AttrName).(string)
-		if !ok {
-			if ao, ok := i.Entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
-				dr.Seek(ao)
-				if ent, err := dr.Next(); err == nil {
-					name, _ = ent.Val(dwarf.AttrName).(string)
-				}
-			}
-		}
-
+		name := d.Name(i.Entry)
 		buf.WriteString(name)
 		if i.Caller != nil {
 			fmt.Fprintf(&buf, " %s:%d:%d ", i.CallFile.Name, i.CallLine, i.CallColumn)
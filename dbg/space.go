@@ -0,0 +1,80 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dbg
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// Space provides access to the debug info of all of the modules in an
+// obj.AddressSpace. Each module's debug info is loaded on first use.
+type Space struct {
+	as *obj.AddressSpace
+
+	mu   sync.Mutex
+	data map[*obj.Module]*spaceData
+}
+
+type spaceData struct {
+	once sync.Once
+	d    *Data
+	err  error
+}
+
+// NewSpace returns a Space for the modules in as.
+func NewSpace(as *obj.AddressSpace) *Space {
+	return &Space{as: as, data: make(map[*obj.Module]*spaceData)}
+}
+
+// Module returns the debug info for module m. It returns an error if m
+// has no DWARF debug info.
+func (s *Space) Module(m *obj.Module) (*Data, error) {
+	s.mu.Lock()
+	sd := s.data[m]
+	if sd == nil {
+		sd = new(spaceData)
+		s.data[m] = sd
+	}
+	s.mu.Unlock()
+
+	sd.once.Do(func() {
+		f, ok := m.File.(obj.AsDebugDwarf)
+		if !ok {
+			sd.err = fmt.Errorf("%s: no DWARF debug info", m.Name)
+			return
+		}
+		dw, err := f.AsDebugDwarf()
+		if err != nil {
+			sd.err = fmt.Errorf("%s: %w", m.Name, err)
+			return
+		}
+		if dw == nil {
+			sd.err = fmt.Errorf("%s: no DWARF debug info", m.Name)
+			return
+		}
+		sd.d, sd.err = New(dw)
+	})
+	return sd.d, sd.err
+}
+
+// Lookup returns the module containing address addr in the address
+// space, the debug info for that module, and the PC in the module's
+// File corresponding to addr. The PC can be passed to the methods of
+// the returned Data, such as AddrToSubprogram and LineReader.SeekPC.
+//
+// If addr is not in any module, it returns a nil Module and a nil
+// error. If the module has no debug info, it returns the module and an
+// error.
+func (s *Space) Lookup(addr uint64) (m *obj.Module, d *Data, pc uint64, err error) {
+	m, _ = s.as.ResolveAddr(addr)
+	if m == nil {
+		return nil, nil, 0, nil
+	}
+	d, err = s.Module(m)
+	return m, d, m.ToFile(addr), err
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dbg
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"os"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+func TestSpace(t *testing.T) {
+	const path = "testdata/inline"
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	f, err := obj.Open(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Find main.
+	ef, err := elf.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ef.Close()
+	syms, err := ef.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mainPC uint64
+	for _, sym := range syms {
+		if sym.Name == "main" {
+			mainPC = sym.Value
+		}
+	}
+	if mainPC == 0 {
+		t.Fatal("main not found")
+	}
+
+	const bias = 0x100000
+	as := obj.NewAddressSpace()
+	mod := as.Add(path, f, bias)
+	space := NewSpace(as)
+
+	m, d, pc, err := space.Lookup(mainPC + bias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != mod || pc != mainPC {
+		t.Fatalf("Lookup(%#x): want %s at %#x, got %v at %#x", mainPC+bias, path, mainPC, m, pc)
+	}
+	sub, ok := d.AddrToSubprogram(pc, CU{})
+	if !ok || sub.Val(dwarf.AttrName) != "main" {
+		t.Errorf("AddrToSubprogram(%#x): want main, got %v", pc, sub.Entry)
+	}
+	if d2, err := space.Module(m); d2 != d || err != nil {
+		t.Errorf("Module returned different Data on second call")
+	}
+
+	if m, _, _, err := space.Lookup(0); m != nil || err != nil {
+		t.Errorf("Lookup of unmapped address: want nil, nil, got %v, %v", m, err)
+	}
+}
@@ -0,0 +1,454 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dynlink resolves the undefined dynamic symbols of an ELF
+// executable against the shared libraries in a directory tree, the way
+// the dynamic linker would, but without running it.
+package dynlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// DefaultSearchPath is the library search path used when
+// Resolver.DefaultPath is nil.
+var DefaultSearchPath = []string{"/lib64", "/usr/lib64", "/lib", "/usr/lib"}
+
+// A Resolver finds the libraries needed by an executable and binds its
+// undefined symbols to their definitions.
+//
+// The zero Resolver resolves against the root of the host file system.
+type Resolver struct {
+	// Sysroot is the directory that all paths are relative to. This
+	// includes the path passed to Resolve, library search directories,
+	// and the targets of absolute symbolic links. If Sysroot is "",
+	// paths are resolved on the host file system.
+	Sysroot string
+
+	// LibraryPath lists directories to search before each object's
+	// RUNPATH, like the LD_LIBRARY_PATH environment variable.
+	LibraryPath []string
+
+	// DefaultPath lists directories to search after each object's
+	// RUNPATH. If nil, DefaultSearchPath is used.
+	DefaultPath []string
+}
+
+// maxSymlinks limits the number of symbolic links followed while
+// resolving a single path.
+const maxSymlinks = 40
+
+// A Result is the outcome of resolving an executable.
+type Result struct {
+	// Objects lists the executable and the libraries it loads, in load
+	// order. Objects[0] is the executable. Load order is breadth-first
+	// in the order of DT_NEEDED entries, which is also the order in
+	// which objects are searched for symbol definitions.
+	Objects []*Object
+
+	// Bindings lists each undefined dynamic symbol of each object and
+	// the definition it binds to, ordered by object and then symbol.
+	Bindings []Binding
+
+	// Missing lists the libraries that could not be found.
+	Missing []Missing
+}
+
+// An Object is an executable or shared library loaded by the resolver.
+type Object struct {
+	// Path is the path of this object, relative to the Resolver's
+	// Sysroot, after following symbolic links.
+	Path string
+
+	// Needed is the DT_NEEDED name that this object was loaded for, or
+	// "" for the executable.
+	Needed string
+
+	// Parent is the object that first needed this object, or nil for
+	// the executable.
+	Parent *Object
+
+	// File is the object file of this object. It is closed by
+	// Result.Close.
+	File obj.File
+
+	// Dynamic is the dynamic linking information of File.
+	Dynamic *obj.Dynamic
+
+	osFile *os.File
+	defs   map[string][]def
+
+	// definesVersions indicates some symbol defined by this object
+	// has a version, meaning the object has version definitions.
+	definesVersions bool
+}
+
+type def struct {
+	id      obj.SymID
+	version obj.SymVersion
+}
+
+// A Binding is the binding of an undefined dynamic symbol.
+type Binding struct {
+	// From is the object containing the undefined symbol.
+	From *Object
+
+	// Sym is the undefined symbol in From.File.
+	Sym obj.SymID
+
+	// Name is the name of the symbol.
+	Name string
+
+	// Version is the version of the symbol requested by From. Its Name
+	// is "" if the reference is unversioned.
+	Version obj.SymVersion
+
+	// Weak indicates the reference is weak. A weak reference that
+	// doesn't resolve is bound to 0 by the dynamic linker rather than
+	// causing an error.
+	Weak bool
+
+	// To is the object defining the symbol, or nil if the symbol is
+	// unresolved.
+	To *Object
+
+	// Def is the defining symbol in To.File, or obj.NoSym if the symbol
+	// is unresolved.
+	Def obj.SymID
+}
+
+// Resolved reports whether b was bound to a definition.
+func (b Binding) Resolved() bool {
+	return b.To != nil
+}
+
+func (b Binding) String() string {
+	s := fmt.Sprintf("%s: %s%s", b.From.Path, b.Name, b.Version)
+	if b.To == nil {
+		if b.Weak {
+			return s + " => unresolved (weak)"
+		}
+		return s + " => unresolved"
+	}
+	return s + " => " + b.To.Path
+}
+
+// Missing is a needed library that could not be found.
+type Missing struct {
+	// Name is the DT_NEEDED name of the library.
+	Name string
+
+	// NeededBy is the object that needs the library.
+	NeededBy *Object
+}
+
+// Unresolved returns the bindings of r that are not weak and didn't
+// resolve to a definition. The dynamic linker would fail to run the
+// executable with these references.
+func (r *Result) Unresolved() []Binding {
+	var out []Binding
+	for _, b := range r.Bindings {
+		if b.To == nil && !b.Weak {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// Close closes the object files of all Objects in r.
+func (r *Result) Close() {
+	for _, o := range r.Objects {
+		o.close()
+	}
+}
+
+func (o *Object) close() {
+	o.File.Close()
+	o.osFile.Close()
+}
+
+// Resolve loads the executable at path exe, then loads its needed
+// libraries and binds the undefined dynamic symbols of all loaded
+// objects.
+//
+// Libraries that can't be found are reported in Result.Missing rather
+// than as an error. Libraries of a different architecture than exe are
+// skipped during the search, as the dynamic linker does.
+func (r *Resolver) Resolve(exe string) (*Result, error) {
+	res := new(Result)
+	root, err := r.open(exe, nil)
+	if err != nil {
+		return nil, err
+	}
+	res.Objects = append(res.Objects, root)
+
+	// Load needed libraries breadth-first. A library is loaded only
+	// once, even if several objects need it.
+	loaded := make(map[string]*Object)
+	for i := 0; i < len(res.Objects); i++ {
+		o := res.Objects[i]
+		if o.Dynamic.SOName != "" {
+			loaded[o.Dynamic.SOName] = o
+		}
+		for _, name := range o.Dynamic.Needed {
+			if _, ok := loaded[name]; ok {
+				continue
+			}
+			lib, err := r.find(name, o)
+			if err != nil {
+				res.Close()
+				return nil, err
+			}
+			if lib == nil {
+				res.Missing = append(res.Missing, Missing{name, o})
+				// Report each missing library once.
+				loaded[name] = nil
+				continue
+			}
+			lib.Needed = name
+			loaded[name] = lib
+			res.Objects = append(res.Objects, lib)
+		}
+	}
+
+	for _, o := range res.Objects {
+		o.indexDefs()
+	}
+	for _, o := range res.Objects {
+		for i, n := obj.SymID(0), o.File.NumSyms(); i < n; i++ {
+			sym := o.File.Sym(i)
+			if sym.Kind != obj.SymUndef || !sym.Dynamic() || sym.Name == "" {
+				continue
+			}
+			b := Binding{From: o, Sym: i, Name: sym.Name, Version: o.Dynamic.Versions[i], Weak: sym.Weak(), Def: obj.NoSym}
+			b.To, b.Def = lookup(res.Objects, b.Name, b.Version)
+			res.Bindings = append(res.Bindings, b)
+		}
+	}
+	return res, nil
+}
+
+// indexDefs indexes the dynamic symbols defined by o.
+func (o *Object) indexDefs() {
+	o.defs = make(map[string][]def)
+	for i, n := obj.SymID(0), o.File.NumSyms(); i < n; i++ {
+		sym := o.File.Sym(i)
+		if sym.Kind == obj.SymUndef || sym.Kind == obj.SymSection || !sym.Dynamic() || sym.Local() {
+			continue
+		}
+		v := o.Dynamic.Versions[i]
+		o.defs[sym.Name] = append(o.defs[sym.Name], def{i, v})
+		if v.Name != "" {
+			o.definesVersions = true
+		}
+	}
+}
+
+// lookup finds the first definition of name at version in scope.
+func lookup(scope []*Object, name string, version obj.SymVersion) (*Object, obj.SymID) {
+	for _, o := range scope {
+		for _, d := range o.defs[name] {
+			if version.Name != "" {
+				// A versioned reference binds only to that version,
+				// unless the defining object doesn't define any
+				// versions, in which case the dynamic linker accepts
+				// any definition. The object may still require
+				// versions from other libraries.
+				if d.version.Name != version.Name && o.definesVersions {
+					continue
+				}
+			} else if d.version.Hidden {
+				// An unversioned reference binds only to the default
+				// version of a symbol.
+				continue
+			}
+			// Weak definitions bind just like strong definitions.
+			return o, d.id
+		}
+	}
+	return nil, obj.NoSym
+}
+
+// find searches for library name needed by o. It returns nil, nil if
+// the library isn't found.
+func (r *Resolver) find(name string, o *Object) (*Object, error) {
+	if strings.Contains(name, "/") {
+		return r.try(name, o)
+	}
+
+	var dirs []string
+	addDirs := func(from *Object, list []string) {
+		for _, dir := range list {
+			if dir, ok := expand(dir, from); ok {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	// DT_RPATH is only used if the object doesn't have a DT_RUNPATH.
+	// The RPATHs of the whole chain of loading objects apply.
+	if len(o.Dynamic.RunPath) == 0 {
+		for p := o; p != nil; p = p.Parent {
+			addDirs(p, p.Dynamic.RPath)
+		}
+	}
+	dirs = append(dirs, r.LibraryPath...)
+	addDirs(o, o.Dynamic.RunPath)
+	if r.DefaultPath == nil {
+		dirs = append(dirs, DefaultSearchPath...)
+	} else {
+		dirs = append(dirs, r.DefaultPath...)
+	}
+
+	for _, dir := range dirs {
+		lib, err := r.try(path.Join(dir, name), o)
+		if lib != nil || err != nil {
+			return lib, err
+		}
+	}
+	return nil, nil
+}
+
+// expand expands the dynamic string tokens in search directory dir of
+// object o. It returns false if dir contains an unsupported token.
+func expand(dir string, o *Object) (string, bool) {
+	origin := path.Dir(o.Path)
+	dir = strings.Replace(dir, "${ORIGIN}", origin, -1)
+	dir = strings.Replace(dir, "$ORIGIN", origin, -1)
+	if strings.Contains(dir, "$") {
+		return "", false
+	}
+	return dir, true
+}
+
+// try opens the library at path p on behalf of parent. It returns nil,
+// nil if p doesn't exist or isn't a compatible object file.
+func (r *Resolver) try(p string, parent *Object) (*Object, error) {
+	lib, err := r.open(p, parent)
+	if err != nil {
+		var fe *formatError
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) || errors.As(err, &fe) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// Skip libraries for other architectures.
+	var root *Object
+	for root = parent; root.Parent != nil; root = root.Parent {
+	}
+	if lib.File.Info().Arch != root.File.Info().Arch {
+		lib.close()
+		return nil, nil
+	}
+	return lib, nil
+}
+
+// A formatError indicates a file isn't a usable object file.
+type formatError struct {
+	path string
+	err  error
+}
+
+func (e *formatError) Error() string {
+	return e.path + ": " + e.err.Error()
+}
+
+func (e *formatError) Unwrap() error {
+	return e.err
+}
+
+// open opens the object file at path p. If p isn't a usable object
+// file, it returns a *formatError.
+func (r *Resolver) open(p string, parent *Object) (*Object, error) {
+	real, err := r.realPath(p)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(r.hostPath(real))
+	if err != nil {
+		return nil, err
+	}
+	file, err := obj.Open(f)
+	if err != nil {
+		f.Close()
+		return nil, &formatError{p, err}
+	}
+	dyn, err := obj.ReadDynamic(file)
+	if err != nil {
+		file.Close()
+		f.Close()
+		return nil, &formatError{p, err}
+	}
+	return &Object{Path: real, Parent: parent, File: file, Dynamic: dyn, osFile: f}, nil
+}
+
+// hostPath returns the host path of sysroot-relative path p.
+func (r *Resolver) hostPath(p string) string {
+	root := r.Sysroot
+	if root == "" {
+		root = "/"
+	}
+	return filepath.Join(root, filepath.FromSlash(p))
+}
+
+// realPath resolves all symbolic links in sysroot-relative path p,
+// keeping the result within the sysroot. Absolute link targets are
+// interpreted relative to the sysroot and ".." never leaves it.
+func (r *Resolver) realPath(p string) (string, error) {
+	var done []string
+	todo := splitPath(p)
+	links := 0
+	for len(todo) > 0 {
+		elem := todo[0]
+		todo = todo[1:]
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			if len(done) > 0 {
+				done = done[:len(done)-1]
+			}
+			continue
+		}
+		cur := "/" + path.Join(append(done, elem)...)
+		fi, err := os.Lstat(r.hostPath(cur))
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			done = append(done, elem)
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return "", &os.PathError{Op: "open", Path: p, Err: fmt.Errorf("too many levels of symbolic links")}
+		}
+		target, err := os.Readlink(r.hostPath(cur))
+		if err != nil {
+			return "", err
+		}
+		target = filepath.ToSlash(target)
+		if strings.HasPrefix(target, "/") {
+			done = nil
+		}
+		todo = append(splitPath(target), todo...)
+	}
+	return "/" + path.Join(done...), nil
+}
+
+func splitPath(p string) []string {
+	var out []string
+	for _, elem := range strings.Split(p, "/") {
+		if elem != "" {
+			out = append(out, elem)
+		}
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynlink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+func TestResolve(t *testing.T) {
+	r := &Resolver{Sysroot: "testdata/sysroot"}
+	res, err := r.Resolve("/opt/app/bin/exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	var paths []string
+	for _, o := range res.Objects {
+		paths = append(paths, o.Path)
+	}
+	// The i386 libbase.so in /opt/app/lib must be skipped, and /lib is
+	// an absolute symlink that must resolve within the sysroot.
+	wantPaths := []string{"/opt/app/bin/exe", "/opt/app/lib/libapp.so", "/usr/lib/libbase.so"}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("want objects %v, got %v", wantPaths, paths)
+	}
+	if len(res.Missing) != 0 {
+		t.Errorf("want no missing libraries, got %v", res.Missing)
+	}
+
+	var got []string
+	for _, b := range res.Bindings {
+		got = append(got, b.String())
+	}
+	want := []string{
+		"/opt/app/bin/exe: app_func => /opt/app/lib/libapp.so",
+		// A versioned reference binds to the hidden version.
+		"/opt/app/bin/exe: base_func@BASE_1 => /usr/lib/libbase.so",
+		"/opt/app/bin/exe: missing_weak => unresolved (weak)",
+		"/opt/app/bin/exe: weak_def@BASE_1 => /usr/lib/libbase.so",
+		// The first definition in load order wins.
+		"/opt/app/bin/exe: shared_sym => /opt/app/lib/libapp.so",
+		"/opt/app/lib/libapp.so: app_missing => unresolved",
+		// libapp was linked against the default version.
+		"/opt/app/lib/libapp.so: base_func@BASE_2 => /usr/lib/libbase.so",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want bindings:\n%q\ngot:\n%q", want, got)
+	}
+
+	for _, b := range res.Bindings {
+		if b.To == nil {
+			continue
+		}
+		if def := b.To.File.Sym(b.Def); def.Name != b.Name {
+			t.Errorf("%v: bound to symbol %q", b, def.Name)
+		}
+	}
+	for _, b := range res.Bindings {
+		if b.Name == "base_func" {
+			if b.To == nil {
+				t.Errorf("%v: not resolved", b)
+			} else if v := b.To.Dynamic.Versions[b.Def]; v.Name != b.Version.Name {
+				t.Errorf("%v: bound to version %v", b, v)
+			}
+		}
+	}
+
+	unresolved := res.Unresolved()
+	if len(unresolved) != 1 || unresolved[0].Name != "app_missing" {
+		t.Errorf("want app_missing unresolved, got %v", unresolved)
+	}
+}
+
+func TestResolveMissing(t *testing.T) {
+	// Without the default search path, libapp.so's dependency can't be
+	// found, but the executable's RUNPATH finds libapp.so.
+	r := &Resolver{Sysroot: "testdata/sysroot", DefaultPath: []string{}}
+	res, err := r.Resolve("/opt/app/bin/exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	if len(res.Objects) != 2 {
+		t.Errorf("want 2 objects, got %d", len(res.Objects))
+	}
+	if len(res.Missing) != 1 || res.Missing[0].Name != "libbase.so" || res.Missing[0].NeededBy != res.Objects[0] {
+		t.Errorf("want libbase.so missing from executable, got %v", res.Missing)
+	}
+	var names []string
+	for _, b := range res.Unresolved() {
+		names = append(names, b.Name)
+	}
+	want := []string{"base_func", "weak_def", "app_missing", "base_func"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("want unresolved %v, got %v", want, names)
+	}
+}
+
+func TestRealPath(t *testing.T) {
+	r := &Resolver{Sysroot: "testdata/sysroot"}
+	for _, test := range []struct{ in, want string }{
+		{"/lib/libbase.so", "/usr/lib/libbase.so"},
+		{"/../../lib/./libbase.so", "/usr/lib/libbase.so"},
+		{"/opt/app/bin/../lib/libapp.so", "/opt/app/lib/libapp.so"},
+	} {
+		got, err := r.realPath(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+		} else if got != test.want {
+			t.Errorf("%s: want %s, got %s", test.in, test.want, got)
+		}
+	}
+}
+
+func TestLookupVersions(t *testing.T) {
+	// An object that requires versions from other libraries but
+	// doesn't define any versions itself.
+	needer := &Object{
+		Path:    "needer",
+		Dynamic: &obj.Dynamic{Versions: map[obj.SymID]obj.SymVersion{1: {Name: "GLIBC_2.2.5", Library: "libc.so.6"}}},
+		defs:    map[string][]def{"foo": {{2, obj.SymVersion{}}}},
+	}
+	// An object that defines versions.
+	definer := &Object{
+		Path:            "definer",
+		Dynamic:         &obj.Dynamic{Versions: map[obj.SymID]obj.SymVersion{3: {Name: "V2"}}},
+		defs:            map[string][]def{"bar": {{3, obj.SymVersion{Name: "V2"}}}},
+		definesVersions: true,
+	}
+	for _, test := range []struct {
+		name, version string
+		want          *Object
+	}{
+		{"foo", "V1", needer},
+		{"foo", "", needer},
+		{"bar", "V2", definer},
+		{"bar", "V1", nil},
+		{"bar", "", definer},
+	} {
+		got, _ := lookup([]*Object{needer, definer}, test.name, obj.SymVersion{Name: test.version})
+		if got != test.want {
+			t.Errorf("lookup(%s@%s): want %v, got %v", test.name, test.version, test.want, got)
+		}
+	}
+}
+
+func TestFindErrors(t *testing.T) {
+	parent, err := (&Resolver{Sysroot: "testdata/sysroot"}).open("/opt/app/bin/exe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.close()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "lib", "libbase.so"), []byte("not an object file"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("loop", filepath.Join(dir, "loop")); err != nil {
+		t.Skip(err)
+	}
+
+	// Missing libraries and files that aren't object files are skipped.
+	r := &Resolver{Sysroot: dir, DefaultPath: []string{"/missing", "/lib"}}
+	if lib, err := r.find("libbase.so", parent); lib != nil || err != nil {
+		t.Errorf("want library skipped, got %v, %v", lib, err)
+	}
+
+	// Other errors are reported.
+	r = &Resolver{Sysroot: dir, DefaultPath: []string{"/loop"}}
+	if lib, err := r.find("libbase.so", parent); err == nil {
+		t.Errorf("want error for symbolic link loop, got %v", lib)
+		lib.close()
+	}
+}
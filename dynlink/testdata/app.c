@@ -0,0 +1,6 @@
+extern int base_func(void);
+extern int app_missing(void);
+
+int shared_sym = 2;
+
+int app_func(void) { return base_func() + app_missing(); }
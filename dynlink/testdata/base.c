@@ -0,0 +1,8 @@
+int base_func_v1(void) { return 1; }
+int base_func_v2(void) { return 2; }
+__asm__(".symver base_func_v1, base_func@BASE_1");
+__asm__(".symver base_func_v2, base_func@@BASE_2");
+
+int shared_sym = 1;
+
+__attribute__((weak)) int weak_def(void) { return 0; }
@@ -0,0 +1,17 @@
+#!/usr/bin/bash
+# build.bash builds a small sysroot for testing the resolver.
+
+set -e
+
+rm -rf sysroot
+mkdir -p sysroot/usr/lib sysroot/opt/app/lib sysroot/opt/app/bin
+ln -s /usr/lib sysroot/lib
+
+CFLAGS="-nostdlib -fPIC -O2 -fno-asynchronous-unwind-tables"
+
+gcc $CFLAGS -shared -Wl,-soname,libbase.so -Wl,--version-script=base.map -o sysroot/usr/lib/libbase.so base.c
+# A library of the wrong architecture that appears earlier in the search
+# path and must be skipped.
+gcc $CFLAGS -m32 -shared -Wl,-soname,libbase.so -Wl,--version-script=base.map -o sysroot/opt/app/lib/libbase.so base.c
+gcc $CFLAGS -shared -Wl,-soname,libapp.so -o sysroot/opt/app/lib/libapp.so app.c sysroot/usr/lib/libbase.so
+gcc $CFLAGS -Wl,--allow-shlib-undefined -Wl,--enable-new-dtags -Wl,-rpath,'$ORIGIN/../lib' -o sysroot/opt/app/bin/exe exe.c sysroot/opt/app/lib/libapp.so sysroot/usr/lib/libbase.so
@@ -0,0 +1,16 @@
+extern int base_func_old(void);
+__asm__(".symver base_func_old, base_func@BASE_1");
+
+extern int app_func(void);
+extern int shared_sym;
+extern int weak_def(void);
+__attribute__((weak)) extern int missing_weak(void);
+
+void _start(void) {
+	base_func_old();
+	app_func();
+	weak_def();
+	if (missing_weak)
+		missing_weak();
+	shared_sym++;
+}
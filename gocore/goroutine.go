@@ -0,0 +1,380 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocore
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"sort"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/symname"
+	"github.com/aclements/go-obj/unwind"
+)
+
+// A GStatus is the scheduling status of a goroutine.
+type GStatus uint32
+
+// These match the runtime's _G* constants, which have been stable
+// since Go 1.0, with new states added at the end.
+const (
+	GIdle      GStatus = 0
+	GRunnable  GStatus = 1
+	GRunning   GStatus = 2
+	GSyscall   GStatus = 3
+	GWaiting   GStatus = 4
+	GDead      GStatus = 6
+	GCopyStack GStatus = 8
+	GPreempted GStatus = 9
+	GLeaked    GStatus = 10 // Go 1.26 and later
+	GDeadExtra GStatus = 11 // Go 1.26 and later
+
+	// gScan is set in addition to another status while the garbage
+	// collector is scanning the goroutine's stack.
+	gScan GStatus = 0x1000
+)
+
+var gStatusNames = [...]string{
+	GIdle:      "idle",
+	GRunnable:  "runnable",
+	GRunning:   "running",
+	GSyscall:   "syscall",
+	GWaiting:   "waiting",
+	GDead:      "dead",
+	GCopyStack: "copystack",
+	GPreempted: "preempted",
+	GLeaked:    "leaked",
+	GDeadExtra: "deadextra",
+}
+
+func (s GStatus) String() string {
+	if int(s) < len(gStatusNames) && gStatusNames[s] != "" {
+		return gStatusNames[s]
+	}
+	return fmt.Sprintf("GStatus(%d)", uint32(s))
+}
+
+// A Goroutine is a goroutine in the process.
+type Goroutine struct {
+	// Addr is the address of the runtime's g structure.
+	Addr uint64
+
+	// ID is the goroutine ID, as shown in tracebacks.
+	ID uint64
+
+	// Status is the goroutine's scheduling status.
+	Status GStatus
+
+	// WaitReason describes why the goroutine is blocked, such as
+	// "chan receive", if Status is GWaiting. Otherwise it is "".
+	WaitReason string
+
+	// CreatePC is the PC of the go statement that created the
+	// goroutine, and StartPC is the entry PC of the goroutine's
+	// function.
+	CreatePC, StartPC uint64
+
+	// StackLo and StackHi give the bounds [StackLo, StackHi) of the
+	// goroutine's stack.
+	StackLo, StackHi uint64
+
+	// Thread is the thread running the goroutine, or nil if it isn't
+	// running on a thread or the process's threads are unknown.
+	Thread *obj.Thread
+
+	// schedSP, schedPC, and schedBP are the registers saved in g.sched when
+	// the goroutine was last descheduled.
+	schedSP, schedPC, schedBP uint64
+}
+
+// gLayout is the layout of the runtime's goroutine structures.
+type gLayout struct {
+	allgs uint64 // Address of runtime.allgs
+
+	gSize                       uint64
+	stackLo, stackHi            field
+	schedSP, schedPC, schedBP   field
+	m, status, goid, waitreason field
+	gopc, startpc               field
+	mSize                       uint64
+	procid                      field
+	waitReasons, nWaitReasons   uint64
+}
+
+// gLayout returns the layout of the runtime's goroutine structures.
+func (p *Process) gLayout() (*gLayout, error) {
+	p.g.once.Do(func() {
+		p.g.l, p.g.err = p.readGLayout()
+	})
+	return p.g.l, p.g.err
+}
+
+func (p *Process) readGLayout() (*gLayout, error) {
+	var l gLayout
+	var err error
+	if l.allgs, _, err = p.global("runtime.allgs"); err != nil {
+		return nil, err
+	}
+
+	var types [4]structType
+	for i, name := range []string{"runtime.g", "runtime.stack", "runtime.gobuf", "runtime.m"} {
+		if types[i], err = p.structType(name); err != nil {
+			return nil, err
+		}
+	}
+	g, stack, gobuf, m := types[0], types[1], types[2], types[3]
+
+	// Get the fields we need, recording the first error. Fields of
+	// embedded structures are offset by the offset of the structure.
+	get := func(s structType, base field, f *field, names ...string) {
+		if err == nil {
+			*f, err = s.field(names...)
+			f.off += base.off
+		}
+	}
+	var gStack, gSched field
+	get(g, field{}, &gStack, "stack")
+	get(g, field{}, &gSched, "sched")
+	get(stack, gStack, &l.stackLo, "lo")
+	get(stack, gStack, &l.stackHi, "hi")
+	get(gobuf, gSched, &l.schedSP, "sp")
+	get(gobuf, gSched, &l.schedPC, "pc")
+	get(gobuf, gSched, &l.schedBP, "bp")
+	get(g, field{}, &l.m, "m")
+	get(g, field{}, &l.status, "atomicstatus")
+	get(g, field{}, &l.goid, "goid")
+	get(g, field{}, &l.waitreason, "waitreason")
+	get(g, field{}, &l.gopc, "gopc")
+	get(g, field{}, &l.startpc, "startpc")
+	get(m, field{}, &l.procid, "procid")
+	if err != nil {
+		return nil, fmt.Errorf("reading runtime goroutine layout: %w", err)
+	}
+	if l.waitreason.size > 8 {
+		// Before Go 1.11, this was a string.
+		return nil, fmt.Errorf("runtime.g.waitreason has unsupported type %s", l.waitreason.typ)
+	}
+	l.gSize, l.mSize = uint64(g.ByteSize), uint64(m.ByteSize)
+	for _, f := range []struct {
+		name string
+		f    field
+	}{
+		{"stack.lo", l.stackLo}, {"stack.hi", l.stackHi},
+		{"sched.sp", l.schedSP}, {"sched.pc", l.schedPC}, {"sched.bp", l.schedBP},
+		{"m", l.m}, {"atomicstatus", l.status}, {"goid", l.goid},
+		{"waitreason", l.waitreason}, {"gopc", l.gopc}, {"startpc", l.startpc},
+	} {
+		if err := checkUint("runtime.g."+f.name, f.f, g.ByteSize); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkUint("runtime.m.procid", l.procid, m.ByteSize); err != nil {
+		return nil, err
+	}
+
+	// Wait reasons are indexes into this array of strings. Go 1.11
+	// and later.
+	addr, typ, err := p.global("runtime.waitReasonStrings")
+	if err == nil {
+		if arr, ok := stripTypedefs(typ).(*dwarf.ArrayType); ok && arr.Count > 0 {
+			l.waitReasons, l.nWaitReasons = addr, uint64(arr.Count)
+		}
+	}
+
+	return &l, nil
+}
+
+// Goroutines returns the goroutines in the process that aren't dead,
+// sorted by ID.
+func (p *Process) Goroutines() ([]*Goroutine, error) {
+	l, err := p.gLayout()
+	if err != nil {
+		return nil, err
+	}
+	ws := uint64(p.arch.Layout.WordSize())
+
+	hdr, err := p.read(l.allgs, 2*ws)
+	if err != nil {
+		return nil, fmt.Errorf("reading goroutine list: %w", err)
+	}
+	base, n := p.arch.Layout.Word(hdr), p.arch.Layout.Word(hdr[ws:])
+	if n > 1<<24 {
+		return nil, fmt.Errorf("goroutine list length %d is too large", n)
+	}
+	ptrs, err := p.read(base, n*ws)
+	if err != nil {
+		return nil, fmt.Errorf("reading goroutine list: %w", err)
+	}
+
+	var gs []*Goroutine
+	for i := uint64(0); i < n; i++ {
+		g, err := p.goroutine(l, p.arch.Layout.Word(ptrs[i*ws:]))
+		if err != nil {
+			return nil, err
+		}
+		if g.Status == GDead || g.Status == GDeadExtra {
+			continue
+		}
+		gs = append(gs, g)
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		return gs[i].ID < gs[j].ID
+	})
+	return gs, nil
+}
+
+// goroutine decodes the runtime g structure at addr.
+func (p *Process) goroutine(l *gLayout, addr uint64) (*Goroutine, error) {
+	b, err := p.read(addr, l.gSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading goroutine at %#x: %w", addr, err)
+	}
+	g := &Goroutine{
+		Addr:     addr,
+		ID:       p.uint(b, l.goid),
+		Status:   GStatus(p.uint(b, l.status)) &^ gScan,
+		CreatePC: p.uint(b, l.gopc),
+		StartPC:  p.uint(b, l.startpc),
+		StackLo:  p.uint(b, l.stackLo),
+		StackHi:  p.uint(b, l.stackHi),
+		schedSP:  p.uint(b, l.schedSP),
+		schedPC:  p.uint(b, l.schedPC),
+		schedBP:  p.uint(b, l.schedBP),
+	}
+	if g.Status == GWaiting {
+		if g.WaitReason, err = p.waitReason(l, p.uint(b, l.waitreason)); err != nil {
+			return nil, fmt.Errorf("reading goroutine %d: %w", g.ID, err)
+		}
+	}
+	if m := p.uint(b, l.m); m != 0 && p.threads != nil {
+		mb, err := p.read(m, l.mSize)
+		if err != nil {
+			return nil, fmt.Errorf("reading M of goroutine %d: %w", g.ID, err)
+		}
+		procid := p.uint(mb, l.procid)
+		for i := range p.threads {
+			if uint64(p.threads[i].ID) == procid {
+				g.Thread = &p.threads[i]
+				break
+			}
+		}
+	}
+	return g, nil
+}
+
+// waitReason returns the description of wait reason number reason.
+func (p *Process) waitReason(l *gLayout, reason uint64) (string, error) {
+	if reason >= l.nWaitReasons {
+		return fmt.Sprintf("waitReason(%d)", reason), nil
+	}
+	ws := uint64(p.arch.Layout.WordSize())
+	hdr, err := p.read(l.waitReasons+reason*2*ws, 2*ws)
+	if err != nil {
+		return "", fmt.Errorf("reading wait reason: %w", err)
+	}
+	str, n := p.arch.Layout.Word(hdr), p.arch.Layout.Word(hdr[ws:])
+	if n > 1<<10 {
+		return "", fmt.Errorf("wait reason length %d is too large", n)
+	}
+	s, err := p.read(str, n)
+	if err != nil {
+		return "", fmt.Errorf("reading wait reason: %w", err)
+	}
+	return string(s), nil
+}
+
+// Stack unwinds the stack of goroutine g and returns its frames from
+// innermost to outermost. If max > 0, Stack returns at most max frames.
+//
+// If g is running on a thread, Stack starts from the thread's
+// registers. If the thread is running a signal handler, such as when
+// a Go program crashes, Stack starts from the registers the signal
+// interrupted. Otherwise, Stack starts from the registers saved when g
+// was last descheduled. Unwinding stops at runtime.goexit, which is the
+// outermost frame of every goroutine. If unwinding fails partway, Stack
+// returns the frames it recovered along with an error.
+func (p *Process) Stack(g *Goroutine, max int) ([]unwind.Frame, error) {
+	u, err := p.unwinder()
+	if err != nil {
+		return nil, err
+	}
+	ar := p.arch.Regs
+	var regs map[arch.Reg]uint64
+	if g.Thread != nil {
+		regs = p.threadRegs(u, g)
+	}
+	if regs == nil {
+		if g.schedSP == 0 {
+			return nil, fmt.Errorf("goroutine %d has no saved registers", g.ID)
+		}
+		regs = map[arch.Reg]uint64{ar.PC: g.schedPC, ar.SP: g.schedSP, ar.FP: g.schedBP}
+	}
+
+	frames, err := u.Unwind(regs, max)
+	for i, f := range frames {
+		if isRuntimeFunc(f.Func, "goexit") {
+			return frames[:i+1], nil
+		}
+	}
+	return frames, err
+}
+
+// sigContexts gives the offsets of the interrupted registers in the
+// ucontext the Linux kernel passes to a signal handler.
+var sigContexts = map[*arch.Arch]struct{ pc, sp, fp uint64 }{
+	arch.AMD64: {pc: 168, sp: 160, fp: 120},
+}
+
+// threadRegs returns the registers of g on its thread, or nil if the
+// thread isn't running on g's stack.
+func (p *Process) threadRegs(u *unwind.Unwinder, g *Goroutine) map[arch.Reg]uint64 {
+	ar := p.arch.Regs
+	onStack := func(sp uint64) bool {
+		return g.StackLo <= sp && sp < g.StackHi
+	}
+	regs := g.Thread.Regs
+	if onStack(regs[ar.SP]) {
+		return regs
+	}
+
+	// Look for a signal handler on the thread's signal stack. The
+	// kernel pushes the ucontext at the canonical frame address of
+	// runtime.sigtramp, which is the stack pointer of its "caller".
+	sc, ok := sigContexts[p.arch]
+	if !ok {
+		return nil
+	}
+	frames, _ := u.Unwind(regs, 32)
+	for i := 0; i+1 < len(frames); i++ {
+		if !isRuntimeFunc(frames[i].Func, "sigtramp") {
+			continue
+		}
+		uc := frames[i+1].SP
+		pc, err1 := p.word(uc + sc.pc)
+		sp, err2 := p.word(uc + sc.sp)
+		fp, err3 := p.word(uc + sc.fp)
+		if err1 != nil || err2 != nil || err3 != nil || !onStack(sp) {
+			return nil
+		}
+		return map[arch.Reg]uint64{ar.PC: pc, ar.SP: sp, ar.FP: fp}
+	}
+	return nil
+}
+
+// isRuntimeFunc reports whether symbol name is runtime function fn or
+// its ABI wrapper.
+func isRuntimeFunc(name, fn string) bool {
+	n := symname.Parse(name)
+	return n.Package == "runtime" && n.Receiver == "" && n.Func == fn && !n.IsClosure()
+}
+
+// unwinder returns the stack unwinder for the process.
+func (p *Process) unwinder() (*unwind.Unwinder, error) {
+	p.unwind.once.Do(func() {
+		p.unwind.u, p.unwind.err = unwind.New(p.as, p.mem)
+	})
+	return p.unwind.u, p.unwind.err
+}
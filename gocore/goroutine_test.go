@@ -0,0 +1,140 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/symtab"
+	"github.com/aclements/go-obj/unwind"
+)
+
+const goroutineGo = `package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func recv(ch chan int) {
+	<-ch
+}
+
+//go:noinline
+func blocked(ch chan int) {
+	recv(ch)
+}
+
+func main() {
+	var lim syscall.Rlimit
+	syscall.Getrlimit(syscall.RLIMIT_CORE, &lim)
+	lim.Cur = lim.Max
+	syscall.Setrlimit(syscall.RLIMIT_CORE, &lim)
+
+	ch := make(chan int)
+	for i := 0; i < 3; i++ {
+		go blocked(ch)
+	}
+	time.Sleep(100 * time.Millisecond)
+	os.Stdout.Sync()
+	panic("crash")
+}
+`
+
+func TestGoroutines(t *testing.T) {
+	p, _ := loadCore(t, goroutineGo)
+	gs, err := p.Goroutines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := symtabFor(t, p)
+
+	var nBlocked int
+	var main *Goroutine
+	for i, g := range gs {
+		if i > 0 && gs[i-1].ID >= g.ID {
+			t.Errorf("goroutines out of order: %d, %d", gs[i-1].ID, g.ID)
+		}
+		if g.StackLo >= g.StackHi {
+			t.Errorf("goroutine %d has bad stack [%#x,%#x)", g.ID, g.StackLo, g.StackHi)
+		}
+		switch {
+		case g.WaitReason == "chan receive":
+			nBlocked++
+			if g.Status != GWaiting {
+				t.Errorf("goroutine %d: want waiting, got %s", g.ID, g.Status)
+			}
+			// Since Go 1.22, the goroutine starts in a wrapper
+			// function, main.main.gowrap1.
+			if got := syms(g.StartPC); !strings.HasPrefix(got, "main.") {
+				t.Errorf("goroutine %d: want start function in main, got %s", g.ID, got)
+			}
+			if got := syms(g.CreatePC); got != "main.main" {
+				t.Errorf("goroutine %d: want created by main.main, got %s", g.ID, got)
+			}
+			frames, err := p.Stack(g, 0)
+			if err != nil {
+				t.Errorf("goroutine %d: %v", g.ID, err)
+			}
+			checkFrames(t, g, frames, "runtime.gopark", "main.blocked", "runtime.goexit")
+			for _, f := range frames {
+				if f.Func == "main.blocked" && (len(f.Inline) == 0 || f.Inline[0].Func != "main.recv") {
+					t.Errorf("goroutine %d: want main.recv inlined into main.blocked, got %+v", g.ID, f.Inline)
+				}
+			}
+		case syms(g.StartPC) == "runtime.main":
+			main = g
+		}
+	}
+	if nBlocked != 3 {
+		t.Errorf("want 3 blocked goroutines, got %d", nBlocked)
+	}
+	if main == nil {
+		t.Fatalf("main goroutine not found")
+	}
+	if main.ID != 1 || main.Status != GRunning || main.Thread == nil {
+		t.Errorf("bad main goroutine: %+v", main)
+	}
+	frames, err := p.Stack(main, 0)
+	if err != nil {
+		t.Errorf("main goroutine: %v", err)
+	}
+	checkFrames(t, main, frames, "main.main", "runtime.main", "runtime.goexit")
+}
+
+// symtabFor returns a function that returns the name of the symbol
+// containing pc in p.
+func symtabFor(t *testing.T, p *Process) func(pc uint64) string {
+	syms := symtab.NewSpace(p.as)
+	return func(pc uint64) string {
+		m, id := syms.Addr(pc)
+		if id == obj.NoSym {
+			return ""
+		}
+		return syms.Table(m).Syms()[id].Name
+	}
+}
+
+// checkFrames checks that frames includes functions funcs in order,
+// and ends with the last of funcs. ABI wrapper suffixes are ignored.
+func checkFrames(t *testing.T, g *Goroutine, frames []unwind.Frame, funcs ...string) {
+	t.Helper()
+	var names []string
+	for _, f := range frames {
+		names = append(names, strings.TrimSuffix(f.Func, ".abi0"))
+	}
+	i := 0
+	for _, name := range names {
+		if i < len(funcs) && name == funcs[i] {
+			i++
+		}
+	}
+	if i < len(funcs) || len(names) == 0 || names[len(names)-1] != funcs[len(funcs)-1] {
+		t.Errorf("goroutine %d: want stack with %v, got %v", g.ID, funcs, names)
+	}
+}
@@ -0,0 +1,540 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocore
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/aclements/go-obj/arch"
+)
+
+// An Arena is a heap arena, which is a large, aligned region of the
+// address space that the heap allocates spans from.
+type Arena struct {
+	// Addr and End give the address range [Addr, End) of the arena.
+	Addr, End uint64
+
+	// HeapArena is the address of the runtime's heapArena metadata for
+	// this arena.
+	HeapArena uint64
+}
+
+// A SpanState is the state of a span.
+type SpanState uint8
+
+const (
+	// SpanDead is a span that isn't in use.
+	SpanDead SpanState = iota
+	// SpanInUse is a span of heap objects.
+	SpanInUse
+	// SpanManual is a span that is manually managed by the runtime,
+	// such as a goroutine stack.
+	SpanManual
+)
+
+func (s SpanState) String() string {
+	switch s {
+	case SpanDead:
+		return "dead"
+	case SpanInUse:
+		return "in use"
+	case SpanManual:
+		return "manual"
+	}
+	return fmt.Sprintf("SpanState(%d)", s)
+}
+
+// A Span is a run of pages in the heap. Spans in use by the heap are
+// divided into objects of a single size class.
+type Span struct {
+	// Addr is the address of the runtime's mspan structure.
+	Addr uint64
+
+	// Start and End give the address range [Start, End) of the span's
+	// pages.
+	Start, End uint64
+
+	// State is the state of the span.
+	State SpanState
+
+	// SizeClass is the size class of the span's objects. Size class 0
+	// is a span holding a single large object.
+	SizeClass uint8
+
+	// NoScan indicates the span's objects don't contain pointers.
+	NoScan bool
+
+	// ElemSize is the size of each object in the span, and NElems is
+	// the number of objects.
+	ElemSize uint64
+	NElems   int
+
+	// FreeIndex is the index of the slot where the runtime starts
+	// searching for a free object. All objects before FreeIndex are
+	// allocated.
+	FreeIndex int
+
+	// AllocCount is the number of allocated objects, as of the last
+	// time the span was swept or cached by a P.
+	AllocCount int
+
+	allocBits, markBits uint64
+}
+
+// An Object is an object in the heap.
+type Object struct {
+	// Addr is the address of the object, and Size is its size, which
+	// is the size of its size class.
+	Addr, Size uint64
+
+	// Span is the span containing the object.
+	Span *Span
+
+	// Marked indicates the object's mark bit is set. When the garbage
+	// collector isn't running, the mark bits are clear.
+	Marked bool
+}
+
+// heapLayout is the layout of the runtime's heap structures.
+type heapLayout struct {
+	mheap uint64 // Address of runtime.mheap_
+
+	// mheap fields.
+	arenas, heapArenas field
+	l1Len, l2Len       uint64
+	l2Bits             uint
+	arenaIdxSize       uint64
+
+	// heapArena fields.
+	spans         field
+	pagesPerArena uint64
+
+	// mspan fields.
+	spanSize                               uint64
+	startAddr, npages, freeindex, nelems   field
+	allocCount, spanclass, state, elemsize field
+	allocBits, gcmarkBits                  field
+
+	pageSize, arenaBytes, arenaBaseOffset uint64
+
+	// Inline mark bits, if the runtime uses them.
+	inlineMarks                     bool
+	inlineMarksSize, inlineMarksOff uint64
+	maxInlineSize                   uint64
+}
+
+// heapLayout returns the layout of the runtime's heap structures.
+func (p *Process) heapLayout() (*heapLayout, error) {
+	p.heap.once.Do(func() {
+		p.heap.h, p.heap.err = p.readHeapLayout()
+	})
+	return p.heap.h, p.heap.err
+}
+
+func (p *Process) readHeapLayout() (*heapLayout, error) {
+	var h heapLayout
+	var err error
+	if h.mheap, _, err = p.global("runtime.mheap_"); err != nil {
+		return nil, err
+	}
+
+	var types [3]structType
+	for i, name := range []string{"runtime.mheap", "runtime.heapArena", "runtime.mspan"} {
+		if types[i], err = p.structType(name); err != nil {
+			return nil, err
+		}
+	}
+	mheap, heapArena, mspan := types[0], types[1], types[2]
+
+	// Get the fields we need, recording the first error.
+	get := func(s structType, f *field, names ...string) {
+		if err == nil {
+			*f, err = s.field(names...)
+		}
+	}
+	get(mheap, &h.arenas, "arenas")
+	// Go 1.23 renamed allArenas to heapArenas.
+	get(mheap, &h.heapArenas, "heapArenas", "allArenas")
+	get(heapArena, &h.spans, "spans")
+	get(mspan, &h.startAddr, "startAddr")
+	get(mspan, &h.npages, "npages")
+	get(mspan, &h.freeindex, "freeindex")
+	get(mspan, &h.nelems, "nelems")
+	get(mspan, &h.allocCount, "allocCount")
+	get(mspan, &h.spanclass, "spanclass")
+	get(mspan, &h.state, "state")
+	get(mspan, &h.elemsize, "elemsize")
+	get(mspan, &h.allocBits, "allocBits")
+	get(mspan, &h.gcmarkBits, "gcmarkBits")
+	if err != nil {
+		return nil, fmt.Errorf("reading runtime heap layout: %w", err)
+	}
+	h.spanSize = uint64(mspan.ByteSize)
+	for _, f := range []struct {
+		name string
+		f    field
+	}{
+		{"startAddr", h.startAddr}, {"npages", h.npages},
+		{"freeindex", h.freeindex}, {"nelems", h.nelems},
+		{"allocCount", h.allocCount}, {"spanclass", h.spanclass},
+		{"state", h.state}, {"elemsize", h.elemsize},
+		{"allocBits", h.allocBits}, {"gcmarkBits", h.gcmarkBits},
+	} {
+		if err := checkUint("runtime.mspan."+f.name, f.f, mspan.ByteSize); err != nil {
+			return nil, err
+		}
+	}
+
+	// The arenas field is a two-level array of heapArena pointers:
+	// [1 << arenaL1Bits]*[1 << arenaL2Bits]*heapArena.
+	l1, ok := stripTypedefs(h.arenas.typ).(*dwarf.ArrayType)
+	if !ok {
+		return nil, fmt.Errorf("runtime.mheap.arenas has unexpected type %s", h.arenas.typ)
+	}
+	l2, ok := arrayElem(l1.Type)
+	if !ok {
+		return nil, fmt.Errorf("runtime.mheap.arenas has unexpected type %s", h.arenas.typ)
+	}
+	h.l1Len, h.l2Len = uint64(l1.Count), uint64(l2.Count)
+	h.l2Bits = uint(bits.TrailingZeros64(h.l2Len))
+	if h.l2Len == 0 || h.l2Len&(h.l2Len-1) != 0 {
+		return nil, fmt.Errorf("runtime.mheap.arenas has unexpected L2 length %d", h.l2Len)
+	}
+	h.arenaIdxSize = uint64(p.arch.Layout.WordSize())
+	if elem, ok := sliceElem(h.heapArenas.typ); ok {
+		h.arenaIdxSize = uint64(elem.Size())
+	}
+	switch h.arenaIdxSize {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("runtime.mheap.heapArenas has unsupported element size %d", h.arenaIdxSize)
+	}
+	spans, ok := stripTypedefs(h.spans.typ).(*dwarf.ArrayType)
+	if !ok {
+		return nil, fmt.Errorf("runtime.heapArena.spans has unexpected type %s", h.spans.typ)
+	}
+	h.pagesPerArena = uint64(spans.Count)
+
+	if h.arenaBytes, err = p.constant("runtime.heapArenaBytes"); err != nil {
+		return nil, err
+	}
+	h.pageSize = h.arenaBytes / h.pagesPerArena
+	if v, err := p.constant("runtime.arenaBaseOffset"); err == nil {
+		h.arenaBaseOffset = v
+	} else if p.arch == arch.AMD64 {
+		// The compiler doesn't always emit this constant.
+		h.arenaBaseOffset = 0xffff800000000000
+	}
+
+	// With the Green Tea garbage collector, small object spans keep
+	// their mark bits at the end of the span while the garbage
+	// collector is marking.
+	if imb, err := p.structType("runtime.spanInlineMarkBits"); err == nil {
+		if marks, err := imb.field("marks"); err == nil {
+			h.inlineMarks = true
+			h.inlineMarksSize = uint64(imb.ByteSize)
+			h.inlineMarksOff = uint64(marks.off)
+			// gc.MinSizeForMallocHeader
+			ws := uint64(p.arch.Layout.WordSize())
+			h.maxInlineSize = ws * ws * 8
+		}
+	}
+
+	return &h, nil
+}
+
+// arrayElem returns the array type that pointer type typ points to.
+func arrayElem(typ dwarf.Type) (*dwarf.ArrayType, bool) {
+	ptr, ok := stripTypedefs(typ).(*dwarf.PtrType)
+	if !ok {
+		return nil, false
+	}
+	arr, ok := stripTypedefs(ptr.Type).(*dwarf.ArrayType)
+	return arr, ok
+}
+
+// sliceElem returns the element type of Go slice type typ.
+func sliceElem(typ dwarf.Type) (dwarf.Type, bool) {
+	st, ok := stripTypedefs(typ).(*dwarf.StructType)
+	if !ok || len(st.Field) == 0 {
+		return nil, false
+	}
+	ptr, ok := stripTypedefs(st.Field[0].Type).(*dwarf.PtrType)
+	if !ok {
+		return nil, false
+	}
+	return ptr.Type, true
+}
+
+// Arenas returns the process's heap arenas, sorted by address.
+func (p *Process) Arenas() ([]Arena, error) {
+	h, err := p.heapLayout()
+	if err != nil {
+		return nil, err
+	}
+	ws := uint64(p.arch.Layout.WordSize())
+
+	// Read the list of arena indexes.
+	hdr, err := p.read(h.mheap+uint64(h.heapArenas.off), 2*ws)
+	if err != nil {
+		return nil, fmt.Errorf("reading heap arena list: %w", err)
+	}
+	base, n := p.arch.Layout.Word(hdr), p.arch.Layout.Word(hdr[ws:])
+	if n > 1<<24 {
+		return nil, fmt.Errorf("heap arena list length %d is too large", n)
+	}
+	idxs, err := p.read(base, n*h.arenaIdxSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading heap arena list: %w", err)
+	}
+	idxField := field{size: int64(h.arenaIdxSize)}
+
+	var arenas []Arena
+	l2Cache := make(map[uint64]uint64)
+	for i := uint64(0); i < n; i++ {
+		idxField.off = int64(i * h.arenaIdxSize)
+		idx := p.uint(idxs, idxField)
+		l1, l2 := idx>>h.l2Bits, idx&(h.l2Len-1)
+		if l1 >= h.l1Len {
+			return nil, fmt.Errorf("heap arena index %#x out of range", idx)
+		}
+		l2Arr, ok := l2Cache[l1]
+		if !ok {
+			if l2Arr, err = p.word(h.mheap + uint64(h.arenas.off) + l1*ws); err != nil {
+				return nil, fmt.Errorf("reading heap arena map: %w", err)
+			}
+			l2Cache[l1] = l2Arr
+		}
+		ha, err := p.word(l2Arr + l2*ws)
+		if err != nil {
+			return nil, fmt.Errorf("reading heap arena map: %w", err)
+		}
+		if ha == 0 {
+			continue
+		}
+		addr := idx*h.arenaBytes + h.arenaBaseOffset
+		arenas = append(arenas, Arena{Addr: addr, End: addr + h.arenaBytes, HeapArena: ha})
+	}
+	sort.Slice(arenas, func(i, j int) bool {
+		return arenas[i].Addr < arenas[j].Addr
+	})
+	return arenas, nil
+}
+
+// Spans returns the spans in the process's heap arenas that are in use
+// by the heap or manually managed, sorted by address.
+func (p *Process) Spans() ([]*Span, error) {
+	h, err := p.heapLayout()
+	if err != nil {
+		return nil, err
+	}
+	arenas, err := p.Arenas()
+	if err != nil {
+		return nil, err
+	}
+	ws := uint64(p.arch.Layout.WordSize())
+
+	var spans []*Span
+	seen := make(map[uint64]bool)
+	for _, a := range arenas {
+		b, err := p.read(a.HeapArena+uint64(h.spans.off), h.pagesPerArena*ws)
+		if err != nil {
+			return nil, fmt.Errorf("reading spans of arena at %#x: %w", a.Addr, err)
+		}
+		for i := uint64(0); i < h.pagesPerArena; i++ {
+			addr := p.arch.Layout.Word(b[i*ws:])
+			if addr == 0 || seen[addr] {
+				continue
+			}
+			s, err := p.span(h, addr)
+			if err != nil {
+				return nil, err
+			}
+			// Pages that aren't in use may point to stale spans, which
+			// the runtime may have reused for other pages.
+			page := a.Addr + i*h.pageSize
+			if s.State == SpanDead || page < s.Start || page >= s.End {
+				continue
+			}
+			seen[addr] = true
+			spans = append(spans, s)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Start < spans[j].Start
+	})
+	return spans, nil
+}
+
+// span decodes the mspan at addr.
+func (p *Process) span(h *heapLayout, addr uint64) (*Span, error) {
+	b, err := p.read(addr, h.spanSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading span at %#x: %w", addr, err)
+	}
+	spanclass := p.uint(b, h.spanclass)
+	s := &Span{
+		Addr:       addr,
+		Start:      p.uint(b, h.startAddr),
+		State:      SpanState(p.uint(b, h.state)),
+		SizeClass:  uint8(spanclass >> 1),
+		NoScan:     spanclass&1 != 0,
+		ElemSize:   p.uint(b, h.elemsize),
+		NElems:     int(p.uint(b, h.nelems)),
+		FreeIndex:  int(p.uint(b, h.freeindex)),
+		AllocCount: int(p.uint(b, h.allocCount)),
+		allocBits:  p.uint(b, h.allocBits),
+		markBits:   p.uint(b, h.gcmarkBits),
+	}
+	s.End = s.Start + p.uint(b, h.npages)*h.pageSize
+	return s, nil
+}
+
+// Objects calls fn for each allocated object in the spans that are in
+// use by the heap, in address order. If fn returns false, Objects stops
+// and returns nil.
+//
+// An object is allocated if it is before its span's FreeIndex, or its
+// bit is set in the span's allocation bitmap. Objects that became
+// unreachable since the last garbage collection are still allocated.
+func (p *Process) Objects(fn func(Object) bool) error {
+	spans, err := p.Spans()
+	if err != nil {
+		return err
+	}
+	for _, s := range spans {
+		if s.State != SpanInUse {
+			continue
+		}
+		cont, err := p.spanObjects(s, fn)
+		if err != nil {
+			return err
+		}
+		if !cont {
+			return nil
+		}
+	}
+	return nil
+}
+
+// spanObjects calls fn for each allocated object in span s. It returns
+// false if fn returned false.
+func (p *Process) spanObjects(s *Span, fn func(Object) bool) (bool, error) {
+	h, err := p.heapLayout()
+	if err != nil {
+		return false, err
+	}
+	if s.NElems == 0 || s.ElemSize == 0 {
+		return true, nil
+	}
+	nbytes := uint64(s.NElems+7) / 8
+	alloc, err := p.read(s.allocBits, nbytes)
+	if err != nil {
+		return false, fmt.Errorf("reading allocation bits of span at %#x: %w", s.Start, err)
+	}
+	marks, err := p.read(s.markBits, nbytes)
+	if err != nil {
+		return false, fmt.Errorf("reading mark bits of span at %#x: %w", s.Start, err)
+	}
+	if h.inlineMarks && s.SizeClass != 0 && s.ElemSize >= 16 && s.ElemSize <= h.maxInlineSize {
+		// Merge in the inline mark bits, which are only set while
+		// the garbage collector is marking.
+		inline, err := p.read(s.Start+h.pageSize-h.inlineMarksSize+h.inlineMarksOff, nbytes)
+		if err != nil {
+			return false, fmt.Errorf("reading inline mark bits of span at %#x: %w", s.Start, err)
+		}
+		for i := range marks {
+			marks[i] |= inline[i]
+		}
+	}
+
+	for i := 0; i < s.NElems; i++ {
+		bit := byte(1) << (i % 8)
+		if i >= s.FreeIndex && alloc[i/8]&bit == 0 {
+			continue
+		}
+		obj := Object{
+			Addr:   s.Start + uint64(i)*s.ElemSize,
+			Size:   s.ElemSize,
+			Span:   s,
+			Marked: marks[i/8]&bit != 0,
+		}
+		if !fn(obj) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// A SizeClassStats summarizes the heap objects of one size class.
+type SizeClassStats struct {
+	// SizeClass is the size class. Size class 0 is large objects.
+	SizeClass uint8
+
+	// ElemSize is the size of the objects in this class. It is 0 for
+	// size class 0, whose objects have different sizes.
+	ElemSize uint64
+
+	// Spans is the number of spans in this class, and SpanBytes is
+	// their total size.
+	Spans     int
+	SpanBytes uint64
+
+	// Objects is the number of allocated objects in this class, and
+	// Bytes is their total size.
+	Objects int
+	Bytes   uint64
+
+	// Marked is the number of marked objects in this class.
+	Marked int
+}
+
+// HeapStats returns statistics for each size class that has spans in
+// use by the heap, ordered by size class.
+func (p *Process) HeapStats() ([]SizeClassStats, error) {
+	spans, err := p.Spans()
+	if err != nil {
+		return nil, err
+	}
+	classes := make(map[uint8]*SizeClassStats)
+	for _, s := range spans {
+		if s.State != SpanInUse {
+			continue
+		}
+		st := classes[s.SizeClass]
+		if st == nil {
+			st = &SizeClassStats{SizeClass: s.SizeClass}
+			if s.SizeClass != 0 {
+				st.ElemSize = s.ElemSize
+			}
+			classes[s.SizeClass] = st
+		}
+		st.Spans++
+		st.SpanBytes += s.End - s.Start
+		_, err := p.spanObjects(s, func(o Object) bool {
+			st.Objects++
+			st.Bytes += o.Size
+			if o.Marked {
+				st.Marked++
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats := make([]SizeClassStats, 0, len(classes))
+	for _, st := range classes {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].SizeClass < stats[j].SizeClass
+	})
+	return stats, nil
+}
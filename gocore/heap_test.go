@@ -0,0 +1,184 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+const heapGo = `package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+type T struct {
+	next *T
+	buf  [88]byte
+}
+
+var small []*T
+var big []byte
+
+func main() {
+	var lim syscall.Rlimit
+	syscall.Getrlimit(syscall.RLIMIT_CORE, &lim)
+	lim.Cur = lim.Max
+	syscall.Setrlimit(syscall.RLIMIT_CORE, &lim)
+
+	for i := 0; i < 1000; i++ {
+		small = append(small, new(T))
+	}
+	big = make([]byte, 1<<20)
+	fmt.Printf("%p %p\n", &big[0], small[0])
+	os.Stdout.Sync()
+	panic("crash")
+}
+`
+
+// loadCore builds and runs Go program src, and returns the Process for
+// its core file and the program's output.
+func loadCore(t *testing.T, src string) (*Process, []byte) {
+	t.Helper()
+	bin := gotest.Build(t, src)
+	core, out := gotest.Core(t, bin)
+	f := gotest.Open(t, core)
+	p, err := NewCore(f, func(path string) (obj.File, error) {
+		return gotest.Open(t, path), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, out
+}
+
+func TestHeap(t *testing.T) {
+	p, out := loadCore(t, heapGo)
+	var bigAddr, smallAddr uint64
+	if _, err := fmt.Sscanf(string(out), "%v %v", &bigAddr, &smallAddr); err != nil {
+		t.Fatalf("parsing output %q: %v", out, err)
+	}
+
+	arenas, err := p.Arenas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, a := range arenas {
+		found = found || (a.Addr <= bigAddr && bigAddr < a.End)
+	}
+	if !found {
+		t.Errorf("no arena contains %#x: %+v", bigAddr, arenas)
+	}
+
+	spans, err := p.Spans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manual int
+	var bigSpan *Span
+	for i, s := range spans {
+		if i > 0 && spans[i-1].End > s.Start {
+			t.Errorf("spans overlap or are out of order: [%#x,%#x) and [%#x,%#x)", spans[i-1].Start, spans[i-1].End, s.Start, s.End)
+		}
+		if s.State == SpanManual {
+			manual++
+		}
+		if s.Start <= bigAddr && bigAddr < s.End {
+			bigSpan = s
+		}
+	}
+	if manual == 0 {
+		t.Errorf("no manual spans found")
+	}
+	if bigSpan == nil {
+		t.Fatalf("no span contains %#x", bigAddr)
+	}
+	if bigSpan.State != SpanInUse || bigSpan.SizeClass != 0 || bigSpan.Start != bigAddr || bigSpan.ElemSize < 1<<20 || bigSpan.NElems != 1 {
+		t.Errorf("bad span for large object: %+v", bigSpan)
+	}
+
+	var smallObj, bigObj bool
+	var n96 int
+	err = p.Objects(func(o Object) bool {
+		if o.Addr+o.Size > o.Span.End || o.Addr < o.Span.Start {
+			t.Errorf("object [%#x,%#x) outside span [%#x,%#x)", o.Addr, o.Addr+o.Size, o.Span.Start, o.Span.End)
+		}
+		switch o.Addr {
+		case smallAddr:
+			smallObj = o.Size == 96
+		case bigAddr:
+			bigObj = o.Span.Addr == bigSpan.Addr
+		}
+		if o.Size == 96 {
+			n96++
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !smallObj || !bigObj {
+		t.Errorf("objects not found: small %v, big %v", smallObj, bigObj)
+	}
+	if n96 < 1000 {
+		t.Errorf("want at least 1000 96-byte objects, got %d", n96)
+	}
+
+	stats, err := p.HeapStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total, classTotal int
+	for _, st := range stats {
+		total += st.Objects
+		if st.ElemSize == 96 {
+			classTotal = st.Objects
+		}
+		if st.SizeClass == 0 && st.Bytes < 1<<20 {
+			t.Errorf("large objects total %d bytes, want at least %d", st.Bytes, 1<<20)
+		}
+	}
+	if classTotal != n96 {
+		t.Errorf("HeapStats found %d 96-byte objects, Objects found %d", classTotal, n96)
+	}
+	t.Logf("%d objects in %d spans", total, len(spans))
+}
+
+func TestCheckUint(t *testing.T) {
+	for _, test := range []struct {
+		f    field
+		size int64
+		ok   bool
+	}{
+		{field{off: 0, size: 8}, 8, true},
+		{field{off: 6, size: 2}, 8, true},
+		{field{off: 0, size: 3}, 8, false},
+		{field{off: 0, size: 16}, 16, false},
+		{field{off: 4, size: 8}, 8, false},
+		{field{off: -1, size: 1}, 8, false},
+	} {
+		if err := checkUint("f", test.f, test.size); (err == nil) != test.ok {
+			t.Errorf("checkUint(%+v, %d): want ok %v, got %v", test.f, test.size, test.ok, err)
+		}
+	}
+}
+
+func TestNoDWARF(t *testing.T) {
+	// gocore has no fallback layouts, so it rejects executables without
+	// DWARF.
+	f := gotest.Open(t, gotest.Build(t, heapGo, "-ldflags=-w"))
+	as := obj.NewAddressSpace()
+	as.Add("exe", f, 0)
+	if _, err := New(as, nil); err == nil {
+		t.Errorf("New succeeded for an executable without DWARF")
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gocore analyzes the runtime state of a Go program, such as
+// its heap and goroutines, from a core file.
+//
+// The layouts of the runtime's data structures change between Go
+// releases, so gocore takes them from the DWARF debug info of the Go
+// executable. gocore has no built-in layouts for known Go releases to
+// fall back on, so it does not support executables built without DWARF,
+// such as those linked with -ldflags=-w or -s; New and NewCore return an
+// error for them.
+//
+// Reference: runtime/mheap.go, runtime/mbitmap.go, and
+// runtime/runtime2.go in the Go source tree, and golang.org/x/debug/internal/gocore.
+package gocore
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/dbg"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/unwind"
+)
+
+// A Process is the state of a Go program, typically from a core file.
+//
+// A Process is safe for concurrent use.
+type Process struct {
+	// Exe is the module of the Go executable.
+	Exe *obj.Module
+
+	as   *obj.AddressSpace
+	mem  io.ReaderAt
+	arch *arch.Arch
+	dbg  *dbg.Space
+	dw   *dbg.Data
+
+	// threads are the process's threads, or nil if unknown.
+	threads []obj.Thread
+
+	heap struct {
+		once sync.Once
+		h    *heapLayout
+		err  error
+	}
+	g struct {
+		once sync.Once
+		l    *gLayout
+		err  error
+	}
+	unwind struct {
+		once sync.Once
+		u    *unwind.Unwinder
+		err  error
+	}
+}
+
+// New returns the Process for the modules in as. Memory is read from
+// mem, where offsets are addresses in the address space, such as the
+// memory returned by obj.CoreMemory. Memory that can't be read from
+// mem, or all memory if mem is nil, is read from the modules in as.
+//
+// The Go executable is the first module in as with DWARF debug info
+// that defines the runtime's heap. New returns an error if there's no
+// such module, including if the Go executable was built without DWARF.
+func New(as *obj.AddressSpace, mem io.ReaderAt) (*Process, error) {
+	p := &Process{as: as, mem: mem, dbg: dbg.NewSpace(as)}
+	for _, m := range as.Modules() {
+		dw, err := p.dbg.Module(m)
+		if err != nil {
+			continue
+		}
+		if _, _, err := dw.Var("runtime.mheap_"); err != nil {
+			continue
+		}
+		p.Exe, p.dw = m, dw
+		break
+	}
+	if p.Exe == nil {
+		return nil, fmt.Errorf("no Go executable with DWARF debug info found")
+	}
+	p.arch = p.Exe.File.Info().Arch
+	return p, nil
+}
+
+// NewCore returns the Process for core file f. It calls open to open
+// each object file mapped in the process, as in
+// obj.NewAddressSpaceFromMappings.
+//
+// Unlike New, the Process also records the threads in f, which are
+// used to unwind the stacks of running goroutines.
+//
+// If NewCore returns an error, it closes any object files it opened.
+func NewCore(f obj.File, open func(path string) (obj.File, error)) (*Process, error) {
+	maps, err := obj.CoreMappings(f)
+	if err != nil {
+		return nil, err
+	}
+	as, err := obj.NewAddressSpaceFromMappings(maps, open)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newCore(f, as)
+	if err != nil {
+		for _, m := range as.Modules() {
+			m.File.Close()
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func newCore(f obj.File, as *obj.AddressSpace) (*Process, error) {
+	mem, err := obj.CoreMemory(f)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := obj.CoreThreads(f)
+	if err != nil {
+		return nil, err
+	}
+	p, err := New(as, mem)
+	if err != nil {
+		return nil, err
+	}
+	p.threads = threads
+	return p, nil
+}
+
+// Arch returns the architecture of the process.
+func (p *Process) Arch() *arch.Arch {
+	return p.arch
+}
+
+// read reads size bytes of memory at address addr.
+func (p *Process) read(addr, size uint64) ([]byte, error) {
+	var memErr error
+	if p.mem != nil {
+		buf := make([]byte, size)
+		if _, memErr = p.mem.ReadAt(buf, int64(addr)); memErr == nil {
+			return buf, nil
+		}
+	}
+	// Fall back to the modules.
+	d, err := p.as.Data(addr, size)
+	if err != nil {
+		if memErr != nil {
+			return nil, memErr
+		}
+		return nil, err
+	}
+	return d.B, nil
+}
+
+// word reads a word of memory at address addr.
+func (p *Process) word(addr uint64) (uint64, error) {
+	b, err := p.read(addr, uint64(p.arch.Layout.WordSize()))
+	if err != nil {
+		return 0, err
+	}
+	return p.arch.Layout.Word(b), nil
+}
+
+// global returns the address in the process of runtime variable name.
+func (p *Process) global(name string) (uint64, dwarf.Type, error) {
+	addr, typ, err := p.dw.Var(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	return p.Exe.FromFile(addr), typ, nil
+}
+
+// constant returns the value of runtime constant name.
+func (p *Process) constant(name string) (uint64, error) {
+	v, err := p.dw.Const(name)
+	return uint64(v), err
+}
+
+// A field is the location of a field within a runtime structure.
+type field struct {
+	off, size int64
+	typ       dwarf.Type
+}
+
+// A structType is the layout of a runtime structure.
+type structType struct {
+	*dwarf.StructType
+}
+
+// structType returns the layout of runtime structure type name.
+func (p *Process) structType(name string) (structType, error) {
+	typ, err := p.dw.Type(name)
+	if err != nil {
+		return structType{}, err
+	}
+	st, ok := stripTypedefs(typ).(*dwarf.StructType)
+	if !ok {
+		return structType{}, fmt.Errorf("DWARF type %s is not a struct", name)
+	}
+	return structType{st}, nil
+}
+
+// field returns the first field of s named one of names. Passing
+// several names supports fields that were renamed between releases.
+func (s structType) field(names ...string) (field, error) {
+	for _, name := range names {
+		for _, f := range s.Field {
+			if f.Name == name {
+				return field{f.ByteOffset, f.Type.Size(), f.Type}, nil
+			}
+		}
+	}
+	return field{}, fmt.Errorf("field %s not found in %s", names[0], s.StructName)
+}
+
+// checkUint checks that uint can decode integer field f of a structure
+// of size bytes. name is the field's name for errors.
+func checkUint(name string, f field, size int64) error {
+	switch f.size {
+	case 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("%s has unsupported size %d", name, f.size)
+	}
+	if f.off < 0 || f.off+f.size > size {
+		return fmt.Errorf("%s at offset %d is outside its %d-byte structure", name, f.off, size)
+	}
+	return nil
+}
+
+// uint decodes field f from b, which contains the structure. f must
+// have been checked by checkUint against the size of b.
+func (p *Process) uint(b []byte, f field) uint64 {
+	l := p.arch.Layout
+	b = b[f.off:]
+	switch f.size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(l.Uint16(b))
+	case 4:
+		return uint64(l.Uint32(b))
+	case 8:
+		return l.Uint64(b)
+	}
+	panic(fmt.Sprintf("bad field size %d", f.size))
+}
+
+func stripTypedefs(typ dwarf.Type) dwarf.Type {
+	for {
+		td, ok := typ.(*dwarf.TypedefType)
+		if !ok {
+			return typ
+		}
+		typ = td.Type
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gotest provides helpers for tests that build and inspect Go
+// binaries using the local Go toolchain.
+package gotest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// Build builds a Go program from source src using the local Go
+// toolchain, and returns the path of the binary. It builds for
+// linux/amd64 without cgo, and passes flags to "go build".
+//
+// It skips the test in short mode or if there's no Go toolchain.
+func Build(t *testing.T, src string, flags ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping Go build in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "hello.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module hello\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "hello")
+	args := append([]string{"build", "-o", bin}, flags...)
+	cmd := exec.Command(goTool, append(args, ".")...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build failed: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// Core runs the binary bin built by Build, which should crash and dump
+// core, and returns the path of the core file and the binary's standard
+// output. It runs bin with GOTRACEBACK=crash in the directory
+// containing bin. bin must raise its own RLIMIT_CORE.
+//
+// It skips the test if the host can't run bin or the system doesn't
+// write core files to the working directory.
+func Core(t *testing.T, bin string) (string, []byte) {
+	t.Helper()
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skipf("can't run linux/amd64 binary on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	dir := filepath.Dir(bin)
+	var stdout bytes.Buffer
+	cmd := exec.Command(bin)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOTRACEBACK=crash")
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("%s didn't crash", bin)
+	}
+	cores, err := filepath.Glob(filepath.Join(dir, "core*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cores) != 1 {
+		t.Skip("no core file written; check ulimit -c and /proc/sys/kernel/core_pattern")
+	}
+	return cores[0], stdout.Bytes()
+}
+
+// Open opens the object file at path. The file is closed when the test
+// finishes.
+func Open(t *testing.T, path string) obj.File {
+	t.Helper()
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fp.Close() })
+	f, err := obj.Open(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(f.Close)
+	return f
+}
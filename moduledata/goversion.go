@@ -0,0 +1,30 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduledata
+
+import (
+	"github.com/aclements/go-obj/buildinfo"
+	"github.com/aclements/go-obj/obj"
+)
+
+// GoVersion returns the version of the Go toolchain that built f, such
+// as "go1.21.3". It reads the runtime.buildVersion variable if f has
+// symbols, and otherwise the version recorded in f's build information.
+func GoVersion(f obj.File) (string, error) {
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		sym := f.Sym(i)
+		if sym.Name != "runtime.buildVersion" || sym.Kind == obj.SymUndef {
+			continue
+		}
+		dec := &decoder{mem: FileMemory(f), file: f, layout: f.Info().Arch.Layout}
+		return dec.stringAt(sym.Value)
+	}
+
+	bi, err := buildinfo.Read(f)
+	if err != nil {
+		return "", err
+	}
+	return bi.GoVersion, nil
+}
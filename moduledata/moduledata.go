@@ -0,0 +1,613 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package moduledata decodes the Go runtime's module data, which
+// describes the layout of each Go module (an executable, shared
+// library, or plugin) and points to most of the runtime's other
+// metadata, such as the pclntab, type descriptors, and itabs.
+//
+// The first module's data is stored in the runtime.firstmoduledata
+// variable. Later modules are linked through the next field at run
+// time.
+//
+// The layout of the runtime's moduledata structure changes between Go
+// releases. This supports the layouts used by Go 1.16 and later.
+//
+// Reference: runtime/symtab.go and cmd/link/internal/ld/symtab.go in
+// the Go source tree.
+package moduledata
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/pclntab"
+)
+
+// ErrNotFound is returned when an object file doesn't contain module
+// data.
+var ErrNotFound = errors.New("Go module data not found")
+
+// Version is a moduledata layout version. Each version is named for the
+// Go release that introduced it.
+type Version int
+
+const (
+	VersionUnknown Version = iota
+
+	// Version116 is the layout of Go 1.16 and 1.17.
+	Version116
+
+	// Version118 adds the rodata and gofunc fields.
+	Version118
+
+	// Version120 adds the coverage counter bounds.
+	Version120
+
+	// Version121 adds the inittasks field.
+	Version121
+
+	// Version127 stores type descriptors and itabs contiguously,
+	// replacing the typelinks and itablinks fields with TypeDescLen,
+	// ItabOffset, and ItabSize.
+	Version127
+)
+
+func (v Version) String() string {
+	switch v {
+	case Version116:
+		return "go1.16"
+	case Version118:
+		return "go1.18"
+	case Version120:
+		return "go1.20"
+	case Version121:
+		return "go1.21"
+	case Version127:
+		return "go1.27"
+	}
+	return fmt.Sprintf("Version(%d)", int(v))
+}
+
+// A ModuleData is a decoded runtime.moduledata structure.
+//
+// Fields that bound a region of memory, such as Text and EText, give
+// the half-open address range [Text, EText).
+type ModuleData struct {
+	// Addr is the address of the moduledata structure.
+	Addr uint64
+
+	// Version is the layout version of the structure.
+	Version Version
+
+	// Layout is the byte order and pointer size of the structure.
+	Layout arch.Layout
+
+	// PCHeader is the address of the module's pclntab.
+	PCHeader uint64
+
+	// FindFuncTab is the address of the module's findfunctab, which
+	// can be passed to pclntab.Table.SetFindFuncTab.
+	FindFuncTab uint64
+
+	// MinPC and MaxPC give the range of PCs covered by the module's
+	// function table.
+	MinPC, MaxPC uint64
+
+	Text, EText           uint64
+	NoPtrData, ENoPtrData uint64
+	Data, EData           uint64
+	BSS, EBSS             uint64
+	NoPtrBSS, ENoPtrBSS   uint64
+
+	// CovCtrs and ECovCtrs bound the coverage counters. These are
+	// only recorded by Go 1.20 and later.
+	CovCtrs, ECovCtrs uint64
+
+	// End is the end of the module's data.
+	End uint64
+
+	// GCData and GCBSS are the addresses of the pointer bitmaps of the
+	// data and BSS sections.
+	GCData, GCBSS uint64
+
+	// Types and ETypes bound the module's type descriptors. Offsets
+	// into type data, such as typelinks, are relative to Types.
+	Types, ETypes uint64
+
+	// TypeDescLen is the length of the type descriptors at the start
+	// of Types that were listed in typelinks in earlier layouts. This
+	// is only recorded by Version127 and later.
+	TypeDescLen uint64
+
+	// ItabOffset and ItabSize give the location of the module's itabs
+	// relative to Types. These are only recorded by Version127 and
+	// later.
+	ItabOffset, ItabSize uint64
+
+	// ROData is the start of the module's read-only data. GoFunc is
+	// the address of the go:func.* symbol, which funcdata offsets are
+	// relative to. These are only recorded by Go 1.18 and later.
+	ROData, GoFunc uint64
+
+	// EPCLNTab is the end of the module's pclntab. This is only
+	// recorded by Version127 and later.
+	EPCLNTab uint64
+
+	// TextSectMap maps the module's text sections, if the text had to
+	// be split into several sections.
+	TextSectMap []TextSect
+
+	// Typelinks gives the addresses of the module's type descriptors
+	// that can be found by reflection, sorted by type string. Itablinks
+	// gives the addresses of the module's itabs. These are nil for
+	// Version127 and later, which instead store type descriptors and
+	// itabs contiguously.
+	Typelinks []uint64
+	Itablinks []uint64
+
+	// PTab is the plugin's exported symbol table.
+	PTab []PTabEntry
+
+	// PluginPath is the path of the plugin, or "" if this module isn't
+	// a plugin.
+	PluginPath string
+
+	// ModuleName is the name of the shared library, or "" if this
+	// module isn't a shared library.
+	ModuleName string
+
+	// HasMain indicates that this module contains the main function.
+	HasMain bool
+
+	// Next is the address of the next module's moduledata, or 0 if
+	// this is the last module. In an object file, this is always 0;
+	// the runtime links modules together as they're loaded.
+	Next uint64
+}
+
+// A TextSect describes one of a module's text sections.
+type TextSect struct {
+	// VAddr and End give the range of addresses [VAddr, End) of the
+	// section as linked.
+	VAddr, End uint64
+
+	// BaseAddr is the address of the section after relocation.
+	BaseAddr uint64
+}
+
+// A PTabEntry is an entry in a plugin's exported symbol table.
+type PTabEntry struct {
+	// NameOff is the offset of the symbol's name from Types.
+	NameOff int32
+
+	// TypeOff is the offset of the symbol's type descriptor from
+	// Types.
+	TypeOff int32
+}
+
+// TextAddr returns the address of text offset off in md. Text offsets
+// are used by method tables, and are relative to the start of the
+// module's text, accounting for split text sections.
+func (md *ModuleData) TextAddr(off uint32) uint64 {
+	o := uint64(off)
+	for i, sect := range md.TextSectMap {
+		// The end of the last section is also valid.
+		if o >= sect.VAddr && o < sect.End || (i == len(md.TextSectMap)-1 && o == sect.End) {
+			return sect.BaseAddr + o - sect.VAddr
+		}
+	}
+	return md.Text + o
+}
+
+// A Memory is a source of program memory. *obj.AddressSpace implements
+// Memory.
+type Memory interface {
+	Data(addr, size uint64) (*obj.Data, error)
+}
+
+// FileMemory returns a Memory that reads from the mapped sections of f.
+func FileMemory(f obj.File) Memory {
+	return fileMemory{f}
+}
+
+type fileMemory struct {
+	f obj.File
+}
+
+func (m fileMemory) Data(addr, size uint64) (*obj.Data, error) {
+	s := m.f.ResolveAddr(addr)
+	if s == nil {
+		return nil, &obj.ErrNoData{Detail: fmt.Sprintf("address %#x is not in any section", addr)}
+	}
+	return s.Data(addr, size)
+}
+
+// Find locates and decodes the first module's data in f. It uses the
+// runtime.firstmoduledata symbol if f has one. Otherwise, for stripped
+// binaries, it searches f's data sections for a moduledata that points
+// to f's pclntab. It returns ErrNotFound if f doesn't contain module
+// data.
+//
+// The layout version is inferred from the pclntab version and the
+// contents of the structure.
+func Find(f obj.File) (*ModuleData, error) {
+	t, err := pclntab.NewTable(f)
+	if err == pclntab.ErrNoPCLNTab {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	mem := FileMemory(f)
+	dec := &decoder{mem: mem, file: f, layout: t.Layout}
+
+	var addr, size uint64
+	syms := f.NumSyms()
+	for i := obj.SymID(0); i < syms; i++ {
+		if sym := f.Sym(i); sym.Name == "runtime.firstmoduledata" && sym.Kind != obj.SymUndef {
+			addr, size = sym.Value, sym.Size
+			break
+		}
+	}
+	if addr == 0 {
+		addr, err = dec.search(f, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := dec.version(addr, t)
+	if err != nil {
+		return nil, err
+	}
+	md, err := dec.decode(addr, v)
+	if err != nil {
+		return nil, err
+	}
+	if md.PCHeader != t.Data().Addr {
+		return nil, fmt.Errorf("moduledata at %#x points to pclntab at %#x, want %#x", addr, md.PCHeader, t.Data().Addr)
+	}
+	// The next field is the last field of the structure. If we know
+	// the size of the structure, use it rather than relying on the
+	// layout of the fields just before it.
+	if ws := uint64(t.Layout.WordSize()); size >= ws && size != dec.size(v) {
+		if md.Next, err = dec.word(addr + size - ws); err != nil {
+			return nil, err
+		}
+	}
+	return md, nil
+}
+
+// Read decodes the moduledata at address addr in mem, which has the
+// given layout version. This is typically used to follow the Next
+// field of a module in a process's memory.
+func Read(mem Memory, layout arch.Layout, addr uint64, v Version) (*ModuleData, error) {
+	dec := &decoder{mem: mem, layout: layout}
+	return dec.decode(addr, v)
+}
+
+type decoder struct {
+	mem    Memory
+	file   obj.File // For resolving symbolic relocations, or nil
+	layout arch.Layout
+}
+
+// search finds the moduledata in f that points to pclntab t.
+func (dec *decoder) search(f obj.File, t *pclntab.Table) (uint64, error) {
+	hdr := t.Data().Addr
+	ws := t.Layout.WordSize()
+	for _, s := range f.SectionsByKind(obj.SectionData) {
+		if !s.Mapped() || s.ZeroInitialized() {
+			continue
+		}
+		d, err := s.Data(s.Bounds())
+		if err != nil {
+			return 0, err
+		}
+		d.Layout = t.Layout
+		r := obj.NewReader(d)
+		for r.Avail() >= ws {
+			addr := r.Addr()
+			if addr%uint64(ws) != 0 {
+				r.Align(ws)
+				continue
+			}
+			if dec.ptr(r) != hdr {
+				continue
+			}
+			// Check the length of the ftab slice, which has an entry
+			// for each function plus an end entry.
+			if n, err := dec.word(addr + uint64(17*ws)); err == nil && n == uint64(t.NumFuncs()+1) {
+				return addr, nil
+			}
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// version infers the layout version of the moduledata at addr, which
+// points to pclntab t.
+func (dec *decoder) version(addr uint64, t *pclntab.Table) (Version, error) {
+	switch t.Version {
+	case pclntab.Version116:
+		return Version116, nil
+	case pclntab.Version118:
+		return Version118, nil
+	case pclntab.Version120:
+	default:
+		return VersionUnknown, fmt.Errorf("unsupported pclntab version %s", t.Version)
+	}
+
+	// Version127 follows types with typedesclen, which is a length,
+	// where earlier layouts have etypes, which is an address.
+	ws := uint64(t.Layout.WordSize())
+	typesAddr := addr + ws*typesField(Version120)
+	types, err := dec.word(typesAddr)
+	if err != nil {
+		return VersionUnknown, err
+	}
+	next, err := dec.word(typesAddr + ws)
+	if err != nil {
+		return VersionUnknown, err
+	}
+	if next < types {
+		return Version127, nil
+	}
+
+	// Go 1.21 added inittasks along with runtime.doInit1.
+	for i := 0; i < t.NumFuncs(); i++ {
+		if t.Func(i).Name() == "runtime.doInit1" {
+			return Version121, nil
+		}
+	}
+	return Version120, nil
+}
+
+// typesField returns the word index of the types field in layout v.
+func typesField(v Version) uint64 {
+	// pcHeader, 6 slices, findfunctab, minpc, maxpc, and 5 pairs of
+	// section bounds.
+	n := uint64(1 + 6*3 + 3 + 5*2)
+	if v >= Version120 {
+		n += 2 // covctrs, ecovctrs
+	}
+	return n + 3 // end, gcdata, gcbss
+}
+
+// size returns the size in bytes of the moduledata structure in layout
+// v.
+func (dec *decoder) size(v Version) uint64 {
+	n := typesField(v) + 2 // types, etypes
+	if v >= Version127 {
+		n += 4 // typedesclen, itaboffset, itabsize, epclntab
+	}
+	if v >= Version118 {
+		n += 2 // rodata, gofunc
+	}
+	n += 3 // textsectmap
+	if v < Version127 {
+		n += 2 * 3 // typelinks, itablinks
+	}
+	n += 3 + 2 + 3 // ptab, pluginpath, pkghashes
+	if v >= Version121 {
+		n += 3 // inittasks
+	}
+	n += 2 + 3 // modulename, modulehashes
+	if v >= Version121 {
+		// hasmain and bad, gcdatamask, gcbssmask, typemap, next
+		n += 1 + 2*2 + 1 + 1
+	} else {
+		// hasmain, gcdatamask, gcbssmask, typemap, bad, next
+		n += 1 + 2*2 + 1 + 1 + 1
+	}
+	return n * uint64(dec.layout.WordSize())
+}
+
+// decode decodes the moduledata at addr in layout v.
+func (dec *decoder) decode(addr uint64, v Version) (*ModuleData, error) {
+	if v <= VersionUnknown || v > Version127 {
+		return nil, fmt.Errorf("unsupported moduledata version %s", v)
+	}
+	d, err := dec.mem.Data(addr, dec.size(v))
+	if err != nil {
+		return nil, fmt.Errorf("reading moduledata: %w", err)
+	}
+	d.Layout = dec.layout
+	r := obj.NewReader(d)
+	ws := dec.layout.WordSize()
+
+	md := &ModuleData{Addr: addr, Version: v, Layout: dec.layout}
+	md.PCHeader = dec.ptr(r)
+	r.Skip(6 * 3 * ws) // pclntab slices
+	md.FindFuncTab = dec.ptr(r)
+	md.MinPC, md.MaxPC = dec.ptr(r), dec.ptr(r)
+	md.Text, md.EText = dec.ptr(r), dec.ptr(r)
+	md.NoPtrData, md.ENoPtrData = dec.ptr(r), dec.ptr(r)
+	md.Data, md.EData = dec.ptr(r), dec.ptr(r)
+	md.BSS, md.EBSS = dec.ptr(r), dec.ptr(r)
+	md.NoPtrBSS, md.ENoPtrBSS = dec.ptr(r), dec.ptr(r)
+	if v >= Version120 {
+		md.CovCtrs, md.ECovCtrs = dec.ptr(r), dec.ptr(r)
+	}
+	md.End, md.GCData, md.GCBSS = dec.ptr(r), dec.ptr(r), dec.ptr(r)
+	md.Types = dec.ptr(r)
+	if v >= Version127 {
+		md.TypeDescLen = r.Word()
+	}
+	md.ETypes = dec.ptr(r)
+	if v >= Version127 {
+		md.ItabOffset, md.ItabSize = r.Word(), r.Word()
+	}
+	if v >= Version118 {
+		md.ROData, md.GoFunc = dec.ptr(r), dec.ptr(r)
+	}
+	if v >= Version127 {
+		md.EPCLNTab = dec.ptr(r)
+	}
+
+	sects, n := dec.slice(r)
+	if md.TextSectMap, err = dec.textSects(sects, n); err != nil {
+		return nil, fmt.Errorf("reading textsectmap: %w", err)
+	}
+	if v < Version127 {
+		links, n := dec.slice(r)
+		if md.Typelinks, err = dec.typelinks(md.Types, links, n); err != nil {
+			return nil, fmt.Errorf("reading typelinks: %w", err)
+		}
+		links, n = dec.slice(r)
+		if md.Itablinks, err = dec.ptrs(links, n); err != nil {
+			return nil, fmt.Errorf("reading itablinks: %w", err)
+		}
+	}
+	ptab, n := dec.slice(r)
+	if md.PTab, err = dec.ptab(ptab, n); err != nil {
+		return nil, fmt.Errorf("reading ptab: %w", err)
+	}
+	if md.PluginPath, err = dec.string(r); err != nil {
+		return nil, fmt.Errorf("reading pluginpath: %w", err)
+	}
+	r.Skip(3 * ws) // pkghashes
+	if v >= Version121 {
+		r.Skip(3 * ws) // inittasks
+	}
+	if md.ModuleName, err = dec.string(r); err != nil {
+		return nil, fmt.Errorf("reading modulename: %w", err)
+	}
+	r.Skip(3 * ws) // modulehashes
+	md.HasMain = r.Peek(1)[0] != 0
+	// Skip to next.
+	r.SetOffset(len(d.B) - ws)
+	md.Next = dec.ptr(r)
+	return md, nil
+}
+
+// ptr reads a pointer from r, applying any relocation.
+func (dec *decoder) ptr(r *obj.Reader) uint64 {
+	sym, val := r.Ptr()
+	if sym != obj.NoSym && dec.file != nil {
+		val += dec.file.Sym(sym).Value
+	}
+	return val
+}
+
+// word reads a pointer at addr.
+func (dec *decoder) word(addr uint64) (uint64, error) {
+	d, err := dec.mem.Data(addr, uint64(dec.layout.WordSize()))
+	if err != nil {
+		return 0, err
+	}
+	d.Layout = dec.layout
+	return dec.ptr(obj.NewReader(d)), nil
+}
+
+// slice reads a slice header from r and returns its base address and
+// length.
+func (dec *decoder) slice(r *obj.Reader) (addr, n uint64) {
+	addr, n = dec.ptr(r), r.Word()
+	r.Word() // cap
+	return
+}
+
+// string reads a string header from r and returns the string.
+func (dec *decoder) string(r *obj.Reader) (string, error) {
+	addr, n := dec.ptr(r), r.Word()
+	if n == 0 {
+		return "", nil
+	}
+	d, err := dec.array(addr, n, 1)
+	if err != nil {
+		return "", err
+	}
+	return string(d.B), nil
+}
+
+// stringAt reads the string whose header is at addr.
+func (dec *decoder) stringAt(addr uint64) (string, error) {
+	d, err := dec.mem.Data(addr, 2*uint64(dec.layout.WordSize()))
+	if err != nil {
+		return "", err
+	}
+	d.Layout = dec.layout
+	return dec.string(obj.NewReader(d))
+}
+
+// array reads n elements of size bytes at addr.
+func (dec *decoder) array(addr, n, size uint64) (*obj.Data, error) {
+	if n > (1<<32)/size {
+		return nil, fmt.Errorf("length %d is too large", n)
+	}
+	d, err := dec.mem.Data(addr, n*size)
+	if err != nil {
+		return nil, err
+	}
+	d.Layout = dec.layout
+	return d, nil
+}
+
+func (dec *decoder) textSects(addr, n uint64) ([]TextSect, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	ws := uint64(dec.layout.WordSize())
+	d, err := dec.array(addr, n, 3*ws)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	sects := make([]TextSect, n)
+	for i := range sects {
+		sects[i] = TextSect{dec.ptr(r), dec.ptr(r), dec.ptr(r)}
+	}
+	return sects, nil
+}
+
+func (dec *decoder) typelinks(types, addr, n uint64) ([]uint64, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	d, err := dec.array(addr, n, 4)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	links := make([]uint64, n)
+	for i := range links {
+		links[i] = types + uint64(r.Int32())
+	}
+	return links, nil
+}
+
+func (dec *decoder) ptrs(addr, n uint64) ([]uint64, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	d, err := dec.array(addr, n, uint64(dec.layout.WordSize()))
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	ptrs := make([]uint64, n)
+	for i := range ptrs {
+		ptrs[i] = dec.ptr(r)
+	}
+	return ptrs, nil
+}
+
+func (dec *decoder) ptab(addr, n uint64) ([]PTabEntry, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	d, err := dec.array(addr, n, 8)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	ptab := make([]PTabEntry, n)
+	for i := range ptab {
+		ptab[i] = PTabEntry{r.Int32(), r.Int32()}
+	}
+	return ptab, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduledata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+const helloGo = `package main
+
+import "fmt"
+
+type T struct{ x int }
+
+func (t T) String() string { return fmt.Sprint(t.x) }
+
+func main() {
+	fmt.Println(T{42})
+}
+`
+
+func TestFind(t *testing.T) {
+	var symAddr uint64
+	for _, test := range []struct {
+		name  string
+		flags []string
+	}{
+		{"default", nil},
+		{"pie", []string{"-buildmode=pie"}},
+		{"stripped", []string{"-ldflags=-s -w"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := gotest.Open(t, gotest.Build(t, helloGo, test.flags...))
+			md, err := Find(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md.Version < Version120 {
+				t.Errorf("want version >= %s, got %s", Version120, md.Version)
+			}
+
+			syms := make(map[string]obj.Sym)
+			for i := obj.SymID(0); i < f.NumSyms(); i++ {
+				sym := f.Sym(i)
+				syms[sym.Name] = sym
+			}
+			switch test.name {
+			case "default":
+				sym, ok := syms["runtime.firstmoduledata"]
+				if !ok {
+					t.Fatal("runtime.firstmoduledata not found")
+				}
+				if md.Addr != sym.Value {
+					t.Errorf("want moduledata at %#x, got %#x", sym.Value, md.Addr)
+				}
+				symAddr = sym.Value
+				for name, got := range map[string]uint64{
+					"runtime.text":      md.Text,
+					"runtime.etext":     md.EText,
+					"runtime.noptrdata": md.NoPtrData,
+					"runtime.data":      md.Data,
+					"runtime.bss":       md.BSS,
+					"runtime.noptrbss":  md.NoPtrBSS,
+					"runtime.end":       md.End,
+					"runtime.types":     md.Types,
+					"runtime.etypes":    md.ETypes,
+					"runtime.gcdata":    md.GCData,
+					"runtime.gcbss":     md.GCBSS,
+					"go:func.*":         md.GoFunc,
+				} {
+					if sym, ok := syms[name]; !ok || sym.Value != got {
+						t.Errorf("want %s = %#x, got %#x", name, sym.Value, got)
+					}
+				}
+			case "stripped":
+				if len(syms) != 0 {
+					t.Fatalf("binary has %d symbols", len(syms))
+				}
+				// Stripping only removes sections after the data, so
+				// the moduledata doesn't move.
+				if symAddr != 0 && md.Addr != symAddr {
+					t.Errorf("want moduledata at %#x, got %#x", symAddr, md.Addr)
+				}
+			}
+
+			// Check section bounds.
+			for name, got := range map[string][2]uint64{
+				".text":      {md.Text, md.EText},
+				".noptrdata": {md.NoPtrData, md.ENoPtrData},
+				".data":      {md.Data, md.EData},
+				".bss":       {md.BSS, md.EBSS},
+				".noptrbss":  {md.NoPtrBSS, md.ENoPtrBSS},
+			} {
+				s := f.SectionByName(name)
+				if s == nil {
+					t.Errorf("section %s not found", name)
+					continue
+				}
+				// The Go linker puts some symbols before runtime.text.
+				if name == ".text" {
+					if got[0] < s.Addr || got[1] > s.Addr+s.Size {
+						t.Errorf("want %s in [%#x,%#x), got [%#x,%#x)", name, s.Addr, s.Addr+s.Size, got[0], got[1])
+					}
+					continue
+				}
+				if want := [2]uint64{s.Addr, s.Addr + s.Size}; got != want {
+					t.Errorf("want %s at [%#x,%#x), got [%#x,%#x)", name, want[0], want[1], got[0], got[1])
+				}
+			}
+			if s := f.SectionByName(".gopclntab"); s == nil || md.PCHeader != s.Addr {
+				t.Errorf("want pclntab at %v, got %#x", s, md.PCHeader)
+			}
+			if md.MinPC < md.Text || md.MaxPC > md.EText || md.MinPC >= md.MaxPC {
+				t.Errorf("bad PC range [%#x,%#x) for text [%#x,%#x)", md.MinPC, md.MaxPC, md.Text, md.EText)
+			}
+			if !md.HasMain || md.ModuleName != "" || md.PluginPath != "" || md.Next != 0 {
+				t.Errorf("got HasMain %v, ModuleName %q, PluginPath %q, Next %#x", md.HasMain, md.ModuleName, md.PluginPath, md.Next)
+			}
+
+			// Check type data.
+			if md.Version >= Version127 {
+				if md.TypeDescLen == 0 || md.ItabSize == 0 || md.Types+md.ItabOffset+md.ItabSize > md.ETypes {
+					t.Errorf("bad type data: types [%#x,%#x), TypeDescLen %#x, itabs %#x+%#x", md.Types, md.ETypes, md.TypeDescLen, md.ItabOffset, md.ItabSize)
+				}
+			} else {
+				if len(md.Typelinks) == 0 || len(md.Itablinks) == 0 {
+					t.Errorf("want typelinks and itablinks, got %d and %d", len(md.Typelinks), len(md.Itablinks))
+				}
+				for _, addr := range md.Typelinks {
+					if addr < md.Types || addr >= md.ETypes {
+						t.Errorf("typelink %#x outside types [%#x,%#x)", addr, md.Types, md.ETypes)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, helloGo))
+	md, err := Find(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md2, err := Read(FileMemory(f), md.Layout, md.Addr, md.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md2.Text != md.Text || md2.Types != md.Types || md2.GoFunc != md.GoFunc {
+		t.Errorf("Read returned different module data: %+v, want %+v", md2, md)
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	var want string
+	for _, flags := range [][]string{nil, {"-ldflags=-s -w"}} {
+		f := gotest.Open(t, gotest.Build(t, helloGo, flags...))
+		v, err := GoVersion(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(v, "go1.") && !strings.HasPrefix(v, "devel ") {
+			t.Errorf("%v: bad Go version %q", flags, v)
+		}
+		if want == "" {
+			want = v
+		} else if v != want {
+			t.Errorf("%v: want Go version %q, got %q", flags, want, v)
+		}
+	}
+}
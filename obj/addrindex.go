@@ -0,0 +1,67 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"sort"
+
+	"github.com/aclements/go-obj/internal/imap"
+)
+
+// An AddrIndex maps addresses to the sections containing them.
+//
+// File.ResolveAddr uses an AddrIndex of a File's mapped sections. An
+// AddrIndex can also be constructed over other sets of sections, such as
+// the non-mapped sections of a file that uses a separate address space
+// for them.
+//
+// Sections may overlap. An address in more than one section resolves to
+// whichever of those sections appears first in the slice passed to
+// NewAddrIndex. Empty sections don't contain any addresses.
+type AddrIndex struct {
+	// ranges is a sorted list of disjoint address ranges.
+	ranges []addrRange
+}
+
+type addrRange struct {
+	low, high uint64
+	s         *Section
+}
+
+// NewAddrIndex returns an index of the addresses of sections.
+func NewAddrIndex(sections []*Section) *AddrIndex {
+	// Insert sections in reverse so earlier sections take precedence
+	// where they overlap later sections.
+	var m imap.Imap
+	for i := len(sections) - 1; i >= 0; i-- {
+		s := sections[i]
+		high := s.Addr + s.Size
+		if high < s.Addr {
+			// Clamp sections that extend past the end of the address
+			// space.
+			high = ^uint64(0)
+		}
+		m.Insert(imap.Interval{Low: s.Addr, High: high}, s)
+	}
+
+	x := new(AddrIndex)
+	for it := m.Iter(0); it.Valid(); it.Next() {
+		key := it.Key()
+		x.ranges = append(x.ranges, addrRange{key.Low, key.High, it.Value().(*Section)})
+	}
+	return x
+}
+
+// Resolve returns the section containing addr, or nil if no section in
+// the index contains addr.
+func (x *AddrIndex) Resolve(addr uint64) *Section {
+	i := sort.Search(len(x.ranges), func(i int) bool {
+		return addr < x.ranges[i].high
+	})
+	if i < len(x.ranges) && x.ranges[i].low <= addr {
+		return x.ranges[i].s
+	}
+	return nil
+}
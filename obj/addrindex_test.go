@@ -0,0 +1,42 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import "testing"
+
+func TestAddrIndex(t *testing.T) {
+	a := &Section{Name: "a", Addr: 0x1000, Size: 0x100}
+	b := &Section{Name: "b", Addr: 0x1080, Size: 0x100} // Overlaps a
+	c := &Section{Name: "c", Addr: 0x1200, Size: 0}     // Empty
+	d := &Section{Name: "d", Addr: 0x1300, Size: 0x10}
+	e := &Section{Name: "e", Addr: 0x1000, Size: 0x400} // Covers everything
+	x := NewAddrIndex([]*Section{a, b, c, d, e})
+	for _, test := range []struct {
+		addr uint64
+		want *Section
+	}{
+		{0xfff, nil},
+		{0x1000, a},
+		{0x10ff, a},
+		{0x1100, b},
+		{0x117f, b},
+		{0x1180, e},
+		{0x1200, e},
+		{0x1300, d},
+		{0x130f, d},
+		{0x1310, e},
+		{0x13ff, e},
+		{0x1400, nil},
+	} {
+		if got := x.Resolve(test.addr); got != test.want {
+			t.Errorf("Resolve(%#x): want %v, got %v", test.addr, test.want, got)
+		}
+	}
+
+	// Empty index.
+	if got := NewAddrIndex(nil).Resolve(0); got != nil {
+		t.Errorf("Resolve on empty index: want nil, got %v", got)
+	}
+}
@@ -6,7 +6,10 @@ package obj
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/aclements/go-obj/arch"
 )
@@ -20,13 +23,17 @@ type Data struct {
 	Addr uint64
 
 	// B stores the raw byte data. Callers must not modify this.
+	//
+	// B may refer to memory owned by the File this Data came from.
+	// After the File is closed, B remains safe to read, but may read as
+	// zeros unless the Data has been retained. See Retain.
 	B []byte
 
 	// R stores the relocations applied to this Data in increasing
 	// address order.
 	//
-	// This may include relocations outside or partially outside of this
-	// Data's address range.
+	// This may include relocations partially outside of this Data's
+	// address range.
 	R []Reloc
 
 	// Layout specifies the byte order and word size of this data. This
@@ -34,23 +41,170 @@ type Data struct {
 	// not be correct for sections or symbols that have a fixed byte
 	// order regardless of the host order.
 	Layout arch.Layout
+
+	// refs is the reference count of the memory backing B, or nil if
+	// B's lifetime isn't tracked.
+	refs *refCount
+}
+
+// Retain ensures that d.B remains valid until a matching call to
+// Release, even if the File d came from is closed in the meantime.
+//
+// Without Retain, closing a File never invalidates memory that a Data
+// refers to, but it may replace the contents of d.B with zeros. Retain
+// must be called before the File is closed; otherwise it panics.
+func (d *Data) Retain() {
+	if d.refs != nil {
+		d.refs.inc()
+	}
+}
+
+// Release releases a reference acquired by Retain. Once all references
+// to a closed File's data are released, its resources are freed, and
+// d.B may read as zeros.
+func (d *Data) Release() {
+	if d.refs != nil {
+		d.refs.dec()
+	}
+}
+
+// refCount is a reference count that calls release when it reaches
+// zero. The count starts at 1, which is the reference held by the
+// owner. Once the owner drops its reference with close, no new
+// references can be acquired.
+type refCount struct {
+	mu      sync.Mutex
+	n       int
+	closed  bool
+	release func()
+}
+
+func newRefCount(release func()) *refCount {
+	return &refCount{n: 1, release: release}
+}
+
+// acquire acquires a reference and reports whether it succeeded. It
+// fails if the owner has dropped its reference.
+func (r *refCount) acquire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	r.n++
+	return true
 }
 
+func (r *refCount) inc() {
+	if !r.acquire() {
+		panic("Data.Retain called after File was closed")
+	}
+}
+
+// close drops the owner's reference. Calling close more than once has
+// no effect.
+func (r *refCount) close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	r.mu.Unlock()
+	r.dec()
+}
+
+func (r *refCount) dec() {
+	r.mu.Lock()
+	r.n--
+	n := r.n
+	r.mu.Unlock()
+	if n < 0 {
+		panic("too many Data.Release calls")
+	}
+	if n == 0 {
+		r.release()
+	}
+}
+
+// RelocAt returns the first relocation in d.R that applies at exactly
+// address addr, or nil if there is no such relocation.
+func (d *Data) RelocAt(addr uint64) *Reloc {
+	i := sort.Search(len(d.R), func(i int) bool {
+		return d.R[i].Addr >= addr
+	})
+	if i < len(d.R) && d.R[i].Addr == addr {
+		return &d.R[i]
+	}
+	return nil
+}
+
+// A Reader reads binary values from a Data.
+//
+// By default, a Reader panics if a read goes past the end of its Data.
+// This is the fast path for trusted input. A Reader created by
+// NewCheckedReader instead latches the first error, much like
+// bufio.Scanner: the failing read and all subsequent reads return zero
+// values, and the error can be retrieved with Err.
 type Reader struct {
 	d *Data
 	p int // Offset into P
+
+	checked bool
+	err     error
 }
 
+// NewReader returns a Reader positioned at the beginning of d that
+// panics on out-of-range reads.
 func NewReader(d *Data) *Reader {
-	return &Reader{d, 0}
+	return &Reader{d: d}
+}
+
+// NewCheckedReader returns a Reader positioned at the beginning of d
+// that records an error rather than panicking. Out-of-range reads or
+// seeks record an *ErrOutOfRange error; malformed encodings, such as a
+// Uvarint that overflows 64 bits, record other errors. The caller
+// should check Err after reading.
+func NewCheckedReader(d *Data) *Reader {
+	return &Reader{d: d, checked: true}
+}
+
+// Err returns the first error encountered by a checked Reader, or nil.
+// Unchecked Readers always return nil.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// fail reports an out-of-range access of size bytes at offset off.
+func (r *Reader) fail(off int, size uint64) {
+	err := &ErrOutOfRange{
+		Addr: r.d.Addr + uint64(off), Size: size,
+		Low: r.d.Addr, High: r.d.Addr + uint64(len(r.d.B)),
+	}
+	r.setErr(err)
+}
+
+// setErr reports a decoding error. For unchecked Readers, it panics.
+// For checked Readers, it records err if this is the first error and
+// moves the cursor to the end of r's Data.
+func (r *Reader) setErr(err error) {
+	if !r.checked {
+		panic(err)
+	}
+	if r.err == nil {
+		r.err = err
+	}
+	r.p = len(r.d.B)
 }
 
 // SetAddr moves r's cursor to the given address. If addr is out of
-// range for r's Data, it panics.
+// range for r's Data, it panics, or, for checked Readers, records an
+// error.
 func (r *Reader) SetAddr(addr uint64) {
 	o := int(addr - r.d.Addr)
-	if addr < r.d.Addr || o >= len(r.d.B) {
-		panic(fmt.Sprintf("address 0x%x out of data's range [0x%x,0x%x)", addr, r.d.Addr, r.d.Addr+uint64(len(r.d.B))))
+	if addr < r.d.Addr || o < 0 || o >= len(r.d.B) {
+		r.fail(o, 0)
+		return
 	}
 	r.p = o
 }
@@ -61,18 +215,16 @@ func (r *Reader) Addr() uint64 {
 }
 
 // SetOffset moves r's cursor to the given offset from the beginning of
-// r's data.
+// r's data. If offset is out of range, it panics, or, for checked
+// Readers, records an error.
 func (r *Reader) SetOffset(offset int) {
 	if offset < 0 || offset >= len(r.d.B) {
-		r.badOffset(offset)
+		r.fail(offset, 0)
+		return
 	}
 	r.p = offset
 }
 
-func (r *Reader) badOffset(offset int) {
-	panic(fmt.Sprintf("offset %d out of data's range [0,%d)", offset, len(r.d.B)))
-}
-
 // Avail returns the number of bytes remaining in r's Data.
 func (r *Reader) Avail() int {
 	return len(r.d.B) - r.p
@@ -80,26 +232,42 @@ func (r *Reader) Avail() int {
 
 func (r *Reader) Uint8() uint8 {
 	o := r.p
+	if o >= len(r.d.B) {
+		r.fail(o, 1)
+		return 0
+	}
 	r.p++
 	return r.d.B[o]
 }
 
 func (r *Reader) Uint16() uint16 {
 	o := r.p
+	if len(r.d.B)-o < 2 {
+		r.fail(o, 2)
+		return 0
+	}
 	r.p += 2
-	return r.d.Layout.Uint16(r.d.B[o : o+2])
+	return r.d.Layout.Uint16(r.d.B[o:])
 }
 
 func (r *Reader) Uint32() uint32 {
 	o := r.p
+	if len(r.d.B)-o < 4 {
+		r.fail(o, 4)
+		return 0
+	}
 	r.p += 4
-	return r.d.Layout.Uint32(r.d.B[o : o+4])
+	return r.d.Layout.Uint32(r.d.B[o:])
 }
 
 func (r *Reader) Uint64() uint64 {
 	o := r.p
+	if len(r.d.B)-o < 8 {
+		r.fail(o, 8)
+		return 0
+	}
 	r.p += 8
-	return r.d.Layout.Uint64(r.d.B[o : o+8])
+	return r.d.Layout.Uint64(r.d.B[o:])
 }
 
 func (r *Reader) Int8() int8   { return int8(r.Uint8()) }
@@ -110,10 +278,33 @@ func (r *Reader) Int64() int64 { return int64(r.Uint64()) }
 // Word reads a word from r using the word size from r's Data.
 func (r *Reader) Word() uint64 {
 	o := r.p
-	r.p += r.d.Layout.WordSize()
+	n := r.d.Layout.WordSize()
+	if len(r.d.B)-o < n {
+		r.fail(o, uint64(n))
+		return 0
+	}
+	r.p += n
 	return r.d.Layout.Word(r.d.B[o:])
 }
 
+// Ptr reads a pointer-sized word from r. If a relocation of the same
+// size applies at the word's address, it returns the relocation's
+// target symbol and addend. Otherwise, it returns NoSym and the raw
+// word value.
+//
+// In relocatable objects and position-independent executables, the raw
+// bytes of a pointer are often meaningless without the relocation that
+// applies to them. Note that relocations without a symbol (such as
+// R_X86_64_RELATIVE) also return NoSym, with the addend as the value.
+func (r *Reader) Ptr() (sym SymID, val uint64) {
+	addr := r.Addr()
+	val = r.Word()
+	if rel := r.d.RelocAt(addr); rel != nil && rel.Type.Size() == r.d.Layout.WordSize() {
+		return rel.Symbol, uint64(rel.Addend)
+	}
+	return NoSym, val
+}
+
 // CString reads a NULL-terminated string. The result omits the final
 // NULL byte. If there is no NULL, this reads to the end of r's Data.
 func (r *Reader) CString() []byte {
@@ -126,3 +317,149 @@ func (r *Reader) CString() []byte {
 	r.p += n + 1
 	return s[:n]
 }
+
+// Bytes reads n bytes. The result aliases r's Data, so the caller
+// must not modify it.
+func (r *Reader) Bytes(n int) []byte {
+	o := r.p
+	if n < 0 || len(r.d.B)-o < n {
+		r.fail(o, uint64(n))
+		return nil
+	}
+	r.p += n
+	return r.d.B[o : o+n : o+n]
+}
+
+// Peek returns the next n bytes without advancing r's cursor. The
+// result aliases r's Data, so the caller must not modify it. If fewer
+// than n bytes remain, Peek fails like other reads, but a checked
+// Reader leaves its cursor where it was.
+func (r *Reader) Peek(n int) []byte {
+	o := r.p
+	if n < 0 || len(r.d.B)-o < n {
+		r.fail(o, uint64(n))
+		r.p = o
+		return nil
+	}
+	return r.d.B[o : o+n : o+n]
+}
+
+// Skip advances r's cursor by n bytes. It is valid to skip to exactly
+// the end of r's Data.
+func (r *Reader) Skip(n int) {
+	if n < 0 || len(r.d.B)-r.p < n {
+		r.fail(r.p, uint64(n))
+		return
+	}
+	r.p += n
+}
+
+// Align advances r's cursor to the next address that is a multiple of
+// n, which must be a power of 2.
+func (r *Reader) Align(n int) {
+	addr := r.Addr()
+	r.Skip(int(roundUp2(addr, uint64(n)) - addr))
+}
+
+// ULEB128 reads an unsigned LEB128-encoded integer. Bits beyond 64
+// are discarded.
+func (r *Reader) ULEB128() uint64 {
+	var v uint64
+	var shift uint
+	b := r.d.B
+	for p := r.p; p < len(b); p++ {
+		x := b[p]
+		if shift < 64 {
+			v |= uint64(x&0x7f) << shift
+		}
+		shift += 7
+		if x&0x80 == 0 {
+			r.p = p + 1
+			return v
+		}
+	}
+	r.fail(r.p, uint64(len(b)-r.p+1))
+	return 0
+}
+
+// SLEB128 reads a signed LEB128-encoded integer. Bits beyond 64 are
+// discarded.
+func (r *Reader) SLEB128() int64 {
+	var v int64
+	var shift uint
+	b := r.d.B
+	for p := r.p; p < len(b); p++ {
+		x := b[p]
+		if shift < 64 {
+			v |= int64(x&0x7f) << shift
+		}
+		shift += 7
+		if x&0x80 == 0 {
+			if shift < 64 && x&0x40 != 0 {
+				// Sign extend.
+				v |= -1 << shift
+			}
+			r.p = p + 1
+			return v
+		}
+	}
+	r.fail(r.p, uint64(len(b)-r.p+1))
+	return 0
+}
+
+// Uvarint reads a Go unsigned varint, as encoded by
+// encoding/binary.PutUvarint. This is the same encoding as ULEB128, but
+// values that overflow 64 bits are an error. This error is not an
+// *ErrOutOfRange.
+func (r *Reader) Uvarint() uint64 {
+	v, n := binary.Uvarint(r.d.B[r.p:])
+	if n <= 0 {
+		if n == 0 {
+			r.fail(r.p, uint64(len(r.d.B)-r.p+1))
+		} else {
+			r.setErr(fmt.Errorf("varint at 0x%x overflows 64 bits", r.Addr()))
+		}
+		return 0
+	}
+	r.p += n
+	return v
+}
+
+// Varint reads a Go signed varint, as encoded by
+// encoding/binary.PutVarint.
+func (r *Reader) Varint() int64 {
+	ux := r.Uvarint()
+	x := int64(ux >> 1)
+	if ux&1 != 0 {
+		x = ^x
+	}
+	return x
+}
+
+// UvarintBytes reads a byte slice prefixed by its length encoded as a
+// Go unsigned varint. This is the encoding of strings in many Go
+// runtime and toolchain metadata formats. The result aliases r's Data,
+// so the caller must not modify it.
+func (r *Reader) UvarintBytes() []byte {
+	o := r.p
+	n := r.Uvarint()
+	if n > uint64(r.Avail()) {
+		r.fail(o, uint64(r.p-o)+n)
+		return nil
+	}
+	return r.Bytes(int(n))
+}
+
+// An ErrOutOfRange error indicates that a requested range of addresses
+// is outside the bounds of a Section, Sym, or Data.
+type ErrOutOfRange struct {
+	// Addr and Size give the requested range.
+	Addr, Size uint64
+
+	// Low and High give the valid address range [Low, High).
+	Low, High uint64
+}
+
+func (e *ErrOutOfRange) Error() string {
+	return fmt.Sprintf("requested data [0x%x, 0x%x) is outside [0x%x, 0x%x)", e.Addr, e.Addr+e.Size, e.Low, e.High)
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/aclements/go-obj/arch"
+)
+
+func TestReaderPanics(t *testing.T) {
+	d := &Data{Addr: 0x1000, B: []byte{1, 2, 3}, Layout: arch.AMD64.Layout}
+	r := NewReader(d)
+	if got := r.Uint16(); got != 0x0201 {
+		t.Fatalf("want 0x0201, got %#x", got)
+	}
+	defer func() {
+		err, ok := recover().(*ErrOutOfRange)
+		if !ok {
+			t.Fatalf("want *ErrOutOfRange panic, got %v", err)
+		}
+		want := ErrOutOfRange{Addr: 0x1002, Size: 4, Low: 0x1000, High: 0x1003}
+		if *err != want {
+			t.Fatalf("want %+v, got %+v", want, *err)
+		}
+	}()
+	r.Uint32()
+	t.Fatalf("out-of-range read did not panic")
+}
+
+func TestReaderChecked(t *testing.T) {
+	d := &Data{Addr: 0x1000, B: []byte{1, 2, 3, 4, 5, 6}, Layout: arch.AMD64.Layout}
+	r := NewCheckedReader(d)
+	if got := r.Uint32(); got != 0x04030201 || r.Err() != nil {
+		t.Fatalf("want 0x04030201, <nil>; got %#x, %v", got, r.Err())
+	}
+	// This fails and latches the error, even though a smaller read
+	// would succeed.
+	if got := r.Uint64(); got != 0 {
+		t.Errorf("want 0 from failed read, got %#x", got)
+	}
+	if got := r.Uint8(); got != 0 {
+		t.Errorf("want 0 after failed read, got %#x", got)
+	}
+	var err *ErrOutOfRange
+	if !errors.As(r.Err(), &err) {
+		t.Fatalf("want *ErrOutOfRange, got %v", r.Err())
+	}
+	want := ErrOutOfRange{Addr: 0x1004, Size: 8, Low: 0x1000, High: 0x1006}
+	if *err != want {
+		t.Fatalf("want %+v, got %+v", want, *err)
+	}
+
+	// Seeking out of range is also an error.
+	r = NewCheckedReader(d)
+	r.SetAddr(0x2000)
+	if r.Err() == nil {
+		t.Errorf("SetAddr out of range did not fail")
+	}
+	r = NewCheckedReader(d)
+	r.SetOffset(-1)
+	if r.Err() == nil {
+		t.Errorf("SetOffset out of range did not fail")
+	}
+}
+
+func TestReaderLEB128(t *testing.T) {
+	// Examples from DWARF 5, section 7.6.
+	for _, test := range []struct {
+		enc []byte
+		u   uint64
+		s   int64
+	}{
+		{[]byte{0x02}, 2, 2},
+		{[]byte{0x7e}, 126, -2},
+		{[]byte{0x7f}, 127, -1},
+		{[]byte{0xff, 0x00}, 127, 127},
+		{[]byte{0x81, 0x7f}, 16257, -127},
+		{[]byte{0x80, 0x01}, 128, 128},
+		{[]byte{0x80, 0x7f}, 16256, -128},
+		{[]byte{0x81, 0x01}, 129, 129},
+		{[]byte{0xff, 0x7e}, 16255, -129},
+		{[]byte{0xb9, 0x64}, 12857, -3527},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ^uint64(0), -1},
+		// Overlong encodings are allowed in LEB128.
+		{[]byte{0x82, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, 2, 2},
+	} {
+		d := &Data{B: append(test.enc, 0xaa), Layout: arch.AMD64.Layout}
+		r := NewReader(d)
+		if got := r.ULEB128(); got != test.u || r.Avail() != 1 {
+			t.Errorf("ULEB128(%x): want %d with 1 byte left, got %d with %d left", test.enc, test.u, got, r.Avail())
+		}
+		r = NewReader(d)
+		if got := r.SLEB128(); got != test.s || r.Avail() != 1 {
+			t.Errorf("SLEB128(%x): want %d with 1 byte left, got %d with %d left", test.enc, test.s, got, r.Avail())
+		}
+	}
+
+	// Truncated encodings.
+	r := NewCheckedReader(&Data{B: []byte{0x80, 0x80}})
+	r.ULEB128()
+	if _, ok := r.Err().(*ErrOutOfRange); !ok {
+		t.Errorf("truncated ULEB128: want *ErrOutOfRange, got %v", r.Err())
+	}
+}
+
+func TestReaderVarint(t *testing.T) {
+	var buf []byte
+	vals := []int64{0, 1, -1, 63, -64, 64, 1 << 40, -1 << 63, 1<<63 - 1}
+	for _, v := range vals {
+		var tmp [binary.MaxVarintLen64]byte
+		buf = append(buf, tmp[:binary.PutVarint(tmp[:], v)]...)
+		buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(v))]...)
+	}
+	r := NewReader(&Data{B: buf})
+	for _, v := range vals {
+		if got := r.Varint(); got != v {
+			t.Errorf("Varint: want %d, got %d", v, got)
+		}
+		if got := r.Uvarint(); got != uint64(v) {
+			t.Errorf("Uvarint: want %d, got %d", uint64(v), got)
+		}
+	}
+	if r.Avail() != 0 {
+		t.Errorf("want 0 bytes left, got %d", r.Avail())
+	}
+
+	// Overflow.
+	r = NewCheckedReader(&Data{B: bytes.Repeat([]byte{0xff}, 11)})
+	r.Uvarint()
+	if err := r.Err(); err == nil {
+		t.Errorf("overlong Uvarint: want error, got nil")
+	} else if _, ok := err.(*ErrOutOfRange); ok {
+		t.Errorf("overlong Uvarint: want overflow error, got %v", err)
+	}
+}
+
+func TestReaderBytes(t *testing.T) {
+	d := &Data{Addr: 0x1001, B: []byte("\x05hello\x00abc\x10"), Layout: arch.AMD64.Layout}
+	r := NewCheckedReader(d)
+	if got := string(r.UvarintBytes()); got != "hello" {
+		t.Errorf("UvarintBytes: want hello, got %q", got)
+	}
+	r.Align(4)
+	if r.Addr() != 0x1008 {
+		t.Errorf("Align(4): want address 0x1008, got %#x", r.Addr())
+	}
+	if got := string(r.Peek(2)); got != "ab" || r.Addr() != 0x1008 {
+		t.Errorf("Peek(2): want ab at 0x1008, got %q at %#x", got, r.Addr())
+	}
+	r.Skip(1)
+	if got := string(r.Bytes(2)); got != "bc" {
+		t.Errorf("Bytes(2): want bc, got %q", got)
+	}
+	// Length prefix that runs past the end.
+	if got := r.UvarintBytes(); got != nil {
+		t.Errorf("UvarintBytes past end: want nil, got %q", got)
+	}
+	if _, ok := r.Err().(*ErrOutOfRange); !ok {
+		t.Errorf("UvarintBytes past end: want *ErrOutOfRange, got %v", r.Err())
+	}
+
+	// A failed Peek doesn't move the cursor.
+	r = NewCheckedReader(d)
+	r.Skip(8)
+	if got := r.Peek(4); got != nil || r.Addr() != 0x1009 {
+		t.Errorf("Peek(4) past end: want nil at 0x1009, got %q at %#x", got, r.Addr())
+	}
+	if _, ok := r.Err().(*ErrOutOfRange); !ok {
+		t.Errorf("Peek(4) past end: want *ErrOutOfRange, got %v", r.Err())
+	}
+
+	r = NewCheckedReader(d)
+	r.Skip(len(d.B))
+	if r.Err() != nil || r.Avail() != 0 {
+		t.Errorf("Skip to end: want no error, got %v", r.Err())
+	}
+}
+
+var benchData = &Data{B: generateBenchData(16 << 10), Layout: arch.AMD64.Layout}
+
+func generateBenchData(size int) []byte {
+	out := make([]byte, size)
+	for i := range out {
+		// Mix of single- and multi-byte LEB128 values and NULs.
+		switch i % 4 {
+		case 0, 1:
+			out[i] = 0x80 | byte(i)
+		case 2:
+			out[i] = 0x7f & byte(i>>2)
+		case 3:
+			out[i] = 0
+		}
+	}
+	return out
+}
+
+func BenchmarkReader(b *testing.B) {
+	bench := func(name string, read func(r *Reader)) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(benchData.B)))
+			for i := 0; i < b.N; i++ {
+				r := NewReader(benchData)
+				for r.Avail() > 0 {
+					read(r)
+				}
+			}
+		})
+	}
+	bench("Uint8", func(r *Reader) { r.Uint8() })
+	bench("Uint32", func(r *Reader) { r.Uint32() })
+	bench("Uint64", func(r *Reader) { r.Uint64() })
+	bench("Word", func(r *Reader) { r.Word() })
+	bench("CString", func(r *Reader) { r.CString() })
+	bench("ULEB128", func(r *Reader) { r.ULEB128() })
+	bench("SLEB128", func(r *Reader) { r.SLEB128() })
+	bench("Uvarint", func(r *Reader) { r.Uvarint() })
+	bench("Bytes", func(r *Reader) { r.Bytes(4) })
+	bench("Skip", func(r *Reader) { r.Skip(4) })
+}
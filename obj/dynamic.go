@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import "fmt"
+
+// Dynamic describes the dynamic linking information of an object file.
+type Dynamic struct {
+	// SOName is the shared object name of this file, or "" if it
+	// doesn't have one.
+	SOName string
+
+	// Needed lists the names of the shared libraries this file depends
+	// on, in order.
+	Needed []string
+
+	// RPath and RunPath list the library search directories recorded in
+	// this file. These may contain dynamic string tokens such as
+	// $ORIGIN.
+	RPath, RunPath []string
+
+	// Versions maps dynamic symbols to their versions. Unversioned
+	// symbols are not in the map.
+	Versions map[SymID]SymVersion
+}
+
+// SymVersion is the version of a dynamic symbol.
+type SymVersion struct {
+	// Name is the name of the version, such as "GLIBC_2.2.5".
+	Name string
+
+	// Hidden indicates this is not the default version of a defined
+	// symbol. Hidden versions can only be bound by references that
+	// request that version explicitly.
+	Hidden bool
+
+	// Library is the name of the shared library expected to define an
+	// undefined symbol at this version. It is "" for defined symbols.
+	Library string
+}
+
+// String returns v in the conventional "name@version" suffix form,
+// such as "@GLIBC_2.2.5" or "@@GLIBC_2.2.5" for a default version.
+func (v SymVersion) String() string {
+	if v.Name == "" {
+		return ""
+	}
+	if v.Hidden || v.Library != "" {
+		return "@" + v.Name
+	}
+	return "@@" + v.Name
+}
+
+// ReadDynamic returns the dynamic linking information of f. If f is
+// not dynamically linked, it returns an empty Dynamic.
+func ReadDynamic(f File) (*Dynamic, error) {
+	switch f := f.(type) {
+	case *elfFile:
+		return f.readDynamic()
+	}
+	return nil, fmt.Errorf("dynamic linking information is not supported for this object file format")
+}
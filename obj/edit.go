@@ -0,0 +1,109 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"fmt"
+	"io"
+)
+
+// An Edit records a set of modifications to an object file. Applying
+// an Edit with Write produces a new object file; the original File is
+// never modified.
+//
+// Currently only ELF files can be edited.
+//
+// Sections that are loaded into memory by a program header (in ELF
+// executables and shared objects) keep their file position and size,
+// since moving them would invalidate the program image. Such sections
+// can be patched or replaced with data of the same size. All other
+// sections may be freely removed, replaced, or added, and are laid out
+// again after the loaded image.
+type Edit struct {
+	f File
+
+	removed  map[SectionID]bool
+	replaced map[SectionID][]byte
+	added    []editSection
+	patches  []editPatch
+	syms     []Sym
+}
+
+type editSection struct {
+	name string
+	data []byte
+}
+
+type editPatch struct {
+	s    *Section
+	addr uint64
+	b    []byte
+}
+
+// NewEdit returns a new, empty set of edits to f.
+func NewEdit(f File) *Edit {
+	return &Edit{
+		f:        f,
+		removed:  make(map[SectionID]bool),
+		replaced: make(map[SectionID][]byte),
+	}
+}
+
+// RemoveSection removes section s from the output. Relocation sections
+// that apply to s are removed along with it, as are symbols defined in
+// s.
+func (e *Edit) RemoveSection(s *Section) {
+	e.checkSection(s)
+	e.removed[s.ID] = true
+}
+
+// ReplaceSection replaces the contents of section s with data. Any
+// patches to s are applied on top of data.
+func (e *Edit) ReplaceSection(s *Section, data []byte) {
+	e.checkSection(s)
+	e.replaced[s.ID] = data
+}
+
+// AddSection adds a new, non-loaded section with the given name and
+// contents. Following the convention of objcopy, sections whose names
+// begin with ".note" are added as note sections.
+func (e *Edit) AddSection(name string, data []byte) {
+	e.added = append(e.added, editSection{name, data})
+}
+
+// Patch overwrites the bytes of section s starting at address addr with
+// b. It panics if the range is outside of s.
+func (e *Edit) Patch(s *Section, addr uint64, b []byte) {
+	e.checkSection(s)
+	if addr < s.Addr || addr+uint64(len(b)) < addr || addr+uint64(len(b)) > s.Addr+s.Size {
+		panic(fmt.Sprintf("patch [0x%x, 0x%x) is outside section [0x%x, 0x%x)", addr, addr+uint64(len(b)), s.Addr, s.Addr+s.Size))
+	}
+	e.patches = append(e.patches, editPatch{s, addr, b})
+}
+
+// AddSym adds sym to the static symbol table, creating the symbol table
+// if necessary. sym.Section must be nil or a section of the File being
+// edited.
+func (e *Edit) AddSym(sym Sym) {
+	if sym.Section != nil {
+		e.checkSection(sym.Section)
+	}
+	e.syms = append(e.syms, sym)
+}
+
+func (e *Edit) checkSection(s *Section) {
+	if s.File != e.f {
+		panic(fmt.Sprintf("section %s is not in the edited file", s))
+	}
+}
+
+// Write writes the edited object file to w.
+func (e *Edit) Write(w io.Writer) error {
+	switch f := e.f.(type) {
+	case *elfFile:
+		return f.writeEdit(e, w)
+	}
+	return fmt.Errorf("editing is not supported for this object file format")
+}
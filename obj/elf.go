@@ -8,11 +8,10 @@ import (
 	"debug/dwarf"
 	"debug/elf"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"os"
+	"sort"
+	"strings"
 	"sync"
-	"syscall"
 
 	"github.com/aclements/go-obj/arch"
 )
@@ -21,10 +20,12 @@ type elfFile struct {
 	f *elf.File
 	elfArch
 
-	// fd is the mmap-able FD of this file, or ^0.
-	fd uintptr
-	// pageSize is the system page size for mmapping.
-	pageSize uint64
+	// src provides the raw bytes of the ELF file.
+	src Source
+
+	// refs counts references to src from the File and from retained
+	// Data. When it drops to zero, src is closed.
+	refs *refCount
 
 	// elfLayout is the data layout of the ELF file itself (as opposed
 	// to the architecture).
@@ -48,12 +49,29 @@ type elfFile struct {
 	// In general, prefer lookupShn, which performs checking.
 	shnToSection []*elfSection
 
+	// byName maps section names to the first section with that name.
+	byName map[string]*Section
+
+	// addrIndexOnce guards lazily constructing addrIndex, the index
+	// used by ResolveAddr.
+	addrIndexOnce sync.Once
+	addrIndex     *AddrIndex
+
 	// symTabs stores the static (index 0) and dynamic (index 1) symbol
 	// tables, if they exist.
 	//
 	// [TIS ELF 1.2 Book III, p. 1-2] There may be at most one of each
 	// type of symbol table section.
 	symTabs [2]elfSymTab
+
+	// hashOnce guards lazily loading hash, the dynamic symbol hash
+	// table used by DynamicSymByName, and versym, the DT_VERSYM table
+	// of the dynamic symbols. hash is nil if the file doesn't have a
+	// usable hash table. versym is nil if the file doesn't have symbol
+	// versions.
+	hashOnce sync.Once
+	hash     *elfHashTable
+	versym   []byte
 }
 
 type elfArch struct {
@@ -67,7 +85,7 @@ var elfArches = map[elf.Machine]elfArch{
 	elf.EM_386:    {arch.I386, rcElf386},
 }
 
-func openElf(r io.ReaderAt) (bool, File, error) {
+func openElf(r Source) (bool, File, error) {
 	// Is this an ELF file?
 	var magic [4]uint8
 	if _, err := r.ReadAt(magic[0:], 0); err != nil {
@@ -84,15 +102,8 @@ func openElf(r io.ReaderAt) (bool, File, error) {
 		return true, nil, err
 	}
 
-	f := &elfFile{f: ff, elfArch: elfArches[ff.Machine]}
-
-	// Is this a real file we can mmap?
-	if file, ok := r.(*os.File); ok {
-		f.fd = file.Fd()
-		f.pageSize = uint64(syscall.Getpagesize())
-	} else {
-		f.fd = ^uintptr(0)
-	}
+	f := &elfFile{f: ff, elfArch: elfArches[ff.Machine], src: r}
+	f.refs = newRefCount(func() { f.src.Close() })
 
 	// Set per-class constants.
 	var elfWordSize int
@@ -117,6 +128,7 @@ func openElf(r io.ReaderAt) (bool, File, error) {
 	var relSections []*elfSection
 	var relocatableSections []*elfSection
 	f.shnToSection = make([]*elfSection, len(ff.Sections))
+	f.byName = make(map[string]*Section)
 	for elfID, elfSect := range ff.Sections {
 		if elfSect.Type == elf.SHT_NULL {
 			continue
@@ -142,11 +154,24 @@ func openElf(r io.ReaderAt) (bool, File, error) {
 		}
 		if elfSect.Type == elf.SHT_NOBITS {
 			s.SetZeroInitialized(true)
+		} else {
+			s.Offset = elfSect.Offset
+		}
+		if elfSect.Flags&elf.SHF_EXECINSTR != 0 {
+			s.SetExecutable(true)
 		}
+		if elfSect.Flags&elf.SHF_TLS != 0 {
+			s.SetTLS(true)
+		}
+		s.Align = elfSect.Addralign
+		s.Kind = elfSectionKind(elfSect)
 
 		es := &elfSection{Section: s, elf: elfSect}
 		f.sections = append(f.sections, es)
 		f.shnToSection[elfID] = es
+		if _, ok := f.byName[s.Name]; !ok {
+			f.byName[s.Name] = s
+		}
 
 		// Track sections we're interested in.
 		switch elfSect.Type {
@@ -231,9 +256,18 @@ func openElf(r io.ReaderAt) (bool, File, error) {
 		symTab := &f.symTabs[i]
 		es := symTab.section
 		if es == nil {
-			// This file doesn't have this type of symbol table.
 			symTab.start = nSyms
 			symTab.end = symTab.start
+			if i == 1 && len(ff.Sections) == 0 && !f.relocatable {
+				// The section headers are stripped, but we can still
+				// find the dynamic symbol table via the dynamic
+				// segment. This is best-effort: if that fails, we
+				// simply don't have dynamic symbols.
+				if count, err := f.loadDynSymsFromSegments(symTab); err == nil {
+					symTab.end += count
+					nSyms += count
+				}
+			}
 			continue
 		}
 
@@ -270,16 +304,41 @@ func openElf(r io.ReaderAt) (bool, File, error) {
 	return true, f, nil
 }
 
-func (f *elfFile) Close() {
-	// Release mmaps.
-	for _, s := range f.sections {
-		if s.mmapped != nil {
-			mmapped := s.mmapped
-			s.data = nil
-			s.mmapped = nil
-			syscall.Munmap(mmapped)
+// elfSectionKind classifies an ELF section by its type, flags, and,
+// for debug sections, its name.
+func elfSectionKind(es *elf.Section) SectionKind {
+	switch es.Type {
+	case elf.SHT_NOBITS:
+		return SectionBSS
+	case elf.SHT_SYMTAB, elf.SHT_DYNSYM, elf.SHT_SYMTAB_SHNDX, elf.SHT_STRTAB,
+		elf.SHT_HASH, elf.SHT_GNU_HASH, elf.SHT_GNU_VERSYM, elf.SHT_GNU_VERDEF, elf.SHT_GNU_VERNEED:
+		return SectionSymTab
+	case elf.SHT_REL, elf.SHT_RELA:
+		return SectionReloc
+	case elf.SHT_NOTE:
+		return SectionNote
+	}
+	for _, prefix := range []string{".debug", ".zdebug", ".stab"} {
+		if strings.HasPrefix(es.Name, prefix) {
+			return SectionDebug
+		}
+	}
+	if es.Flags&elf.SHF_ALLOC != 0 {
+		switch {
+		case es.Flags&elf.SHF_EXECINSTR != 0:
+			return SectionText
+		case es.Flags&elf.SHF_WRITE != 0:
+			return SectionData
 		}
+		return SectionROData
 	}
+	return SectionOther
+}
+
+func (f *elfFile) Close() {
+	// Drop the File's own reference. The source is closed once any
+	// retained Data are released.
+	f.refs.close()
 }
 
 func (f *elfFile) Info() FileInfo {
@@ -323,7 +382,6 @@ type elfSection struct {
 	dataOnce sync.Once
 	data     []byte
 	dataErr  error
-	mmapped  []byte // if non-nil, original mmap of this section
 
 	relocsOnce sync.Once
 	relocs     []Reloc // Relocations that apply to this section. Sorted by Addr.
@@ -342,7 +400,11 @@ func (s *elfSection) String() string {
 // loadable sections), which tends to lead to infinite loops. We don't
 // want to apply relocations to any ELF metadata sections.
 func (s *elfSection) canHaveRelocs() bool {
-	return s.elf.Type == elf.SHT_PROGBITS || s.elf.Type == elf.SHT_NOBITS || s.elf.Type >= elf.SHT_LOPROC
+	switch s.elf.Type {
+	case elf.SHT_PROGBITS, elf.SHT_NOBITS, elf.SHT_INIT_ARRAY, elf.SHT_FINI_ARRAY, elf.SHT_PREINIT_ARRAY:
+		return true
+	}
+	return s.elf.Type >= elf.SHT_LOPROC
 }
 
 // lookupShn returns the *elfSection for a raw ELF section number and
@@ -378,82 +440,88 @@ func (f *elfFile) sectionData(s *Section, addr, size uint64, d *Data) (*Data, er
 func (f *elfFile) elfSectionData(s *elfSection, addr, size uint64, d *Data) error {
 	es := s.elf
 
-	// Validate requested range.
-	if addr+size < addr {
-		panic("address overflow")
+	// Hold a reference so src isn't closed while we read it.
+	if !f.refs.acquire() {
+		return ErrClosed
 	}
-	if addr < es.Addr || addr+size > es.Addr+es.Size {
-		panic(fmt.Sprintf("requested data [0x%x, 0x%x) is outside section [0x%x, 0x%x)", addr, addr+size, es.Addr, es.Addr+es.Size))
+	defer f.refs.dec()
+
+	// Validate requested range.
+	if addr+size < addr || addr < es.Addr || addr+size > es.Addr+es.Size {
+		return &ErrOutOfRange{addr, size, es.Addr, es.Addr + es.Size}
 	}
 
 	// Read the section and its relocations.
-	bytes, err := f.sectionBytes(s)
-	if err != nil {
-		return s.dataErr
+	var b []byte
+	if es.Type != elf.SHT_NOBITS && es.Flags&elf.SHF_COMPRESSED == 0 {
+		// Read just the requested window. The source is responsible
+		// for making this cheap.
+		var err error
+		b, err = f.src.Slice(int64(es.Offset+(addr-es.Addr)), int(size))
+		if err != nil {
+			return fmt.Errorf("reading section %s: %w", s, err)
+		}
+	} else {
+		bytes, err := f.sectionBytes(s)
+		if err != nil {
+			return err
+		}
+		b = bytes[addr-es.Addr:][:size]
 	}
 	relocs, err := f.sectionRelocs(s)
 	if err != nil {
-		return s.dataErr
+		return err
+	}
+
+	// Slice relocs down to those that overlap the requested range.
+	if len(relocs) > 0 && (relocs[0].Addr < addr || relocs[len(relocs)-1].Addr >= addr+size) {
+		lo := sort.Search(len(relocs), func(i int) bool {
+			return relocs[i].Addr+maxRelocSize > addr
+		})
+		hi := lo + sort.Search(len(relocs)-lo, func(i int) bool {
+			return relocs[lo+i].Addr >= addr+size
+		})
+		relocs = relocs[lo:hi:hi]
 	}
 
 	// Construct data.
-	//
-	// TODO: Slice down relocs?
-	*d = Data{Addr: addr, B: bytes[addr-es.Addr:][:size], R: relocs, Layout: f.arch.Layout}
+	*d = Data{Addr: addr, B: b, R: relocs, Layout: f.arch.Layout, refs: f.refs}
 
 	return nil
 }
 
 func (f *elfFile) sectionBytes(s *elfSection) (data []byte, err error) {
 	s.dataOnce.Do(func() {
-		s.data, s.mmapped, s.dataErr = f.sectionBytesUncached(s)
+		s.data, s.dataErr = f.sectionBytesUncached(s)
 	})
 	return s.data, s.dataErr
 }
 
 var testMmapSection func(bool)
 
-func (f *elfFile) sectionBytesUncached(s *elfSection) (data []byte, mmaped []byte, err error) {
+func (f *elfFile) sectionBytesUncached(s *elfSection) (data []byte, err error) {
 	es := s.elf
 
-	// TODO: Make this cross-platform.
 	if es.Type == elf.SHT_NOBITS {
-		// There's no data to mmap. Create an anonymous zeroed mmap to
-		// avoid bloating the Go heap.
-		size := roundUp2(es.Size, f.pageSize)
-		if size > 0 {
-			data, err = syscall.Mmap(-1, 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED|syscall.MAP_ANON)
-			if err == nil {
-				if testMmapSection != nil {
-					testMmapSection(true)
-				}
-				return data[:es.Size], data, nil
-			}
-		}
-		// Just allocate on the heap.
-		if testMmapSection != nil {
-			testMmapSection(false)
+		// There's no data to read.
+		if es.Size != uint64(int(es.Size)) {
+			return nil, fmt.Errorf("section %s is too large", s)
 		}
-		return make([]byte, s.elf.Size), nil, nil
+		return zeroBytes(int(es.Size)), nil
 	}
 
-	// Memory map the section when possible.
-	if f.fd != ^uintptr(0) && es.Flags&elf.SHF_COMPRESSED == 0 && es.Size > 0 {
-		start := roundDown2(es.Offset, f.pageSize)
-		end := roundUp2(es.Offset+es.Size, f.pageSize)
-		data, err = syscall.Mmap(int(f.fd), int64(start), int(end-start), syscall.PROT_READ, syscall.MAP_SHARED|syscall.MAP_FILE)
-		if err == nil {
-			if testMmapSection != nil {
-				testMmapSection(true)
-			}
-			return data[es.Offset-start:][:es.Size], data, nil
+	if es.Flags&elf.SHF_COMPRESSED == 0 {
+		data, err = f.src.Slice(int64(es.Offset), int(es.Size))
+		if err != nil {
+			return nil, fmt.Errorf("reading section %s: %w", s, err)
 		}
+		return data, nil
 	}
 
-	// Mmaping failed or wasn't possible. Read into the heap.
+	// Decompress the section into the heap.
 	data, err = ioutil.ReadAll(es.Open())
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if uint64(len(data)) != es.Size {
 		panic(fmt.Sprintf("reading section got %d bytes, want %d", len(data), es.Size))
@@ -461,26 +529,44 @@ func (f *elfFile) sectionBytesUncached(s *elfSection) (data []byte, mmaped []byt
 	if testMmapSection != nil {
 		testMmapSection(false)
 	}
-	return data, nil, nil
+	return data, nil
 }
 
-func (f *elfFile) ResolveAddr(addr uint64) *Section {
-	if f.relocatable {
-		// Relocatable object files don't have any meaningful load
-		// addresses (even though sections can be marked allocatable).
-		return nil
-	}
+func (f *elfFile) SectionByName(name string) *Section {
+	return f.byName[name]
+}
 
+func (f *elfFile) SectionsByKind(kind SectionKind) []*Section {
+	var out []*Section
 	for _, es := range f.sections {
-		// Only consider sections that will be loaded into the address space.
-		if es.elf.Flags&elf.SHF_ALLOC == 0 {
-			continue
-		}
-
-		if es.Addr <= addr && addr-es.Addr < es.Size {
-			return es.Section
+		if es.Kind == kind {
+			out = append(out, es.Section)
 		}
 	}
+	return out
+}
 
-	return nil
+func (f *elfFile) ResolveAddr(addr uint64) *Section {
+	f.addrIndexOnce.Do(func() {
+		var mapped []*Section
+		for _, es := range f.sections {
+			// Only consider sections that will be loaded into the
+			// address space. Relocatable object files don't have any
+			// meaningful load addresses (even though sections can be
+			// marked allocatable), so none of their sections are
+			// mapped.
+			if !es.Mapped() {
+				continue
+			}
+			// TLS zero-initialized sections (.tbss) have addresses,
+			// but don't occupy them, and generally overlap the
+			// following sections.
+			if es.TLS() && es.ZeroInitialized() {
+				continue
+			}
+			mapped = append(mapped, es.Section)
+		}
+		f.addrIndex = NewAddrIndex(mapped)
+	})
+	return f.addrIndex.Resolve(addr)
 }
@@ -0,0 +1,223 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/aclements/go-obj/arch"
+)
+
+const (
+	// elfNotePrStatus is the type of the NT_PRSTATUS core file note,
+	// which records the state of one thread.
+	elfNotePrStatus = 1
+
+	// elfNoteFile is the type of the NT_FILE core file note, which
+	// lists the files mapped into the process.
+	elfNoteFile = 0x46494c45 // "FILE"
+)
+
+// elfNote is a raw ELF note.
+type elfNote struct {
+	name string
+	typ  uint32
+	desc []byte
+}
+
+// notes returns the notes in f's PT_NOTE segments.
+func (f *elfFile) notes() ([]elfNote, error) {
+	var out []elfNote
+	l := f.elfLayout
+	for _, prog := range f.f.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		b, err := ioutil.ReadAll(prog.Open())
+		if err != nil {
+			return nil, fmt.Errorf("reading note segment: %w", err)
+		}
+		// [TIS ELF 1.2 Book I, p. 2-4] Notes consist of 4-byte words,
+		// even in 64-bit files, and the name and descriptor are padded
+		// to 4-byte alignment.
+		for len(b) > 0 {
+			if len(b) < 12 {
+				return nil, fmt.Errorf("truncated note header")
+			}
+			namesz, descsz, typ := l.Uint32(b), l.Uint32(b[4:]), l.Uint32(b[8:])
+			b = b[12:]
+			nameEnd := roundUp2(uint64(namesz), 4)
+			descEnd := nameEnd + roundUp2(uint64(descsz), 4)
+			if descEnd > uint64(len(b)) {
+				return nil, fmt.Errorf("truncated note")
+			}
+			name := b[:namesz]
+			if i := bytes.IndexByte(name, 0); i >= 0 {
+				name = name[:i]
+			}
+			out = append(out, elfNote{string(name), typ, b[nameEnd:][:descsz]})
+			b = b[descEnd:]
+		}
+	}
+	return out, nil
+}
+
+func (f *elfFile) coreMappings() ([]Mapping, error) {
+	if f.f.Type != elf.ET_CORE {
+		return nil, fmt.Errorf("not a core file")
+	}
+	notes, err := f.notes()
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.name != "CORE" || note.typ != elfNoteFile {
+			continue
+		}
+		// The NT_FILE descriptor consists of a count and page size,
+		// followed by count (start, end, page offset) triples, followed
+		// by count NUL-terminated file names. Everything but the names
+		// is the ELF word size.
+		r := NewCheckedReader(&Data{B: note.desc, Layout: f.elfLayout})
+		count, pageSize := r.Word(), r.Word()
+		if r.Err() != nil || count > uint64(r.Avail()) {
+			return nil, fmt.Errorf("malformed NT_FILE note")
+		}
+		out := make([]Mapping, count)
+		for i := range out {
+			out[i].Start, out[i].End, out[i].Offset = r.Word(), r.Word(), r.Word()*pageSize
+		}
+		for i := range out {
+			out[i].Path = string(r.CString())
+		}
+		if r.Err() != nil {
+			return nil, fmt.Errorf("malformed NT_FILE note: %w", r.Err())
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("core file has no NT_FILE note")
+}
+
+// elfPrStatus describes the layout of struct elf_prstatus on an
+// architecture.
+type elfPrStatus struct {
+	pidOff, regOff int
+	// regs names the registers in elf_gregset_t, in order. Empty names
+	// are skipped.
+	regs []string
+}
+
+var elfPrStatuses = map[*arch.Arch]elfPrStatus{
+	// See struct user_regs_struct in Linux's
+	// arch/x86/include/asm/user_64.h.
+	arch.AMD64: {32, 112, []string{
+		"r15", "r14", "r13", "r12", "rbp", "rbx", "r11", "r10",
+		"r9", "r8", "rax", "rcx", "rdx", "rsi", "rdi", "",
+		"rip", "cs", "rflags", "rsp", "ss", "fs.base", "gs.base", "ds",
+		"es", "fs", "gs",
+	}},
+	// See struct user_regs_struct in Linux's
+	// arch/x86/include/asm/user_32.h.
+	arch.I386: {24, 72, []string{
+		"ebx", "ecx", "edx", "esi", "edi", "ebp", "eax", "ds",
+		"es", "fs", "gs", "", "eip", "cs", "eflags", "esp",
+		"ss",
+	}},
+}
+
+func (f *elfFile) coreThreads() ([]Thread, error) {
+	if f.f.Type != elf.ET_CORE {
+		return nil, fmt.Errorf("not a core file")
+	}
+	layout, ok := elfPrStatuses[f.arch]
+	if !ok {
+		return nil, fmt.Errorf("core file threads are not supported for architecture %s", f.arch)
+	}
+	notes, err := f.notes()
+	if err != nil {
+		return nil, err
+	}
+	var out []Thread
+	wordSize := f.elfLayout.WordSize()
+	for _, note := range notes {
+		if note.name != "CORE" || note.typ != elfNotePrStatus {
+			continue
+		}
+		if len(note.desc) < layout.regOff+len(layout.regs)*wordSize {
+			return nil, fmt.Errorf("malformed NT_PRSTATUS note")
+		}
+		t := Thread{ID: int(f.elfLayout.Uint32(note.desc[layout.pidOff:])), Regs: make(map[arch.Reg]uint64)}
+		for i, name := range layout.regs {
+			if name == "" {
+				continue
+			}
+			reg, ok := f.arch.Regs.ByName(name)
+			if !ok {
+				panic("unknown register " + name)
+			}
+			t.Regs[reg] = f.elfLayout.Word(note.desc[layout.regOff+i*wordSize:])
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// coreMemory reads the memory recorded in the PT_LOAD segments of a
+// core file.
+type coreMemory struct {
+	src  Source
+	segs []coreSegment // Sorted by addr
+}
+
+type coreSegment struct {
+	addr, size, off uint64
+}
+
+func (f *elfFile) coreMemory() (*coreMemory, error) {
+	if f.f.Type != elf.ET_CORE {
+		return nil, fmt.Errorf("not a core file")
+	}
+	m := &coreMemory{src: f.src}
+	for _, prog := range f.f.Progs {
+		// Segments with no file data weren't dumped.
+		if prog.Type == elf.PT_LOAD && prog.Filesz > 0 {
+			m.segs = append(m.segs, coreSegment{prog.Vaddr, prog.Filesz, prog.Off})
+		}
+	}
+	sort.Slice(m.segs, func(i, j int) bool {
+		return m.segs[i].addr < m.segs[j].addr
+	})
+	return m, nil
+}
+
+func (m *coreMemory) ReadAt(p []byte, off int64) (int, error) {
+	addr := uint64(off)
+	n := 0
+	for n < len(p) {
+		i := sort.Search(len(m.segs), func(i int) bool {
+			return addr < m.segs[i].addr+m.segs[i].size
+		})
+		if i == len(m.segs) || addr < m.segs[i].addr {
+			return n, &ErrNoData{fmt.Sprintf("address %#x is not in the core file", addr)}
+		}
+		seg := m.segs[i]
+		segOff := addr - seg.addr
+		chunk := len(p) - n
+		if avail := seg.size - segOff; uint64(chunk) > avail {
+			chunk = int(avail)
+		}
+		k, err := m.src.ReadAt(p[n:n+chunk], int64(seg.off+segOff))
+		n += k
+		if err != nil {
+			return n, err
+		}
+		addr += uint64(k)
+	}
+	return n, nil
+}
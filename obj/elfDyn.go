@@ -0,0 +1,207 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"debug/elf"
+	"fmt"
+	"strings"
+)
+
+func (f *elfFile) readDynamic() (*Dynamic, error) {
+	d := &Dynamic{Versions: make(map[SymID]SymVersion)}
+	dynStrings := func(tag elf.DynTag) ([]string, error) {
+		vals, err := f.f.DynString(tag)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", tag, err)
+		}
+		return vals, nil
+	}
+	var err error
+	if d.Needed, err = dynStrings(elf.DT_NEEDED); err != nil {
+		return nil, err
+	}
+	soname, err := dynStrings(elf.DT_SONAME)
+	if err != nil {
+		return nil, err
+	}
+	if len(soname) > 0 {
+		d.SOName = soname[0]
+	}
+	// Search paths are colon-separated lists.
+	for _, p := range []struct {
+		tag elf.DynTag
+		out *[]string
+	}{{elf.DT_RPATH, &d.RPath}, {elf.DT_RUNPATH, &d.RunPath}} {
+		vals, err := dynStrings(p.tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range vals {
+			*p.out = append(*p.out, strings.Split(val, ":")...)
+		}
+	}
+
+	if err := f.readSymVersions(d.Versions); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// readSymVersions populates versions from the GNU symbol versioning
+// sections.
+func (f *elfFile) readSymVersions(versions map[SymID]SymVersion) error {
+	var versym, verdef, verneed *elfSection
+	for _, es := range f.sections {
+		switch es.elf.Type {
+		case elf.SHT_GNU_VERSYM:
+			versym = es
+		case elf.SHT_GNU_VERDEF:
+			verdef = es
+		case elf.SHT_GNU_VERNEED:
+			verneed = es
+		}
+	}
+	if versym == nil {
+		return nil
+	}
+
+	// Collect version names by version index. Defined versions and
+	// needed versions share an index space.
+	type version struct {
+		name, library string
+	}
+	byIndex := make(map[uint16]version)
+	// [LSB 5.0, Symbol Versioning] Version definitions are a linked
+	// list of Elfxx_Verdef, each followed by a linked list of
+	// Elfxx_Verdaux giving the version name and its parents. The first
+	// Verdaux is the version itself.
+	if verdef != nil {
+		r, strs, err := f.versionReaders(verdef)
+		if err != nil {
+			return err
+		}
+		for off := 0; ; {
+			r.SetOffset(off)
+			_ = r.Uint16() // vd_version
+			flags := r.Uint16()
+			ndx := r.Uint16()
+			_ = r.Uint16() // vd_cnt
+			_ = r.Uint32() // vd_hash
+			aux := r.Uint32()
+			next := r.Uint32()
+			r.SetOffset(off + int(aux))
+			name := strs.str(r.Uint32())
+			if flags&elfVerFlagBase == 0 {
+				// The base version is the file itself, not a version.
+				byIndex[ndx] = version{name: name}
+			}
+			if err := firstErr(r.Err(), strs.r.Err()); err != nil {
+				return fmt.Errorf("reading %s: %w", verdef, err)
+			}
+			if next == 0 {
+				break
+			}
+			off += int(next)
+		}
+	}
+	// Version requirements are a linked list of Elfxx_Verneed, one per
+	// needed file, each followed by a linked list of Elfxx_Vernaux, one
+	// per version needed from that file.
+	if verneed != nil {
+		r, strs, err := f.versionReaders(verneed)
+		if err != nil {
+			return err
+		}
+		for off := 0; ; {
+			r.SetOffset(off)
+			_ = r.Uint16() // vn_version
+			cnt := r.Uint16()
+			library := strs.str(r.Uint32())
+			aux := r.Uint32()
+			next := r.Uint32()
+			for i, auxOff := 0, off+int(aux); i < int(cnt); i++ {
+				r.SetOffset(auxOff)
+				_ = r.Uint32() // vna_hash
+				_ = r.Uint16() // vna_flags
+				other := r.Uint16()
+				name := strs.str(r.Uint32())
+				auxNext := r.Uint32()
+				byIndex[other] = version{name, library}
+				auxOff += int(auxNext)
+			}
+			if err := firstErr(r.Err(), strs.r.Err()); err != nil {
+				return fmt.Errorf("reading %s: %w", verneed, err)
+			}
+			if next == 0 {
+				break
+			}
+			off += int(next)
+		}
+	}
+
+	// Versym has one entry per dynamic symbol.
+	tab := &f.symTabs[1]
+	data, err := versym.Data(versym.Bounds())
+	if err != nil {
+		return err
+	}
+	data.Layout = f.elfLayout
+	r := NewCheckedReader(data)
+	r.Skip(2) // Symbol 0
+	for id := tab.start; id < tab.end && r.Avail() >= 2; id++ {
+		v := r.Uint16()
+		ver, ok := byIndex[v&^elfVersymHidden]
+		if !ok {
+			// Index 0 is local and 1 is the base (global) version.
+			continue
+		}
+		versions[id] = SymVersion{Name: ver.name, Hidden: v&elfVersymHidden != 0, Library: ver.library}
+	}
+	return nil
+}
+
+const (
+	elfVerFlagBase  = 0x1    // VER_FLG_BASE
+	elfVersymHidden = 0x8000 // VERSYM_HIDDEN
+)
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elfStrReader reads strings from an ELF string table.
+type elfStrReader struct {
+	r *Reader
+}
+
+func (s elfStrReader) str(off uint32) string {
+	s.r.SetOffset(int(off))
+	return string(s.r.CString())
+}
+
+// versionReaders returns checked readers for version section es and its
+// linked string table.
+func (f *elfFile) versionReaders(es *elfSection) (*Reader, elfStrReader, error) {
+	data, err := es.Data(es.Bounds())
+	if err != nil {
+		return nil, elfStrReader{}, err
+	}
+	strSection, ok := f.lookupShn(elf.SectionIndex(es.elf.Link))
+	if !ok {
+		return nil, elfStrReader{}, fmt.Errorf("section %s has bad string table link %d", es, es.elf.Link)
+	}
+	strData, err := strSection.Data(strSection.Bounds())
+	if err != nil {
+		return nil, elfStrReader{}, err
+	}
+	data.Layout, strData.Layout = f.elfLayout, f.elfLayout
+	return NewCheckedReader(data), elfStrReader{NewCheckedReader(strData)}, nil
+}
@@ -0,0 +1,774 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// elfShdr is a raw ELF section header.
+//
+// We read these directly from the file rather than using
+// debug/elf.SectionHeader because debug/elf reports the decompressed
+// size and alignment of compressed sections, and we need to write back
+// the raw values.
+type elfShdr struct {
+	name    uint32
+	typ     elf.SectionType
+	flags   elf.SectionFlag
+	addr    uint64
+	off     uint64
+	size    uint64
+	link    uint32
+	info    uint32
+	align   uint64
+	entsize uint64
+}
+
+// elfOutSection is a section in an edited ELF file.
+type elfOutSection struct {
+	name string
+	hdr  elfShdr
+
+	// old is the raw ELF section number of this section in the
+	// original file, or -1 if this is a new section.
+	old int
+
+	// fixed indicates this section is part of the loaded image and
+	// must keep its file offset and size.
+	fixed bool
+
+	// data is the new contents of this section. If nil (and this
+	// section isn't NOBITS), the raw contents are copied from the
+	// original file.
+	data []byte
+}
+
+// elfStrtab builds an ELF string table.
+type elfStrtab struct {
+	buf []byte
+	m   map[string]uint32
+}
+
+func newElfStrtab(base []byte) *elfStrtab {
+	t := &elfStrtab{m: make(map[string]uint32)}
+	if len(base) == 0 {
+		base = []byte{0}
+	}
+	t.buf = append(t.buf, base...)
+	return t
+}
+
+func (t *elfStrtab) add(s string) uint32 {
+	if s == "" {
+		return 0
+	}
+	if off, ok := t.m[s]; ok {
+		return off
+	}
+	off := uint32(len(t.buf))
+	t.buf = append(append(t.buf, s...), 0)
+	t.m[s] = off
+	return off
+}
+
+// elfRawSym is a raw ELF symbol table entry.
+type elfRawSym struct {
+	name  uint32
+	info  uint8
+	other uint8
+	shndx elf.SectionIndex
+	value uint64
+	size  uint64
+}
+
+func (f *elfFile) writeEdit(e *Edit, w io.Writer) error {
+	ff := f.f
+	is64 := ff.Class == elf.ELFCLASS64
+	order := ff.ByteOrder
+
+	// Read the ELF header and the raw section headers.
+	ehdrSize, shentSize := 52, 40
+	if is64 {
+		ehdrSize, shentSize = 64, 64
+	}
+	ehdr := make([]byte, ehdrSize)
+	if _, err := f.src.ReadAt(ehdr, 0); err != nil {
+		return fmt.Errorf("reading ELF header: %w", err)
+	}
+	var phoff, shoff uint64
+	var phentSize, phnum, shstrndx uint16
+	if is64 {
+		phoff, shoff = order.Uint64(ehdr[32:]), order.Uint64(ehdr[40:])
+		phentSize, phnum, shstrndx = order.Uint16(ehdr[54:]), order.Uint16(ehdr[56:]), order.Uint16(ehdr[62:])
+	} else {
+		phoff, shoff = uint64(order.Uint32(ehdr[28:])), uint64(order.Uint32(ehdr[32:]))
+		phentSize, phnum, shstrndx = order.Uint16(ehdr[42:]), order.Uint16(ehdr[44:]), order.Uint16(ehdr[50:])
+	}
+	if shstrndx == uint16(elf.SHN_XINDEX) || len(ff.Sections) >= int(elf.SHN_LORESERVE) {
+		return fmt.Errorf("extended section numbering is not supported")
+	}
+	if len(ff.Sections) == 0 {
+		return fmt.Errorf("file has no section headers")
+	}
+	shdrs := make([]elfShdr, len(ff.Sections))
+	shdrData := make([]byte, len(shdrs)*shentSize)
+	if _, err := f.src.ReadAt(shdrData, int64(shoff)); err != nil {
+		return fmt.Errorf("reading section headers: %w", err)
+	}
+	for i := range shdrs {
+		shdrs[i] = decodeElfShdr(shdrData[i*shentSize:], is64, order)
+	}
+
+	// Determine which sections to keep. Relocation sections go with
+	// the section they apply to.
+	keep := make([]bool, len(shdrs))
+	for i := range keep {
+		es := f.shnToSection[i]
+		keep[i] = es == nil || !e.removed[es.ID]
+	}
+	for i, sh := range shdrs {
+		if (sh.typ == elf.SHT_REL || sh.typ == elf.SHT_RELA) && sh.info != 0 && int(sh.info) < len(keep) && !keep[sh.info] {
+			keep[i] = false
+		}
+	}
+	for _, p := range e.patches {
+		if !keep[f.sections[p.s.ID].RawID] {
+			return fmt.Errorf("patch to removed section %s", p.s)
+		}
+	}
+
+	// Construct the output section table and the section number map.
+	hasPhdrs := phnum > 0
+	var out []*elfOutSection
+	newShn := make([]elf.SectionIndex, len(shdrs))
+	for i, sh := range shdrs {
+		if !keep[i] {
+			newShn[i] = elf.SHN_UNDEF
+			continue
+		}
+		newShn[i] = elf.SectionIndex(len(out))
+		osec := &elfOutSection{name: ff.Sections[i].Name, hdr: sh, old: i}
+		osec.fixed = hasPhdrs && sh.flags&elf.SHF_ALLOC != 0
+		out = append(out, osec)
+	}
+	mapShn := func(shn uint32, what string, osec *elfOutSection) (uint32, error) {
+		if shn == 0 {
+			return 0, nil
+		}
+		if int(shn) >= len(shdrs) {
+			return 0, fmt.Errorf("section %s: bad %s section %d", osec.name, what, shn)
+		}
+		if !keep[shn] {
+			return 0, fmt.Errorf("section %s: %s section %s was removed", osec.name, what, ff.Sections[shn].Name)
+		}
+		return uint32(newShn[shn]), nil
+	}
+	for _, osec := range out[1:] {
+		var err error
+		if osec.hdr.link, err = mapShn(osec.hdr.link, "linked", osec); err != nil {
+			return err
+		}
+		if osec.hdr.typ == elf.SHT_REL || osec.hdr.typ == elf.SHT_RELA || osec.hdr.flags&elf.SHF_INFO_LINK != 0 {
+			if osec.hdr.info, err = mapShn(osec.hdr.info, "target", osec); err != nil {
+				return err
+			}
+		}
+	}
+	outByOld := func(shn int) *elfOutSection {
+		if shn <= 0 || !keep[shn] {
+			return nil
+		}
+		return out[newShn[shn]]
+	}
+
+	// Apply section replacements and patches.
+	for id, data := range e.replaced {
+		osec := outByOld(f.sections[id].RawID)
+		if osec == nil {
+			continue
+		}
+		if osec.hdr.typ == elf.SHT_NOBITS {
+			return fmt.Errorf("cannot replace NOBITS section %s", osec.name)
+		}
+		if osec.fixed && uint64(len(data)) != osec.hdr.size {
+			return fmt.Errorf("cannot change size of loaded section %s from %d to %d bytes", osec.name, osec.hdr.size, len(data))
+		}
+		osec.data = data
+		f.setDecompressed(osec)
+	}
+	patched := make(map[*elfOutSection]bool)
+	for _, p := range e.patches {
+		es := f.sections[p.s.ID]
+		osec := outByOld(es.RawID)
+		if osec.hdr.typ == elf.SHT_NOBITS {
+			return fmt.Errorf("cannot patch NOBITS section %s", osec.name)
+		}
+		if !patched[osec] {
+			// Patch a copy of the replacement or the (decompressed)
+			// section contents.
+			b := osec.data
+			if b == nil {
+				var err error
+				b, err = f.sectionBytes(es)
+				if err != nil {
+					return fmt.Errorf("reading section %s: %w", osec.name, err)
+				}
+			}
+			osec.data = append([]byte(nil), b...)
+			f.setDecompressed(osec)
+			patched[osec] = true
+		}
+		off := p.addr - es.Addr
+		if off+uint64(len(p.b)) > uint64(len(osec.data)) {
+			return fmt.Errorf("patch [0x%x, 0x%x) is outside replaced data of section %s", p.addr, p.addr+uint64(len(p.b)), osec.name)
+		}
+		copy(osec.data[off:], p.b)
+	}
+
+	// Rewrite the symbol tables.
+	var shstrtab *elfStrtab
+	shstrOut := outByOld(int(shstrndx))
+	if shstrOut == nil {
+		return fmt.Errorf("section name table was removed")
+	}
+	symTab := f.symTabs[0].section
+	symRemap, err := f.editSyms(e, &out, outByOld, newShn, keep, &shstrtab, shstrOut)
+	if err != nil {
+		return err
+	}
+	if dynTab := f.symTabs[1].section; dynTab != nil && keep[dynTab.RawID] {
+		if err := f.editDynSyms(outByOld(dynTab.RawID), newShn, keep); err != nil {
+			return err
+		}
+	}
+
+	// Rewrite relocation and group sections that refer to symbols in
+	// the static symbol table.
+	if symRemap != nil && symTab != nil {
+		for _, osec := range out[1:] {
+			if osec.old < 0 || int(osec.hdr.link) != int(newShn[symTab.RawID]) {
+				continue
+			}
+			switch osec.hdr.typ {
+			case elf.SHT_REL, elf.SHT_RELA:
+				if err := f.editRelocs(osec, symRemap); err != nil {
+					return err
+				}
+			case elf.SHT_GROUP:
+				if osec.hdr.info >= uint32(len(symRemap)) || symRemap[osec.hdr.info] < 0 {
+					return fmt.Errorf("group section %s: signature symbol was removed", osec.name)
+				}
+				osec.hdr.info = uint32(symRemap[osec.hdr.info])
+			case elf.SHT_SYMTAB_SHNDX:
+				return fmt.Errorf("editing symbol tables with extended section indexes is not supported")
+			}
+		}
+	}
+	for _, osec := range out[1:] {
+		if osec.hdr.typ == elf.SHT_GROUP {
+			if err := f.editGroup(osec, newShn, keep); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Add new sections.
+	for _, as := range e.added {
+		typ := elf.SHT_PROGBITS
+		align := uint64(1)
+		if strings.HasPrefix(as.name, ".note") {
+			typ, align = elf.SHT_NOTE, 4
+		}
+		osec := &elfOutSection{name: as.name, old: -1, data: as.data}
+		osec.hdr = elfShdr{typ: typ, size: uint64(len(as.data)), align: align}
+		out = append(out, osec)
+	}
+
+	// Rebuild the section name table, unless it's shared with the
+	// symbol names, in which case we extend it.
+	if shstrtab == nil {
+		shstrtab = newElfStrtab(nil)
+	}
+	for _, osec := range out[1:] {
+		osec.hdr.name = shstrtab.add(osec.name)
+	}
+	shstrOut.data = shstrtab.buf
+	shstrOut.hdr.size = uint64(len(shstrtab.buf))
+	if shstrOut.fixed {
+		return fmt.Errorf("loaded section name tables are not supported")
+	}
+
+	// Lay out the file. The loaded image (the ELF header, the program
+	// headers, all segments, and all loaded sections) stays where it
+	// is. Everything else follows it.
+	end := uint64(ehdrSize)
+	if hasPhdrs {
+		end = maxUint64(end, phoff+uint64(phnum)*uint64(phentSize))
+		for _, p := range ff.Progs {
+			end = maxUint64(end, p.Off+p.Filesz)
+		}
+	}
+	for _, osec := range out[1:] {
+		if osec.fixed && osec.hdr.typ != elf.SHT_NOBITS {
+			end = maxUint64(end, osec.hdr.off+osec.hdr.size)
+		}
+	}
+	imageEnd := end
+	for _, osec := range out[1:] {
+		if osec.fixed {
+			continue
+		}
+		if osec.data != nil {
+			osec.hdr.size = uint64(len(osec.data))
+		}
+		if osec.hdr.align > 1 {
+			end = roundUp2(end, osec.hdr.align)
+		}
+		osec.hdr.off = end
+		if osec.hdr.typ != elf.SHT_NOBITS {
+			end += osec.hdr.size
+		}
+	}
+	wordSize := uint64(f.elfLayout.WordSize())
+	newShoff := roundUp2(end, wordSize)
+
+	// Construct the loaded image and patch it.
+	image := make([]byte, imageEnd)
+	if _, err := f.src.ReadAt(image, 0); err != nil {
+		return fmt.Errorf("reading loaded image: %w", err)
+	}
+	for _, osec := range out[1:] {
+		if osec.fixed && osec.data != nil {
+			copy(image[osec.hdr.off:], osec.data)
+		}
+	}
+	if is64 {
+		order.PutUint64(image[40:], newShoff)
+		order.PutUint16(image[58:], uint16(shentSize))
+		order.PutUint16(image[60:], uint16(len(out)))
+		order.PutUint16(image[62:], uint16(newShn[shstrndx]))
+	} else {
+		order.PutUint32(image[32:], uint32(newShoff))
+		order.PutUint16(image[46:], uint16(shentSize))
+		order.PutUint16(image[48:], uint16(len(out)))
+		order.PutUint16(image[50:], uint16(newShn[shstrndx]))
+	}
+
+	// Write everything out.
+	ew := &elfEditWriter{w: w}
+	ew.write(image)
+	for _, osec := range out[1:] {
+		if osec.fixed || osec.hdr.typ == elf.SHT_NOBITS {
+			continue
+		}
+		ew.pad(osec.hdr.off)
+		if osec.data != nil {
+			ew.write(osec.data)
+		} else {
+			ew.copy(io.NewSectionReader(f.src, int64(shdrs[osec.old].off), int64(osec.hdr.size)))
+		}
+	}
+	ew.pad(newShoff)
+	buf := make([]byte, shentSize)
+	for _, osec := range out {
+		encodeElfShdr(buf, &osec.hdr, is64, order)
+		ew.write(buf)
+	}
+	return ew.err
+}
+
+// setDecompressed marks that osec's data is no longer compressed.
+func (f *elfFile) setDecompressed(osec *elfOutSection) {
+	if osec.hdr.flags&elf.SHF_COMPRESSED == 0 {
+		return
+	}
+	osec.hdr.flags &^= elf.SHF_COMPRESSED
+	// debug/elf reports the alignment of the decompressed data.
+	osec.hdr.align = f.f.Sections[osec.old].Addralign
+}
+
+// editSyms rewrites the static symbol table, if necessary, to drop
+// symbols in removed sections, renumber section references, and add
+// new symbols. It returns the mapping from old to new symbol indexes
+// (where -1 indicates a removed symbol), or nil if the symbol indexes
+// didn't change.
+//
+// If the symbol string table is shared with the section name table,
+// it returns the string table builder in *shstrtab.
+func (f *elfFile) editSyms(e *Edit, out *[]*elfOutSection, outByOld func(int) *elfOutSection, newShn []elf.SectionIndex, keep []bool, shstrtab **elfStrtab, shstrOut *elfOutSection) ([]int, error) {
+	tab := &f.symTabs[0]
+	var syms []elfRawSym
+	var nLocal int
+	var symOut, strOut *elfOutSection
+	var strtab *elfStrtab
+	if tab.section != nil {
+		if !keep[tab.section.RawID] {
+			// The whole symbol table was removed.
+			if len(e.syms) > 0 {
+				return nil, fmt.Errorf("cannot add symbols to removed symbol table")
+			}
+			return nil, nil
+		}
+		symOut = outByOld(tab.section.RawID)
+		strOut = outByOld(int(tab.section.elf.Link))
+		syms = f.readElfSyms(&tab.data)
+		nLocal = int(tab.section.elf.Info)
+
+		// Do we need to rewrite the symbol table?
+		changed := len(e.syms) > 0
+		for _, sym := range syms {
+			if shn := sym.shndx; shn < elf.SHN_LORESERVE && int(shn) < len(keep) && newShn[shn] != shn {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil, nil
+		}
+		if symOut.fixed {
+			return nil, fmt.Errorf("cannot rewrite loaded symbol table %s", symOut.name)
+		}
+		strData, err := f.sectionBytes(f.shnToSection[strOut.old])
+		if err != nil {
+			return nil, fmt.Errorf("reading string table %s: %w", strOut.name, err)
+		}
+		strtab = newElfStrtab(strData)
+		if strOut == shstrOut {
+			*shstrtab = strtab
+		}
+	} else if len(e.syms) > 0 {
+		// Create a new symbol table.
+		nLocal = 1
+		syms = make([]elfRawSym, 1)
+		wordSize := uint64(f.elfLayout.WordSize())
+		strOut = &elfOutSection{name: ".strtab", old: -1, hdr: elfShdr{typ: elf.SHT_STRTAB, align: 1}}
+		symOut = &elfOutSection{name: ".symtab", old: -1, hdr: elfShdr{typ: elf.SHT_SYMTAB, align: wordSize, entsize: f.symSize}}
+		*out = append(*out, symOut, strOut)
+		symOut.hdr.link = uint32(len(*out) - 1)
+		strtab = newElfStrtab(nil)
+	} else {
+		return nil, nil
+	}
+
+	// Convert the added symbols.
+	var newLocal, newGlobal []elfRawSym
+	for _, sym := range e.syms {
+		raw, err := f.elfSymFromSym(sym, newShn, keep)
+		if err != nil {
+			return nil, err
+		}
+		raw.name = strtab.add(sym.Name)
+		if sym.Local() {
+			newLocal = append(newLocal, raw)
+		} else {
+			newGlobal = append(newGlobal, raw)
+		}
+	}
+
+	// Build the new table, keeping all local symbols before global
+	// symbols.
+	remap := make([]int, len(syms))
+	newSyms := make([]elfRawSym, 0, len(syms)+len(e.syms))
+	copySyms := func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			sym := syms[i]
+			if shn := sym.shndx; shn < elf.SHN_LORESERVE && int(shn) < len(keep) {
+				if !keep[shn] {
+					// This symbol's section was removed.
+					remap[i] = -1
+					continue
+				}
+				sym.shndx = newShn[shn]
+			}
+			remap[i] = len(newSyms)
+			newSyms = append(newSyms, sym)
+		}
+	}
+	if nLocal > len(syms) {
+		nLocal = len(syms)
+	}
+	copySyms(0, nLocal)
+	newSyms = append(newSyms, newLocal...)
+	nNewLocal := len(newSyms)
+	copySyms(nLocal, len(syms))
+	newSyms = append(newSyms, newGlobal...)
+
+	symOut.data = f.encodeElfSyms(newSyms)
+	symOut.hdr.info = uint32(nNewLocal)
+	strOut.data = strtab.buf
+	return remap, nil
+}
+
+// editDynSyms renumbers section references in the dynamic symbol table
+// in place.
+func (f *elfFile) editDynSyms(osec *elfOutSection, newShn []elf.SectionIndex, keep []bool) error {
+	tab := &f.symTabs[1]
+	syms := f.readElfSyms(&tab.data)
+	changed := false
+	for i, sym := range syms {
+		shn := sym.shndx
+		if shn == elf.SHN_UNDEF || shn >= elf.SHN_LORESERVE || int(shn) >= len(keep) {
+			continue
+		}
+		if !keep[shn] {
+			return fmt.Errorf("dynamic symbol %d refers to removed section %s", i+1, f.f.Sections[shn].Name)
+		}
+		if newShn[shn] != shn {
+			syms[i].shndx = newShn[shn]
+			changed = true
+		}
+	}
+	if changed {
+		osec.data = f.encodeElfSyms(syms)
+	}
+	return nil
+}
+
+// readElfSyms decodes all raw symbols in symbol table data d, including
+// the initial null symbol.
+func (f *elfFile) readElfSyms(d *Data) []elfRawSym {
+	r := NewReader(d)
+	syms := make([]elfRawSym, 0, uint64(len(d.B))/f.symSize)
+	for uint64(r.Avail()) >= f.symSize {
+		var s elfRawSym
+		if f.f.Class == elf.ELFCLASS32 {
+			s.name = r.Uint32()
+			s.value = uint64(r.Uint32())
+			s.size = uint64(r.Uint32())
+			s.info = r.Uint8()
+			s.other = r.Uint8()
+			s.shndx = elf.SectionIndex(r.Uint16())
+		} else {
+			s.name = r.Uint32()
+			s.info = r.Uint8()
+			s.other = r.Uint8()
+			s.shndx = elf.SectionIndex(r.Uint16())
+			s.value = r.Uint64()
+			s.size = r.Uint64()
+		}
+		syms = append(syms, s)
+	}
+	return syms
+}
+
+func (f *elfFile) encodeElfSyms(syms []elfRawSym) []byte {
+	order := f.f.ByteOrder
+	out := make([]byte, uint64(len(syms))*f.symSize)
+	for i, s := range syms {
+		b := out[uint64(i)*f.symSize:]
+		if f.f.Class == elf.ELFCLASS32 {
+			order.PutUint32(b[0:], s.name)
+			order.PutUint32(b[4:], uint32(s.value))
+			order.PutUint32(b[8:], uint32(s.size))
+			b[12], b[13] = s.info, s.other
+			order.PutUint16(b[14:], uint16(s.shndx))
+		} else {
+			order.PutUint32(b[0:], s.name)
+			b[4], b[5] = s.info, s.other
+			order.PutUint16(b[6:], uint16(s.shndx))
+			order.PutUint64(b[8:], s.value)
+			order.PutUint64(b[16:], s.size)
+		}
+	}
+	return out
+}
+
+// elfSymFromSym converts sym to a raw ELF symbol, except for its name.
+func (f *elfFile) elfSymFromSym(sym Sym, newShn []elf.SectionIndex, keep []bool) (elfRawSym, error) {
+	raw := elfRawSym{value: sym.Value, size: sym.Size}
+	bind := elf.STB_GLOBAL
+	if sym.Weak() {
+		bind = elf.STB_WEAK
+	}
+	if sym.Local() {
+		bind = elf.STB_LOCAL
+	}
+	typ := elf.STT_NOTYPE
+	switch sym.Kind {
+	case SymText:
+		typ = elf.STT_FUNC
+	case SymData:
+		typ = elf.STT_OBJECT
+	case SymSection:
+		typ = elf.STT_SECTION
+	}
+	raw.info = elf.ST_INFO(bind, typ)
+	switch {
+	case sym.Section != nil:
+		shn := f.sections[sym.Section.ID].RawID
+		if !keep[shn] {
+			return raw, fmt.Errorf("symbol %s refers to removed section %s", sym.Name, sym.Section)
+		}
+		raw.shndx = newShn[shn]
+	case sym.Kind == SymAbsolute:
+		raw.shndx = elf.SHN_ABS
+	default:
+		raw.shndx = elf.SHN_UNDEF
+	}
+	return raw, nil
+}
+
+// editRelocs renumbers the symbol references in relocation section osec.
+func (f *elfFile) editRelocs(osec *elfOutSection, symRemap []int) error {
+	data := osec.data
+	if data == nil {
+		b, err := f.sectionBytes(f.shnToSection[osec.old])
+		if err != nil {
+			return fmt.Errorf("reading relocation section %s: %w", osec.name, err)
+		}
+		data = append([]byte(nil), b...)
+	}
+	order := f.f.ByteOrder
+	entSize := f.relSize
+	if osec.hdr.typ == elf.SHT_RELA {
+		entSize = f.relaSize
+	}
+	for off := uint64(0); off+entSize <= uint64(len(data)); off += entSize {
+		var sym uint32
+		if f.f.Class == elf.ELFCLASS32 {
+			sym = elf.R_SYM32(order.Uint32(data[off+4:]))
+		} else {
+			sym = elf.R_SYM64(order.Uint64(data[off+8:]))
+		}
+		if sym == 0 {
+			continue
+		}
+		if int(sym) >= len(symRemap) || symRemap[sym] < 0 {
+			return fmt.Errorf("relocation section %s: relocation at offset %#x refers to removed symbol %d", osec.name, off, sym)
+		}
+		newSym := uint32(symRemap[sym])
+		if f.f.Class == elf.ELFCLASS32 {
+			info := order.Uint32(data[off+4:])
+			order.PutUint32(data[off+4:], elf.R_INFO32(newSym, elf.R_TYPE32(info)))
+		} else {
+			info := order.Uint64(data[off+8:])
+			order.PutUint64(data[off+8:], elf.R_INFO(newSym, elf.R_TYPE64(info)))
+		}
+	}
+	osec.data = data
+	return nil
+}
+
+// editGroup renumbers the member sections of group section osec and
+// drops removed members.
+func (f *elfFile) editGroup(osec *elfOutSection, newShn []elf.SectionIndex, keep []bool) error {
+	b, err := f.sectionBytes(f.shnToSection[osec.old])
+	if err != nil {
+		return fmt.Errorf("reading group section %s: %w", osec.name, err)
+	}
+	order := f.f.ByteOrder
+	var data []byte
+	for off := 0; off+4 <= len(b); off += 4 {
+		v := order.Uint32(b[off:])
+		if off > 0 {
+			// Members after the flag word.
+			if int(v) >= len(keep) || !keep[v] {
+				continue
+			}
+			v = uint32(newShn[v])
+		}
+		var buf [4]byte
+		order.PutUint32(buf[:], v)
+		data = append(data, buf[:]...)
+	}
+	osec.data = data
+	return nil
+}
+
+func decodeElfShdr(b []byte, is64 bool, order binary.ByteOrder) elfShdr {
+	var sh elfShdr
+	sh.name = order.Uint32(b[0:])
+	sh.typ = elf.SectionType(order.Uint32(b[4:]))
+	if is64 {
+		sh.flags = elf.SectionFlag(order.Uint64(b[8:]))
+		sh.addr = order.Uint64(b[16:])
+		sh.off = order.Uint64(b[24:])
+		sh.size = order.Uint64(b[32:])
+		sh.link = order.Uint32(b[40:])
+		sh.info = order.Uint32(b[44:])
+		sh.align = order.Uint64(b[48:])
+		sh.entsize = order.Uint64(b[56:])
+	} else {
+		sh.flags = elf.SectionFlag(order.Uint32(b[8:]))
+		sh.addr = uint64(order.Uint32(b[12:]))
+		sh.off = uint64(order.Uint32(b[16:]))
+		sh.size = uint64(order.Uint32(b[20:]))
+		sh.link = order.Uint32(b[24:])
+		sh.info = order.Uint32(b[28:])
+		sh.align = uint64(order.Uint32(b[32:]))
+		sh.entsize = uint64(order.Uint32(b[36:]))
+	}
+	return sh
+}
+
+func encodeElfShdr(b []byte, sh *elfShdr, is64 bool, order binary.ByteOrder) {
+	order.PutUint32(b[0:], sh.name)
+	order.PutUint32(b[4:], uint32(sh.typ))
+	if is64 {
+		order.PutUint64(b[8:], uint64(sh.flags))
+		order.PutUint64(b[16:], sh.addr)
+		order.PutUint64(b[24:], sh.off)
+		order.PutUint64(b[32:], sh.size)
+		order.PutUint32(b[40:], sh.link)
+		order.PutUint32(b[44:], sh.info)
+		order.PutUint64(b[48:], sh.align)
+		order.PutUint64(b[56:], sh.entsize)
+	} else {
+		order.PutUint32(b[8:], uint32(sh.flags))
+		order.PutUint32(b[12:], uint32(sh.addr))
+		order.PutUint32(b[16:], uint32(sh.off))
+		order.PutUint32(b[20:], uint32(sh.size))
+		order.PutUint32(b[24:], sh.link)
+		order.PutUint32(b[28:], sh.info)
+		order.PutUint32(b[32:], uint32(sh.align))
+		order.PutUint32(b[36:], uint32(sh.entsize))
+	}
+}
+
+// elfEditWriter tracks the output position and the first error while
+// writing an edited ELF file.
+type elfEditWriter struct {
+	w   io.Writer
+	pos uint64
+	err error
+}
+
+func (w *elfEditWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	var n int
+	n, w.err = w.w.Write(b)
+	w.pos += uint64(n)
+}
+
+func (w *elfEditWriter) copy(r io.Reader) {
+	if w.err != nil {
+		return
+	}
+	var n int64
+	n, w.err = io.Copy(w.w, r)
+	w.pos += uint64(n)
+}
+
+// pad writes zero bytes up to offset pos.
+func (w *elfEditWriter) pad(pos uint64) {
+	if w.pos < pos {
+		w.write(bytes.Repeat([]byte{0}, int(pos-w.pos)))
+	}
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"debug/elf"
+	"strings"
+	"testing"
+)
+
+func writeEdit(t *testing.T, e *Edit) File {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := e.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// Check that debug/elf is happy with the output, including the
+	// section data.
+	ef, err := elf.NewFile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("debug/elf rejected edited file: %v", err)
+	}
+	for _, s := range ef.Sections {
+		if _, err := s.Data(); err != nil && s.Type != elf.SHT_NOBITS {
+			t.Fatalf("debug/elf failed to read section %s: %v", s.Name, err)
+		}
+	}
+	f, err := Open(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Open of edited file failed: %v", err)
+	}
+	return f
+}
+
+func sectionBytes(t *testing.T, s *Section) []byte {
+	t.Helper()
+	data, err := s.Data(s.Bounds())
+	if err != nil {
+		t.Fatalf("section %s: error getting data: %v", s, err)
+	}
+	return data.B
+}
+
+func symName(f File, id SymID) string {
+	if id == NoSym {
+		return ""
+	}
+	return f.Sym(id).Name
+}
+
+func TestElfEditStripDebug(t *testing.T) {
+	forEachElfTest(t, func(t *testing.T, test *elfTest) {
+		t.Parallel()
+		f := test.openOrSkip(t)
+
+		e := NewEdit(f)
+		var kept []*Section
+		for _, s := range f.Sections() {
+			if strings.HasPrefix(s.Name, ".debug_") {
+				e.RemoveSection(s)
+			} else if !strings.HasPrefix(s.Name, ".rela.debug_") && !strings.HasPrefix(s.Name, ".rel.debug_") {
+				kept = append(kept, s)
+			}
+		}
+		f2 := writeEdit(t, e)
+
+		// Check that exactly the right sections remain with the same
+		// contents.
+		got := f2.Sections()
+		if len(got) != len(kept) {
+			t.Fatalf("want %d sections, got %d", len(kept), len(got))
+		}
+		for i, want := range kept {
+			s := got[i]
+			if want.Name == ".symtab" || want.Name == ".shstrtab" {
+				// These are expected to shrink.
+				if s.Name != want.Name {
+					t.Errorf("section %d: want %s, got %s", i, want.Name, s.Name)
+				}
+				continue
+			}
+			if s.Name != want.Name || s.Addr != want.Addr || s.Size != want.Size || s.SectionFlags != want.SectionFlags {
+				t.Errorf("section %d: want %s %#x/%#x %v, got %s %#x/%#x %v", i, want.Name, want.Addr, want.Size, want.SectionFlags, s.Name, s.Addr, s.Size, s.SectionFlags)
+				continue
+			}
+			// Relocation sections may be renumbered, so we check them
+			// below.
+			if !strings.HasPrefix(s.Name, ".rel") && !bytes.Equal(sectionBytes(t, want), sectionBytes(t, s)) {
+				t.Errorf("section %s: data differs", s.Name)
+			}
+		}
+
+		// Check that the symbols and relocations still agree.
+		names := func(f File) map[string]bool {
+			m := make(map[string]bool)
+			for i := SymID(0); i < f.NumSyms(); i++ {
+				sym := f.Sym(i)
+				if sym.Section == nil || !strings.HasPrefix(sym.Section.Name, ".debug_") {
+					m[sym.Name+"/"+sym.Kind.String()] = true
+				}
+			}
+			return m
+		}
+		want, gotNames := names(f), names(f2)
+		if len(want) != len(gotNames) {
+			t.Errorf("want %d symbols, got %d", len(want), len(gotNames))
+		}
+		for name := range want {
+			if !gotNames[name] {
+				t.Errorf("missing symbol %s", name)
+			}
+		}
+		for i, s := range kept {
+			wantData, _ := s.Data(s.Bounds())
+			gotData, _ := got[i].Data(got[i].Bounds())
+			if len(wantData.R) != len(gotData.R) {
+				t.Errorf("section %s: want %d relocations, got %d", s.Name, len(wantData.R), len(gotData.R))
+				continue
+			}
+			for j := range wantData.R {
+				wr, gr := wantData.R[j], gotData.R[j]
+				if wr.Addr != gr.Addr || wr.Type != gr.Type || wr.Addend != gr.Addend || symName(f, wr.Symbol) != symName(f2, gr.Symbol) {
+					t.Errorf("section %s relocation %d: want %v, got %v", s.Name, j, wr, gr)
+				}
+			}
+		}
+	})
+}
+
+func TestElfEdit(t *testing.T) {
+	for _, path := range []string{"hello-gcc10.3.0-AMD64-dyn", "hello-gcc10.3.0-I386-rel.o", "hello-gcc10.3.0-AMD64-dyn-stripped"} {
+		t.Run(path, func(t *testing.T) {
+			f := findElfTest(t, path).openOrSkip(t)
+
+			text := f.SectionByName(".text")
+			comment := f.SectionByName(".comment")
+			note := []byte("\x04\x00\x00\x00\x04\x00\x00\x00\x01\x00\x00\x00Go\x00\x00abcd")
+			newComment := []byte("edited\x00")
+			patch := []byte{0xcc, 0xcc}
+
+			e := NewEdit(f)
+			e.AddSection(".note.test", note)
+			e.ReplaceSection(comment, newComment)
+			e.Patch(text, text.Addr+4, patch)
+			e.AddSym(Sym{Name: "added_func", Section: text, Value: text.Addr + 4, Size: 2, Kind: SymText})
+			var local SymFlags
+			local.SetLocal(true)
+			e.AddSym(Sym{Name: "added_local", Section: text, Value: text.Addr, Size: 4, Kind: SymText, SymFlags: local})
+			f2 := writeEdit(t, e)
+
+			if s := f2.SectionByName(".note.test"); s == nil {
+				t.Errorf("added section missing")
+			} else if !bytes.Equal(sectionBytes(t, s), note) {
+				t.Errorf("added section: want %q, got %q", note, sectionBytes(t, s))
+			}
+			if s := f2.SectionByName(".comment"); s == nil {
+				t.Errorf(".comment missing")
+			} else if !bytes.Equal(sectionBytes(t, s), newComment) {
+				t.Errorf(".comment: want %q, got %q", newComment, sectionBytes(t, s))
+			}
+			text2 := f2.SectionByName(".text")
+			want := append([]byte(nil), sectionBytes(t, text)...)
+			copy(want[4:], patch)
+			if !bytes.Equal(sectionBytes(t, text2), want) {
+				t.Errorf(".text: patch not applied")
+			}
+
+			// Find the added symbols.
+			found := 0
+			for i := SymID(0); i < f2.NumSyms(); i++ {
+				sym := f2.Sym(i)
+				switch sym.Name {
+				case "added_func":
+					found++
+					if sym.Section != text2 || sym.Value != text.Addr+4 || sym.Size != 2 || sym.Kind != SymText || sym.Local() {
+						t.Errorf("want added_func in .text at %#x, got %+v", text.Addr+4, sym)
+					}
+				case "added_local":
+					found++
+					if !sym.Local() {
+						t.Errorf("added_local is not local")
+					}
+				}
+			}
+			if found != 2 {
+				t.Errorf("want 2 added symbols, found %d", found)
+			}
+		})
+	}
+}
+
+func TestElfEditLoadedSize(t *testing.T) {
+	t.Parallel()
+	f := findElfTest(t, "hello-gcc10.3.0-I386-dyn").openOrSkip(t)
+	e := NewEdit(f)
+	e.ReplaceSection(f.SectionByName(".text"), []byte{0x90})
+	err := e.Write(new(bytes.Buffer))
+	if err == nil || !strings.Contains(err.Error(), "cannot change size of loaded section") {
+		t.Fatalf("want error changing loaded section size, got %v", err)
+	}
+}
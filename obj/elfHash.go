@@ -0,0 +1,366 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+
+	"github.com/aclements/go-obj/arch"
+)
+
+// elfDynEntry is an entry in the dynamic section.
+type elfDynEntry struct {
+	tag elf.DynTag
+	val uint64
+}
+
+// dynEntries returns the entries of f's PT_DYNAMIC segment. Unlike the
+// dynamic section, this is available even if f's section headers are
+// stripped.
+func (f *elfFile) dynEntries() ([]elfDynEntry, error) {
+	for _, prog := range f.f.Progs {
+		if prog.Type != elf.PT_DYNAMIC {
+			continue
+		}
+		b, err := f.src.Slice(int64(prog.Off), int(prog.Filesz))
+		if err != nil {
+			return nil, fmt.Errorf("reading dynamic segment: %w", err)
+		}
+		var out []elfDynEntry
+		r := NewCheckedReader(&Data{B: b, Layout: f.elfLayout})
+		for r.Avail() > 0 {
+			tag, val := elf.DynTag(r.Word()), r.Word()
+			if r.Err() != nil {
+				return nil, fmt.Errorf("truncated dynamic segment")
+			}
+			if tag == elf.DT_NULL {
+				break
+			}
+			out = append(out, elfDynEntry{tag, val})
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+func dynValue(entries []elfDynEntry, tag elf.DynTag) (uint64, bool) {
+	for _, e := range entries {
+		if e.tag == tag {
+			return e.val, true
+		}
+	}
+	return 0, false
+}
+
+// vaddrData returns the file-backed bytes of the loadable segment
+// containing virtual address addr, starting at addr.
+func (f *elfFile) vaddrData(addr uint64) (*Data, error) {
+	for _, prog := range f.f.Progs {
+		if prog.Type != elf.PT_LOAD || addr < prog.Vaddr || addr-prog.Vaddr >= prog.Filesz {
+			continue
+		}
+		off := addr - prog.Vaddr
+		b, err := f.src.Slice(int64(prog.Off+off), int(prog.Filesz-off))
+		if err != nil {
+			return nil, err
+		}
+		return &Data{Addr: addr, B: b, Layout: f.elfLayout}, nil
+	}
+	return nil, fmt.Errorf("address %#x is not in a loadable segment", addr)
+}
+
+// loadDynSymsFromSegments finds the dynamic symbol table using the
+// dynamic segment and returns the number of symbols, excluding the null
+// symbol. This is used when section headers are stripped.
+func (f *elfFile) loadDynSymsFromSegments(tab *elfSymTab) (SymID, error) {
+	dyn, err := f.dynEntries()
+	if err != nil {
+		return 0, err
+	}
+	symAddr, ok1 := dynValue(dyn, elf.DT_SYMTAB)
+	strAddr, ok2 := dynValue(dyn, elf.DT_STRTAB)
+	strSize, ok3 := dynValue(dyn, elf.DT_STRSZ)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, nil
+	}
+	ht, err := f.newElfHashTable(dyn)
+	if err != nil || ht == nil {
+		// Without a hash table, we don't know how many symbols there are.
+		return 0, err
+	}
+	symData, err := f.vaddrData(symAddr)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(len(symData.B)) < ht.nSyms*f.symSize {
+		return 0, fmt.Errorf("dynamic symbol table extends past its segment")
+	}
+	symData.B = symData.B[:ht.nSyms*f.symSize]
+	strData, err := f.vaddrData(strAddr)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(len(strData.B)) < strSize {
+		return 0, fmt.Errorf("dynamic string table extends past its segment")
+	}
+	strData.B = strData.B[:strSize]
+	tab.data, tab.strings = *symData, *strData
+	return SymID(ht.nSyms - 1), nil
+}
+
+// elfHashTable is an ELF symbol hash table, either a GNU hash table
+// (DT_GNU_HASH) or a System V hash table (DT_HASH).
+type elfHashTable struct {
+	gnu bool
+
+	nBuckets uint32
+	buckets  []byte
+	chains   []byte
+
+	// symOffset is the symbol index of the first chain entry of a GNU
+	// hash table.
+	symOffset uint32
+	// bloom, bloomShift, and bloomWordBits describe the bloom filter of
+	// a GNU hash table.
+	bloom         *Data
+	bloomShift    uint32
+	bloomWordBits uint32
+
+	// nSyms is the number of symbols in the dynamic symbol table,
+	// including the null symbol, as implied by the hash table.
+	nSyms uint64
+}
+
+// newElfHashTable returns the GNU hash table of f or, if there isn't
+// one, the System V hash table of f. It returns nil, nil if f has
+// neither.
+func (f *elfFile) newElfHashTable(dyn []elfDynEntry) (*elfHashTable, error) {
+	ht := new(elfHashTable)
+	l := f.elfLayout
+	if addr, ok := dynValue(dyn, elf.DT_GNU_HASH); ok {
+		// The GNU hash table consists of a header of four 32-bit words
+		// (nbuckets, symoffset, bloom size, and bloom shift), the bloom
+		// filter of ELF words, the 32-bit buckets, and one 32-bit chain
+		// word for each symbol from symoffset on.
+		d, err := f.vaddrData(addr)
+		if err != nil {
+			return nil, fmt.Errorf("reading GNU hash table: %w", err)
+		}
+		r := NewCheckedReader(d)
+		ht.gnu = true
+		ht.nBuckets, ht.symOffset = r.Uint32(), r.Uint32()
+		bloomSize, bloomShift := r.Uint32(), r.Uint32()
+		ht.bloom = &Data{B: r.Bytes(int(bloomSize) * l.WordSize()), Layout: l}
+		ht.bloomShift = bloomShift
+		ht.bloomWordBits = uint32(l.WordSize() * 8)
+		ht.buckets = r.Bytes(int(ht.nBuckets) * 4)
+		if r.Err() != nil || ht.nBuckets == 0 || bloomSize == 0 {
+			return nil, fmt.Errorf("malformed GNU hash table")
+		}
+		ht.chains = r.Bytes(r.Avail())
+
+		// The number of symbols is implied by the end of the last
+		// chain.
+		ht.nSyms = uint64(ht.symOffset)
+		for i := uint32(0); i < ht.nBuckets; i++ {
+			if b := uint64(l.Uint32(ht.buckets[i*4:])); b+1 > ht.nSyms {
+				ht.nSyms = b + 1
+			}
+		}
+		if ht.nSyms > uint64(ht.symOffset) {
+			for {
+				ci := (ht.nSyms - 1 - uint64(ht.symOffset)) * 4
+				if ci+4 > uint64(len(ht.chains)) {
+					return nil, fmt.Errorf("malformed GNU hash table")
+				}
+				if l.Uint32(ht.chains[ci:])&1 != 0 {
+					break
+				}
+				ht.nSyms++
+			}
+		}
+		ht.chains = ht.chains[:(ht.nSyms-uint64(ht.symOffset))*4]
+	} else if addr, ok := dynValue(dyn, elf.DT_HASH); ok {
+		// The System V hash table consists of nbucket and nchain, then
+		// the buckets, then the chains. All are 32-bit words, even in
+		// 64-bit files, and there is one chain word per symbol.
+		d, err := f.vaddrData(addr)
+		if err != nil {
+			return nil, fmt.Errorf("reading hash table: %w", err)
+		}
+		r := NewCheckedReader(d)
+		ht.nBuckets = r.Uint32()
+		nChain := r.Uint32()
+		ht.buckets = r.Bytes(int(ht.nBuckets) * 4)
+		ht.chains = r.Bytes(int(nChain) * 4)
+		if r.Err() != nil || ht.nBuckets == 0 {
+			return nil, fmt.Errorf("malformed hash table")
+		}
+		ht.nSyms = uint64(nChain)
+	} else {
+		return nil, nil
+	}
+	return ht, nil
+}
+
+// gnuHash is the hash function of GNU hash tables.
+func gnuHash(name string) uint32 {
+	h := uint32(5381)
+	for i := 0; i < len(name); i++ {
+		h = h*33 + uint32(name[i])
+	}
+	return h
+}
+
+// sysvHash is the hash function of System V hash tables.
+//
+// [TIS ELF 1.2 Book I, p. 2-20]
+func sysvHash(name string) uint32 {
+	var h uint32
+	for i := 0; i < len(name); i++ {
+		h = h<<4 + uint32(name[i])
+		g := h & 0xf0000000
+		if g != 0 {
+			h ^= g >> 24
+		}
+		h &^= g
+	}
+	return h
+}
+
+// lookup calls match for each symbol index in ht that may be named
+// name, until match returns true.
+func (ht *elfHashTable) lookup(l arch.Layout, name string, match func(i uint32) bool) {
+	if ht.gnu {
+		h := gnuHash(name)
+		// Check the bloom filter. Each name sets two bits in one word.
+		bits := ht.bloomWordBits
+		nWords := uint32(len(ht.bloom.B) / int(bits/8))
+		r := NewReader(ht.bloom)
+		r.SetOffset(int((h / bits % nWords) * (bits / 8)))
+		word := r.Word()
+		mask := uint64(1)<<(h%bits) | uint64(1)<<((h>>ht.bloomShift)%bits)
+		if word&mask != mask {
+			return
+		}
+		i := l.Uint32(ht.buckets[h%ht.nBuckets*4:])
+		if i == 0 || i < ht.symOffset {
+			return
+		}
+		for ; uint64(i-ht.symOffset)*4 < uint64(len(ht.chains)); i++ {
+			h2 := l.Uint32(ht.chains[(i-ht.symOffset)*4:])
+			// The low bit of each chain hash marks the end of the
+			// chain.
+			if h|1 == h2|1 && match(i) {
+				return
+			}
+			if h2&1 != 0 {
+				return
+			}
+		}
+		return
+	}
+
+	h := sysvHash(name)
+	nChain := uint32(len(ht.chains) / 4)
+	i := l.Uint32(ht.buckets[h%ht.nBuckets*4:])
+	// Bound the walk in case the chains contain a cycle.
+	for n := uint32(0); i != 0 && i < nChain && n < nChain; n++ {
+		if match(i) {
+			return
+		}
+		i = l.Uint32(ht.chains[i*4:])
+	}
+}
+
+// dynSymHidden reports whether dynamic symbol i (counting the null
+// symbol) is a hidden (non-default) version.
+func (f *elfFile) dynSymHidden(i uint32) bool {
+	if f.versym == nil {
+		return false
+	}
+	return f.elfLayout.Uint16(f.versym[i*2:])&elfVersymHidden != 0
+}
+
+func (f *elfFile) DynamicSymByName(name string) (SymID, bool) {
+	f.hashOnce.Do(func() {
+		dyn, err := f.dynEntries()
+		if err != nil {
+			return
+		}
+		nSyms := uint64(f.symTabs[1].end-f.symTabs[1].start) + 1
+		f.hash, err = f.newElfHashTable(dyn)
+		if err != nil || f.hash == nil || f.hash.nSyms != nSyms {
+			// Fall back to scanning the symbol table if the hash table
+			// is missing or doesn't match the symbol table.
+			f.hash = nil
+		}
+		// The version table is independent of the hash table, so use it
+		// even when scanning.
+		if addr, ok := dynValue(dyn, elf.DT_VERSYM); ok {
+			if d, err := f.vaddrData(addr); err == nil && uint64(len(d.B)) >= nSyms*2 {
+				f.versym = d.B[:nSyms*2]
+			}
+		}
+	})
+
+	tab := &f.symTabs[1]
+	found, foundHidden := NoSym, NoSym
+	match := func(elfSym uint32) bool {
+		id, ok := tab.lookup(elfSym)
+		if !ok || !f.dynSymDefined(tab, id, name) {
+			return false
+		}
+		if f.dynSymHidden(elfSym) {
+			// Keep looking for the default version.
+			if foundHidden == NoSym {
+				foundHidden = id
+			}
+			return false
+		}
+		found = id
+		return true
+	}
+	if f.hash != nil {
+		f.hash.lookup(f.elfLayout, name, match)
+	} else {
+		for id := tab.start; id < tab.end; id++ {
+			if match(uint32(id-tab.start) + 1) {
+				break
+			}
+		}
+	}
+	if found == NoSym {
+		found = foundHidden
+	}
+	return found, found != NoSym
+}
+
+// dynSymDefined reports whether symbol id in tab is a defined symbol
+// named name. It avoids decoding the whole symbol.
+func (f *elfFile) dynSymDefined(tab *elfSymTab, id SymID, name string) bool {
+	r := NewCheckedReader(&tab.data)
+	r.SetOffset(int(f.symSize * uint64(id-tab.start+1)))
+	nameOff := r.Uint32()
+	var shn uint16
+	switch f.f.Class {
+	case elf.ELFCLASS32:
+		r.Skip(4 + 4 + 1 + 1) // st_value, st_size, st_info, st_other
+		shn = r.Uint16()
+	case elf.ELFCLASS64:
+		r.Skip(1 + 1) // st_info, st_other
+		shn = r.Uint16()
+	}
+	if r.Err() != nil || elf.SectionIndex(shn) == elf.SHN_UNDEF {
+		return false
+	}
+	if int(nameOff) >= len(tab.strings.B) {
+		return false
+	}
+	str := tab.strings.B[nameOff:]
+	return len(str) > len(name) && str[len(name)] == 0 && bytes.HasPrefix(str, []byte(name))
+}
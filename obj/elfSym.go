@@ -104,6 +104,17 @@ func (f *elfFile) Sym(i SymID) Sym {
 		case elf.SHN_ABS:
 			kind = SymAbsolute
 		default:
+			if es == nil && len(f.sections) == 0 {
+				// Without section headers, fall back to the symbol
+				// type.
+				switch elf.ST_TYPE(info) {
+				case elf.STT_FUNC, elf.STT_GNU_IFUNC:
+					kind = SymText
+				case elf.STT_OBJECT, elf.STT_TLS, elf.STT_COMMON:
+					kind = SymData
+				}
+				break
+			}
 			if es == nil || es.elf.Flags&elf.SHF_ALLOC == 0 {
 				// Leave unknown.
 				break
@@ -119,6 +130,8 @@ func (f *elfFile) Sym(i SymID) Sym {
 	sym.Kind = kind
 
 	sym.SetLocal(elf.ST_BIND(info) == elf.STB_LOCAL)
+	sym.SetWeak(elf.ST_BIND(info) == elf.STB_WEAK)
+	sym.SetDynamic(tab == &f.symTabs[1])
 
 	return sym
 }
@@ -6,28 +6,39 @@ package obj
 
 import (
 	"bytes"
+	"debug/elf"
+	"io/ioutil"
 	"log"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
-var local = SymFlags{symFlagLocal}
+var (
+	local       = SymFlags{symFlagLocal}
+	weak        = SymFlags{symFlagWeak}
+	dynamic     = SymFlags{symFlagDynamic}
+	weakDynamic = SymFlags{symFlagWeak | symFlagDynamic}
+)
 
 var symTests = map[string]map[int]Sym{
 	"hello-gcc10.3.0-AMD64-dyn": {
 		// Text symbol.
 		66: {"main", &Section{Name: ".text"}, 0x401136, 38, SymText, SymFlags{}},
 		// Data symbol.
-		52: {"data_start", &Section{Name: ".data"}, 0x404020, 0, SymData, SymFlags{}},
+		52: {"data_start", &Section{Name: ".data"}, 0x404020, 0, SymData, weak},
 		// BSS symbol.
 		38: {"completed.0", &Section{Name: ".bss"}, 0x404030, 1, SymData, local},
 		// Undefined dynamic symbol.
-		69 + 0: {"puts", nil, 0, 0, SymUndef, SymFlags{}},
+		69 + 0: {"puts", nil, 0, 0, SymUndef, dynamic},
+		// Weak undefined dynamic symbol.
+		69 + 2: {"__gmon_start__", nil, 0, 0, SymUndef, weakDynamic},
 		// Test section symbol's name.
 		14: {".text", &Section{Name: ".text"}, 0x401050, 0, SymSection, local},
 	},
 	"hello-gcc10.3.0-I386-dyn": {
 		69:     {"main", &Section{Name: ".text"}, 0x8049196, 64, SymText, SymFlags{}},
-		73 + 0: {"puts", nil, 0, 0, SymUndef, SymFlags{}},
+		73 + 0: {"puts", nil, 0, 0, SymUndef, dynamic},
 	},
 }
 
@@ -92,3 +103,226 @@ func TestElfSyms(t *testing.T) {
 		}
 	})
 }
+
+func TestElfReadDynamic(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		puts SymID
+		ver  string
+	}{
+		{"hello-gcc10.3.0-AMD64-dyn", 69, "GLIBC_2.2.5"},
+		{"hello-gcc10.3.0-I386-dyn", 73, "GLIBC_2.0"},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			f := findElfTest(t, test.path).openOrSkip(t)
+			d, err := ReadDynamic(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(d.Needed) != 1 || d.Needed[0] != "libc.so.6" {
+				t.Errorf("want needed [libc.so.6], got %v", d.Needed)
+			}
+			if d.SOName != "" || len(d.RPath) != 0 || len(d.RunPath) != 0 {
+				t.Errorf("want no soname, rpath, or runpath, got %+v", d)
+			}
+			want := SymVersion{Name: test.ver, Library: "libc.so.6"}
+			if got := d.Versions[test.puts]; got != want {
+				t.Errorf("puts: want version %+v, got %+v", want, got)
+			}
+			if got := want.String(); got != "@"+test.ver {
+				t.Errorf("want version string @%s, got %s", test.ver, got)
+			}
+		})
+	}
+}
+
+// openEdited opens testdata file path after applying edit to its bytes.
+func openEdited(t *testing.T, path string, edit func(b []byte, ef *elf.File)) File {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != nil {
+		ef, err := elf.NewFile(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		edit(b, ef)
+	}
+	f, err := Open(NewBytesSource(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// disableGNUHash changes the DT_GNU_HASH entry of an ELF file to
+// DT_DEBUG, so only its System V hash table is visible.
+func disableGNUHash(b []byte, ef *elf.File) {
+	disableDynTag(b, ef, elf.DT_GNU_HASH)
+}
+
+// disableHashes hides both hash tables of an ELF file, so symbol lookups
+// must scan the symbol table.
+func disableHashes(b []byte, ef *elf.File) {
+	disableDynTag(b, ef, elf.DT_GNU_HASH)
+	disableDynTag(b, ef, elf.DT_HASH)
+}
+
+// swapVersioned swaps the two dynamic symbols named "versioned" of an ELF
+// file, along with their version entries, so the hidden version comes
+// first in the symbol table. This invalidates the hash tables.
+func swapVersioned(b []byte, ef *elf.File) {
+	syms, err := ef.DynamicSymbols()
+	if err != nil {
+		panic(err)
+	}
+	var idx []uint64
+	for i, sym := range syms {
+		if sym.Name == "versioned" {
+			// DynamicSymbols omits the null symbol.
+			idx = append(idx, uint64(i+1))
+		}
+	}
+	if len(idx) != 2 {
+		panic("want two versioned symbols")
+	}
+	for _, name := range []string{".dynsym", ".gnu.version"} {
+		sec := ef.Section(name)
+		x, y := sec.Offset+idx[0]*sec.Entsize, sec.Offset+idx[1]*sec.Entsize
+		tmp := append([]byte(nil), b[x:x+sec.Entsize]...)
+		copy(b[x:], b[y:y+sec.Entsize])
+		copy(b[y:], tmp)
+	}
+}
+
+// disableDynTag changes any tag entries in the dynamic segment of an ELF
+// file to DT_DEBUG.
+func disableDynTag(b []byte, ef *elf.File, tag elf.DynTag) {
+	word := 4
+	if ef.Class == elf.ELFCLASS64 {
+		word = 8
+	}
+	for _, prog := range ef.Progs {
+		if prog.Type != elf.PT_DYNAMIC {
+			continue
+		}
+		for off := prog.Off; off < prog.Off+prog.Filesz; off += uint64(2 * word) {
+			if elf.DynTag(ef.ByteOrder.Uint32(b[off:])) == tag {
+				ef.ByteOrder.PutUint32(b[off:], uint32(elf.DT_DEBUG))
+			}
+		}
+	}
+}
+
+// stripSectionHeaders removes the section header table from an ELF
+// file's header.
+func stripSectionHeaders(b []byte, ef *elf.File) {
+	var shoff, shoffSize, shnum int
+	switch ef.Class {
+	case elf.ELFCLASS32:
+		shoff, shoffSize, shnum = 0x20, 4, 0x30
+	case elf.ELFCLASS64:
+		shoff, shoffSize, shnum = 0x28, 8, 0x3c
+	}
+	for i := 0; i < shoffSize; i++ {
+		b[shoff+i] = 0
+	}
+	// Clear e_shnum and e_shstrndx.
+	for i := 0; i < 4; i++ {
+		b[shnum+i] = 0
+	}
+}
+
+func TestElfDynamicSymByName(t *testing.T) {
+	for _, arch := range []string{"I386", "AMD64"} {
+		path := "hashlib-" + arch + ".so"
+		ref := openEdited(t, path, nil)
+		defer ref.Close()
+		refDyn, err := ReadDynamic(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v2 Sym
+		for id, v := range refDyn.Versions {
+			if sym := ref.Sym(id); sym.Name == "versioned" && v.Name == "V2" {
+				v2 = sym
+			}
+		}
+		if v2.Name == "" {
+			t.Fatalf("%s: no default version of versioned", path)
+		}
+
+		for _, test := range []struct {
+			name string
+			edit func(b []byte, ef *elf.File)
+		}{
+			{"gnu", nil},
+			{"sysv", disableGNUHash},
+			{"gnu-stripped", stripSectionHeaders},
+			{"sysv-stripped", func(b []byte, ef *elf.File) {
+				disableGNUHash(b, ef)
+				stripSectionHeaders(b, ef)
+			}},
+			{"scan", func(b []byte, ef *elf.File) {
+				disableHashes(b, ef)
+				swapVersioned(b, ef)
+			}},
+		} {
+			t.Run(arch+"/"+test.name, func(t *testing.T) {
+				f := openEdited(t, path, test.edit)
+				defer f.Close()
+				if f.NumSyms() == 0 {
+					t.Fatal("no symbols")
+				}
+				lookup := func(name string) (Sym, bool) {
+					id, ok := f.DynamicSymByName(name)
+					if !ok {
+						return Sym{}, false
+					}
+					return f.Sym(id), true
+				}
+
+				// Check that every defined dynamic symbol can be found.
+				for i := SymID(0); i < ref.NumSyms(); i++ {
+					want := ref.Sym(i)
+					if !want.Dynamic() || want.Kind == SymUndef || want.Name == "versioned" {
+						continue
+					}
+					got, ok := lookup(want.Name)
+					if !ok {
+						t.Errorf("%s: not found", want.Name)
+						continue
+					}
+					if got.Name != want.Name || got.Value != want.Value || got.Size != want.Size || got.Kind != want.Kind {
+						t.Errorf("%s: want %+v, got %+v", want.Name, want, got)
+					}
+				}
+
+				// Lookup prefers the default version. Compare values, since
+				// f may have no static symbol table or reordered symbols.
+				if got, ok := lookup("versioned"); !ok {
+					t.Errorf("versioned: not found")
+				} else if got.Value != v2.Value {
+					t.Errorf("versioned: want default version V2 at %#x, got %#x", v2.Value, got.Value)
+				}
+
+				for _, name := range []string{"undefined_sym", "nonexistent", ""} {
+					if _, ok := f.DynamicSymByName(name); ok {
+						t.Errorf("%q: unexpectedly found", name)
+					}
+				}
+
+				// Make sure we actually used the expected hash table.
+				ht := f.(*elfFile).hash
+				if test.name == "scan" {
+					if ht != nil {
+						t.Errorf("lookup used a hash table")
+					}
+				} else if ht == nil || ht.gnu != strings.HasPrefix(test.name, "gnu") {
+					t.Errorf("lookup did not use the %s hash table", test.name)
+				}
+			})
+		}
+	}
+}
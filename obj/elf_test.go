@@ -65,6 +65,19 @@ func (test *elfTest) openOrSkip(t *testing.T) File {
 	return f
 }
 
+// findElfTest returns the test for the file at path. It fails the test
+// if there is no such file.
+func findElfTest(t *testing.T, path string) *elfTest {
+	t.Helper()
+	for _, test := range elfTests {
+		if test.path == path {
+			return test
+		}
+	}
+	t.Fatalf("no ELF test file %s", path)
+	return nil
+}
+
 func forEachElfTest(t *testing.T, cb func(t *testing.T, test *elfTest)) {
 	for _, test := range elfTests {
 		t.Run(test.path, func(t *testing.T) {
@@ -105,6 +118,133 @@ func TestElfClose(t *testing.T) {
 	f.Close()
 }
 
+// Test that closing a File while other goroutines are reading its data
+// never crashes, that retained data stays valid, and that reads after
+// Close fail.
+// openCloseTest opens the first ELF test file through a
+// closeTrackingSource, preferring to mmap it.
+func openCloseTest(t *testing.T) (File, *closeTrackingSource) {
+	t.Helper()
+	fp, err := os.Open(filepath.Join("testdata", elfTests[0].path))
+	if err != nil {
+		t.Fatalf("error opening test file: %v", err)
+	}
+	t.Cleanup(func() { fp.Close() })
+	src := &closeTrackingSource{Source: openSource(fp)}
+	f, err := Open(src)
+	if err != nil {
+		t.Fatalf("Open failed unexpectedly: %v", err)
+	}
+	return f, src
+}
+
+// checkStale checks that each byte of b, which is data from a closed
+// File, is either the original byte in want or zero.
+func checkStale(b, want []byte) bool {
+	for i := range b {
+		if b[i] != want[i] && b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestElfCloseConcurrent(t *testing.T) {
+	t.Parallel()
+	f, src := openCloseTest(t)
+	text := f.SectionByName(".text")
+	want := append([]byte(nil), sectionBytes(t, text)...)
+	stale, err := text.Data(text.Bounds())
+	if err != nil {
+		t.Fatalf("error reading .text: %v", err)
+	}
+
+	// Start readers that race with the Close. Nothing is retained, so
+	// the file's memory is released while they read it.
+	var wg sync.WaitGroup
+	var stop int32
+	errs := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				d, err := text.Data(text.Bounds())
+				if err != nil {
+					if err != ErrClosed {
+						errs <- fmt.Sprintf("want ErrClosed, got %v", err)
+						return
+					}
+					continue
+				}
+				if !checkStale(d.B, want) {
+					errs <- "data read during Close is wrong"
+					return
+				}
+			}
+		}()
+	}
+
+	f.Close()
+	f.Close() // Closing twice is harmless.
+	if _, err := text.Data(text.Bounds()); err != ErrClosed {
+		t.Errorf("reading after Close: want ErrClosed, got %v", err)
+	}
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if !src.closed {
+		t.Errorf("Source not closed by Close")
+	}
+
+	// Data that wasn't retained is still safe to read, though it may
+	// read as zeros.
+	if !checkStale(stale.B, want) {
+		t.Errorf("unretained data after Close is wrong")
+	}
+}
+
+func TestElfCloseRetain(t *testing.T) {
+	t.Parallel()
+	f, src := openCloseTest(t)
+	text := f.SectionByName(".text")
+	want := append([]byte(nil), sectionBytes(t, text)...)
+	retained, err := text.Data(text.Bounds())
+	if err != nil {
+		t.Fatalf("error reading .text: %v", err)
+	}
+	retained.Retain()
+
+	f.Close()
+	if _, err := text.Data(text.Bounds()); err != ErrClosed {
+		t.Errorf("reading after Close: want ErrClosed, got %v", err)
+	}
+	if !bytes.Equal(retained.B, want) {
+		t.Errorf("retained data changed after Close")
+	}
+	if src.closed {
+		t.Errorf("Source closed while data is retained")
+	}
+	retained.Release()
+	if !src.closed {
+		t.Errorf("Source not closed after releasing retained data")
+	}
+	if !checkStale(retained.B, want) {
+		t.Errorf("released data is wrong")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Retain after Close did not panic")
+			}
+		}()
+		retained.Retain()
+	}()
+}
+
 func TestElfOpenCorrupted(t *testing.T) {
 	t.Parallel()
 	// Test that a corrupted ELF file is still detected as ELF, rather than
@@ -226,6 +366,12 @@ func TestElfSections(t *testing.T) {
 				if sect.Addr != want.Addr || sect.Size != want.Size {
 					t.Errorf("section %s: want address/size %#x/%#x, got %#x/%#x", sect.Name, want.Addr, want.Size, sect.Addr, sect.Size)
 				}
+				if sect.Offset != want.Offset || sect.Align != want.Align {
+					t.Errorf("section %s: want offset/align %#x/%d, got %#x/%d", sect.Name, want.Offset, want.Align, sect.Offset, sect.Align)
+				}
+				if sect.Kind != want.Kind {
+					t.Errorf("section %s: want kind %v, got %v", sect.Name, want.Kind, sect.Kind)
+				}
 				if sect.SectionFlags != want.SectionFlags {
 					t.Errorf("section %s: want flags %v, got %v", sect.Name, want.SectionFlags, sect.SectionFlags)
 				}
@@ -302,7 +448,57 @@ func TestElfSections(t *testing.T) {
 	})
 }
 
-// Test that we mmap or heap-allocate sections as expected.
+func TestElfResolveAddr(t *testing.T) {
+	forEachElfTest(t, func(t *testing.T, test *elfTest) {
+		t.Parallel()
+		f := test.openOrSkip(t)
+
+		// Compare against a linear scan of the mapped sections.
+		slowResolve := func(addr uint64) *Section {
+			for _, s := range f.Sections() {
+				if s.Mapped() && !(s.TLS() && s.ZeroInitialized()) && s.Addr <= addr && addr-s.Addr < s.Size {
+					return s
+				}
+			}
+			return nil
+		}
+		for _, s := range f.Sections() {
+			for _, addr := range []uint64{s.Addr - 1, s.Addr, s.Addr + s.Size/2, s.Addr + s.Size - 1, s.Addr + s.Size} {
+				if got, want := f.ResolveAddr(addr), slowResolve(addr); got != want {
+					t.Errorf("ResolveAddr(%#x): want %v, got %v", addr, want, got)
+				}
+			}
+			if s.Mapped() && s.Size > 0 && !(s.TLS() && s.ZeroInitialized()) {
+				if got := f.ResolveAddr(s.Addr); got != s {
+					t.Errorf("ResolveAddr(%#x): want %v, got %v", s.Addr, s, got)
+				}
+			}
+		}
+
+		// Check name and kind lookups.
+		for _, s := range f.Sections() {
+			if got := f.SectionByName(s.Name); got == nil || got.Name != s.Name || got.ID > s.ID {
+				t.Errorf("SectionByName(%q): got %v", s.Name, got)
+			}
+			found := false
+			for _, s2 := range f.SectionsByKind(s.Kind) {
+				if s2.Kind != s.Kind {
+					t.Errorf("SectionsByKind(%v) returned %s of kind %v", s.Kind, s2, s2.Kind)
+				}
+				found = found || s2 == s
+			}
+			if !found {
+				t.Errorf("SectionsByKind(%v) is missing %s", s.Kind, s)
+			}
+		}
+		if got := f.SectionByName(".no-such-section"); got != nil {
+			t.Errorf("SectionByName of missing section: want nil, got %v", got)
+		}
+	})
+}
+
+// Test that we mmap the file once, and heap-allocate only the sections
+// that need it.
 func TestElfMmap(t *testing.T) {
 	// Not parallel because we use a global test hook.
 
@@ -338,9 +534,114 @@ func TestElfMmap(t *testing.T) {
 	}
 
 	// Check the counts.
-	const wantMmaped = 15
-	const wantHeap = 2 + 3 + 1 // 2 zero-length, 1 zero-length NOBITS, 3 compressed sections
+	const wantMmaped = 1   // The whole file
+	const wantHeap = 3 + 1 // 3 compressed sections, 1 zero-length NOBITS
 	if mmapCount != wantMmaped || heapCount != wantHeap {
 		t.Errorf("want %d mmaped + %d heap, got %d+%d", wantMmaped, wantHeap, mmapCount, heapCount)
 	}
 }
+
+func TestElfDataOutOfRange(t *testing.T) {
+	t.Parallel()
+	f := elfTests[0].openOrSkip(t)
+	text := f.SectionByName(".text")
+	for _, r := range [][2]uint64{
+		{text.Addr - 1, 2},
+		{text.Addr + text.Size - 1, 2},
+		{text.Addr + 1, ^uint64(0)},
+	} {
+		_, err := text.Data(r[0], r[1])
+		if _, ok := err.(*ErrOutOfRange); !ok {
+			t.Errorf("reading [%#x,+%#x): want *ErrOutOfRange, got %v", r[0], r[1], err)
+		}
+	}
+
+	main := Sym{Section: text, Value: text.Addr + 16, Size: 16}
+	if _, err := main.Data(main.Value, 17); err == nil {
+		t.Errorf("reading past end of symbol: want error, got nil")
+	}
+}
+
+func TestElfPtr(t *testing.T) {
+	t.Parallel()
+	type ptrTest struct {
+		section string
+		addr    uint64
+		sym     string // "" for NoSym
+		val     uint64
+	}
+	for path, tests := range map[string][]ptrTest{
+		"hello-gcc10.3.0-AMD64-rel.o": {
+			// R_X86_64_64 against .text.
+			{".debug_info", 0x19, ".text", 0},
+			// Not relocated. This is the high half of the DW_AT_high_pc.
+			{".debug_info", 0x21, "", 0x26},
+		},
+		"hello-gcc10.3.0-AMD64-pie": {
+			{".got", 0x3fd8, "_ITM_deregisterTMCloneTable", 0},
+			// R_X86_64_RELATIVE.
+			{".init_array", 0x3db8, "", 0x1140},
+			{".data", 0x4008, "", 0x4008},
+		},
+		"hello-gcc10.3.0-I386-dyn": {
+			// R_386_JMP_SLOT with an implicit addend.
+			{".got.plt", 0x0804c00c, "puts", 0x08049040},
+		},
+	} {
+		f := findElfTest(t, path).openOrSkip(t)
+		for _, pt := range tests {
+			s := f.SectionByName(pt.section)
+			// Read just the pointer so we also check that relocations
+			// are sliced down to the requested range.
+			ws := uint64(f.Info().Arch.Layout.WordSize())
+			data, err := s.Data(pt.addr, ws)
+			if err != nil {
+				t.Errorf("%s: reading %s: %v", path, pt.section, err)
+				continue
+			}
+			for _, rel := range data.R {
+				if rel.Addr+maxRelocSize <= pt.addr || rel.Addr >= pt.addr+ws {
+					t.Errorf("%s: reading %s at %#x: relocation at %#x is outside data", path, pt.section, pt.addr, rel.Addr)
+				}
+			}
+			sym, val := NewReader(data).Ptr()
+			if symName(f, sym) != pt.sym || val != pt.val {
+				t.Errorf("%s: %s at %#x: want %q+%#x, got %q+%#x", path, pt.section, pt.addr, pt.sym, pt.val, symName(f, sym), val)
+			}
+		}
+	}
+}
+
+// Test that sections of files that can't be mmapped are read lazily.
+func TestElfWindowed(t *testing.T) {
+	t.Parallel()
+	test := elfTests[0]
+	fp, err := os.Open(filepath.Join("testdata", test.path))
+	if err != nil {
+		t.Fatalf("error opening test file: %v", err)
+	}
+	defer fp.Close()
+	r := &countingReaderAt{r: fp}
+	f, err := Open(r)
+	if err != nil {
+		t.Fatalf("Open failed unexpectedly: %v", err)
+	}
+	defer f.Close()
+	ref := test.openOrSkip(t)
+
+	text, refText := f.SectionByName(".text"), ref.SectionByName(".text")
+	before := atomic.LoadInt64(&r.n)
+	for _, off := range []uint64{0, 0x100, text.Size / 2, text.Size - 0x20} {
+		got, err := text.Data(text.Addr+off, 0x20)
+		if err != nil {
+			t.Fatalf("reading .text+%#x: %v", off, err)
+		}
+		want, _ := refText.Data(text.Addr+off, 0x20)
+		if !bytes.Equal(got.B, want.B) {
+			t.Errorf("reading .text+%#x: data not as expected", off)
+		}
+	}
+	if n := atomic.LoadInt64(&r.n) - before; n >= int64(text.Size) {
+		t.Errorf("reading a few bytes of .text read %d bytes; section is %d bytes", n, text.Size)
+	}
+}
@@ -9,40 +9,40 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-I386-static",
 		arch: arch.I386,
 		sections: []Section{
-			{Name: ".note.gnu.build-id", ID: 0, RawID: 1, Addr: 0x8048154, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x8048178, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 2, RawID: 3, Addr: 0x8048194, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.plt", ID: 3, RawID: 4, Addr: 0x80481b4, Size: 0x70, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 4, RawID: 5, Addr: 0x8049000, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 5, RawID: 6, Addr: 0x8049030, Size: 0xe0, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 6, RawID: 7, Addr: 0x8049110, Size: 0x6a7f1, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: "__libc_freeres_fn", ID: 7, RawID: 8, Addr: 0x80b3910, Size: 0xb55, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 8, RawID: 9, Addr: 0x80b4468, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 9, RawID: 10, Addr: 0x80b5000, Size: 0x1c3d0, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 10, RawID: 11, Addr: 0x80d13d0, Size: 0x13f74, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gcc_except_table", ID: 11, RawID: 12, Addr: 0x80e5344, Size: 0xc4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".tdata", ID: 12, RawID: 13, Addr: 0x80e65e0, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".tbss", ID: 13, RawID: 14, Addr: 0x80e65f0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".init_array", ID: 14, RawID: 15, Addr: 0x80e65f0, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 15, RawID: 16, Addr: 0x80e65f8, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data.rel.ro", ID: 16, RawID: 17, Addr: 0x80e6600, Size: 0x19d4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 17, RawID: 18, Addr: 0x80e7fd4, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 18, RawID: 19, Addr: 0x80e8000, Size: 0x44, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 19, RawID: 20, Addr: 0x80e8060, Size: 0xec0, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_subfreeres", ID: 20, RawID: 21, Addr: 0x80e8f20, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_IO_vtables", ID: 21, RawID: 22, Addr: 0x80e8f60, Size: 0x3b4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_atexit", ID: 22, RawID: 23, Addr: 0x80e9314, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 23, RawID: 24, Addr: 0x80e9320, Size: 0xda4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: "__libc_freeres_ptrs", ID: 24, RawID: 25, Addr: 0x80ea0c4, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 25, RawID: 26, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 26, RawID: 27, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 27, RawID: 28, Addr: 0x0, Size: 0xb8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 28, RawID: 29, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 29, RawID: 30, Addr: 0x0, Size: 0x42, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 30, RawID: 31, Addr: 0x0, Size: 0x10c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 31, RawID: 32, Addr: 0x0, Size: 0x8b90, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x67fc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x17a, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 0, RawID: 1, Addr: 0x8048154, Size: 0x24, Offset: 0x154, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x8048178, Size: 0x1c, Offset: 0x178, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 2, RawID: 3, Addr: 0x8048194, Size: 0x20, Offset: 0x194, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.plt", ID: 3, RawID: 4, Addr: 0x80481b4, Size: 0x70, Offset: 0x1b4, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 4, RawID: 5, Addr: 0x8049000, Size: 0x24, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 5, RawID: 6, Addr: 0x8049030, Size: 0xe0, Offset: 0x1030, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 6, RawID: 7, Addr: 0x8049110, Size: 0x6a7f1, Offset: 0x1110, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: "__libc_freeres_fn", ID: 7, RawID: 8, Addr: 0x80b3910, Size: 0xb55, Offset: 0x6b910, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 8, RawID: 9, Addr: 0x80b4468, Size: 0x18, Offset: 0x6c468, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 9, RawID: 10, Addr: 0x80b5000, Size: 0x1c3d0, Offset: 0x6d000, Align: 32, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 10, RawID: 11, Addr: 0x80d13d0, Size: 0x13f74, Offset: 0x893d0, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gcc_except_table", ID: 11, RawID: 12, Addr: 0x80e5344, Size: 0xc4, Offset: 0x9d344, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".tdata", ID: 12, RawID: 13, Addr: 0x80e65e0, Size: 0x10, Offset: 0x9d5e0, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagTLS}},
+			{Name: ".tbss", ID: 13, RawID: 14, Addr: 0x80e65f0, Size: 0x20, Offset: 0x0, Align: 4, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized | sectionFlagTLS}},
+			{Name: ".init_array", ID: 14, RawID: 15, Addr: 0x80e65f0, Size: 0x8, Offset: 0x9d5f0, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 15, RawID: 16, Addr: 0x80e65f8, Size: 0x8, Offset: 0x9d5f8, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data.rel.ro", ID: 16, RawID: 17, Addr: 0x80e6600, Size: 0x19d4, Offset: 0x9d600, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 17, RawID: 18, Addr: 0x80e7fd4, Size: 0x24, Offset: 0x9efd4, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 18, RawID: 19, Addr: 0x80e8000, Size: 0x44, Offset: 0x9f000, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 19, RawID: 20, Addr: 0x80e8060, Size: 0xec0, Offset: 0x9f060, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_subfreeres", ID: 20, RawID: 21, Addr: 0x80e8f20, Size: 0x24, Offset: 0x9ff20, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_IO_vtables", ID: 21, RawID: 22, Addr: 0x80e8f60, Size: 0x3b4, Offset: 0x9ff60, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_atexit", ID: 22, RawID: 23, Addr: 0x80e9314, Size: 0x4, Offset: 0xa0314, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 23, RawID: 24, Addr: 0x80e9320, Size: 0xda4, Offset: 0x0, Align: 32, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: "__libc_freeres_ptrs", ID: 24, RawID: 25, Addr: 0x80ea0c4, Size: 0x10, Offset: 0x0, Align: 4, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 25, RawID: 26, Addr: 0x0, Size: 0x2b, Offset: 0xa0318, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 26, RawID: 27, Addr: 0x0, Size: 0x20, Offset: 0xa0343, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 27, RawID: 28, Addr: 0x0, Size: 0xb8, Offset: 0xa0363, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 28, RawID: 29, Addr: 0x0, Size: 0x62, Offset: 0xa041b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 29, RawID: 30, Addr: 0x0, Size: 0x42, Offset: 0xa047d, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 30, RawID: 31, Addr: 0x0, Size: 0x10c, Offset: 0xa04bf, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 31, RawID: 32, Addr: 0x0, Size: 0x8b90, Offset: 0xa05cc, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x67fc, Offset: 0xa915c, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x17a, Offset: 0xaf958, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 2232,
 	},
@@ -50,41 +50,41 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-I386-dyn",
 		arch: arch.I386,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x80481b4, Size: 0x13, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x80481c8, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x80481ec, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x8048208, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x8048228, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x8048248, Size: 0x50, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x8048298, Size: 0x4a, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x80482e2, Size: 0xa, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x80482ec, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x804830c, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x8048314, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x8049000, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x8049030, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x8049060, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 14, RawID: 15, Addr: 0x8049080, Size: 0x1d9, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x804925c, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x804a000, Size: 0xe, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x804a010, Size: 0x54, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x804a064, Size: 0x138, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x804bf0c, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x804bf10, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x804bf14, Size: 0xe8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 22, RawID: 23, Addr: 0x804bffc, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x804c000, Size: 0x14, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x804c014, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x804c01c, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xb8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x42, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x10c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x4a0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x24f, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15d, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x80481b4, Size: 0x13, Offset: 0x1b4, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x80481c8, Size: 0x24, Offset: 0x1c8, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x80481ec, Size: 0x1c, Offset: 0x1ec, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x8048208, Size: 0x20, Offset: 0x208, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x8048228, Size: 0x20, Offset: 0x228, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x8048248, Size: 0x50, Offset: 0x248, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x8048298, Size: 0x4a, Offset: 0x298, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x80482e2, Size: 0xa, Offset: 0x2e2, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x80482ec, Size: 0x20, Offset: 0x2ec, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x804830c, Size: 0x8, Offset: 0x30c, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x8048314, Size: 0x10, Offset: 0x314, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x8049000, Size: 0x24, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x8049030, Size: 0x30, Offset: 0x1030, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x8049060, Size: 0x20, Offset: 0x1060, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 14, RawID: 15, Addr: 0x8049080, Size: 0x1d9, Offset: 0x1080, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x804925c, Size: 0x18, Offset: 0x125c, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x804a000, Size: 0xe, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x804a010, Size: 0x54, Offset: 0x2010, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x804a064, Size: 0x138, Offset: 0x2064, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x804bf0c, Size: 0x4, Offset: 0x2f0c, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x804bf10, Size: 0x4, Offset: 0x2f10, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x804bf14, Size: 0xe8, Offset: 0x2f14, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 22, RawID: 23, Addr: 0x804bffc, Size: 0x4, Offset: 0x2ffc, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x804c000, Size: 0x14, Offset: 0x3000, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x804c014, Size: 0x8, Offset: 0x3014, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x804c01c, Size: 0x4, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x301c, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x20, Offset: 0x3047, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xb8, Offset: 0x3067, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, Offset: 0x311f, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x42, Offset: 0x3181, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x10c, Offset: 0x31c3, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x4a0, Offset: 0x32d0, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x24f, Offset: 0x3770, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15d, Offset: 0x39bf, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms:    77,
 		textData: parseHex(`f30f1efb31ed5e89e183e4f0505452e82300000081c36c2f00008d8350d2ffff508d83e0d1ffff505156c7c09691040850e8bafffffff48b1c24c36690669090f30f1efbc366906690669066906690908b1c24c3669066906690669066906690b81cc004083d1cc004087424b80000000085c0741b5589e583ec14681cc00408ffd083c410c9c38db426000000006690c38db426000000008db4260000000090b81cc004082d1cc0040889c2c1e81fc1fa0201d0d1f87420ba0000000085d274175589e583ec1050681cc00408ffd283c410c9c38d742600c38db42600000000f30f1efb803d1cc0040800751b5589e583ec08e868ffffffc6051cc0040801c9c38db42600000000c38db42600000000f30f1efbeb8af30f1efb8d4c240483e4f0ff71fc5589e55351e82800000005522e000083ec0c8d9008e0ffff5289c3e89cfeffff83c410b8000000008d65f8595b5d8d61fcc38b0424c3669066906690f30f1efb55e86b00000081c5162e000057565383ec0c89eb8b7c2428e8fffdffff8d9d10ffffff8d850cffffff29c3c1fb02742931f68db426000000008d760083ec0457ff74242cff74242cff94b50cffffff83c60183c41039f375e383c40c5b5e5f5dc38db426000000008d742600f30f1efbc38b2c24c3`),
@@ -93,34 +93,34 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-I386-dyn-stripped",
 		arch: arch.I386,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x80481b4, Size: 0x13, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x80481c8, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x80481ec, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x8048208, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x8048228, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x8048248, Size: 0x50, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x8048298, Size: 0x4a, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x80482e2, Size: 0xa, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x80482ec, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x804830c, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x8048314, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x8049000, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x8049030, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x8049060, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 14, RawID: 15, Addr: 0x8049080, Size: 0x1d9, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x804925c, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x804a000, Size: 0xe, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x804a010, Size: 0x54, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x804a064, Size: 0x138, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x804bf0c, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x804bf10, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x804bf14, Size: 0xe8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 22, RawID: 23, Addr: 0x804bffc, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x804c000, Size: 0x14, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x804c014, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x804c01c, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 27, RawID: 28, Addr: 0x0, Size: 0x10d, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x80481b4, Size: 0x13, Offset: 0x1b4, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x80481c8, Size: 0x24, Offset: 0x1c8, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x80481ec, Size: 0x1c, Offset: 0x1ec, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x8048208, Size: 0x20, Offset: 0x208, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x8048228, Size: 0x20, Offset: 0x228, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x8048248, Size: 0x50, Offset: 0x248, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x8048298, Size: 0x4a, Offset: 0x298, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x80482e2, Size: 0xa, Offset: 0x2e2, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x80482ec, Size: 0x20, Offset: 0x2ec, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x804830c, Size: 0x8, Offset: 0x30c, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x8048314, Size: 0x10, Offset: 0x314, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x8049000, Size: 0x24, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x8049030, Size: 0x30, Offset: 0x1030, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x8049060, Size: 0x20, Offset: 0x1060, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 14, RawID: 15, Addr: 0x8049080, Size: 0x1d9, Offset: 0x1080, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x804925c, Size: 0x18, Offset: 0x125c, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x804a000, Size: 0xe, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x804a010, Size: 0x54, Offset: 0x2010, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x804a064, Size: 0x138, Offset: 0x2064, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x804bf0c, Size: 0x4, Offset: 0x2f0c, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x804bf10, Size: 0x4, Offset: 0x2f10, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x804bf14, Size: 0xe8, Offset: 0x2f14, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 22, RawID: 23, Addr: 0x804bffc, Size: 0x4, Offset: 0x2ffc, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x804c000, Size: 0x14, Offset: 0x3000, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x804c014, Size: 0x8, Offset: 0x3014, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x804c01c, Size: 0x4, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x301c, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 27, RawID: 28, Addr: 0x0, Size: 0x10d, Offset: 0x3047, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 4,
 	},
@@ -128,41 +128,41 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-I386-pie",
 		arch: arch.I386,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x1b4, Size: 0x13, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x1c8, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x1ec, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x208, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x228, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x248, Size: 0x80, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x2c8, Size: 0x9b, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x364, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x374, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x3a4, Size: 0x40, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x3e4, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x1000, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x1030, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.got", ID: 13, RawID: 14, Addr: 0x1060, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 14, RawID: 15, Addr: 0x1070, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 15, RawID: 16, Addr: 0x1090, Size: 0x209, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 16, RawID: 17, Addr: 0x129c, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 17, RawID: 18, Addr: 0x2000, Size: 0xe, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 18, RawID: 19, Addr: 0x2010, Size: 0x54, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 19, RawID: 20, Addr: 0x2064, Size: 0x138, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 20, RawID: 21, Addr: 0x3ed8, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 21, RawID: 22, Addr: 0x3edc, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 22, RawID: 23, Addr: 0x3ee0, Size: 0xf8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 23, RawID: 24, Addr: 0x3fd8, Size: 0x28, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x4000, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x4008, Size: 0x4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xb8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x42, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x10c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x4d0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x2a0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x158, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x1b4, Size: 0x13, Offset: 0x1b4, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x1c8, Size: 0x24, Offset: 0x1c8, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 2, RawID: 3, Addr: 0x1ec, Size: 0x1c, Offset: 0x1ec, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x208, Size: 0x20, Offset: 0x208, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x228, Size: 0x20, Offset: 0x228, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x248, Size: 0x80, Offset: 0x248, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x2c8, Size: 0x9b, Offset: 0x2c8, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x364, Size: 0x10, Offset: 0x364, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x374, Size: 0x30, Offset: 0x374, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.dyn", ID: 9, RawID: 10, Addr: 0x3a4, Size: 0x40, Offset: 0x3a4, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rel.plt", ID: 10, RawID: 11, Addr: 0x3e4, Size: 0x10, Offset: 0x3e4, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x1000, Size: 0x24, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x1030, Size: 0x30, Offset: 0x1030, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.got", ID: 13, RawID: 14, Addr: 0x1060, Size: 0x10, Offset: 0x1060, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 14, RawID: 15, Addr: 0x1070, Size: 0x20, Offset: 0x1070, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 15, RawID: 16, Addr: 0x1090, Size: 0x209, Offset: 0x1090, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 16, RawID: 17, Addr: 0x129c, Size: 0x18, Offset: 0x129c, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 17, RawID: 18, Addr: 0x2000, Size: 0xe, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 18, RawID: 19, Addr: 0x2010, Size: 0x54, Offset: 0x2010, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 19, RawID: 20, Addr: 0x2064, Size: 0x138, Offset: 0x2064, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 20, RawID: 21, Addr: 0x3ed8, Size: 0x4, Offset: 0x2ed8, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 21, RawID: 22, Addr: 0x3edc, Size: 0x4, Offset: 0x2edc, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 22, RawID: 23, Addr: 0x3ee0, Size: 0xf8, Offset: 0x2ee0, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 23, RawID: 24, Addr: 0x3fd8, Size: 0x28, Offset: 0x2fd8, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x4000, Size: 0x8, Offset: 0x3000, Align: 4, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x4008, Size: 0x4, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x3008, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x20, Offset: 0x3033, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xb8, Offset: 0x3053, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, Offset: 0x310b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x42, Offset: 0x316d, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x10c, Offset: 0x31af, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x4d0, Offset: 0x32bc, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x2a0, Offset: 0x378c, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x158, Offset: 0x3a2c, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 83,
 	},
@@ -170,29 +170,29 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-I386-rel.o",
 		arch: arch.I386,
 		sections: []Section{
-			{Name: ".group", ID: 0, RawID: 1, Addr: 0x0, Size: 0x8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x40, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rel.text", ID: 2, RawID: 3, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".data", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{}},
-			{Name: ".bss", ID: 4, RawID: 5, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
-			{Name: ".rodata", ID: 5, RawID: 6, Addr: 0x0, Size: 0x6, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".text.__x86.get_pc_thunk.ax", ID: 6, RawID: 7, Addr: 0x0, Size: 0x4, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 7, RawID: 8, Addr: 0x0, Size: 0xb8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rel.debug_info", ID: 8, RawID: 9, Addr: 0x0, Size: 0xa0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 9, RawID: 10, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 10, RawID: 11, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rel.debug_aranges", ID: 11, RawID: 12, Addr: 0x0, Size: 0x10, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 12, RawID: 13, Addr: 0x0, Size: 0x42, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rel.debug_line", ID: 13, RawID: 14, Addr: 0x0, Size: 0x8, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 14, RawID: 15, Addr: 0x0, Size: 0x145, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".comment", ID: 15, RawID: 16, Addr: 0x0, Size: 0x2c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.GNU-stack", ID: 16, RawID: 17, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 17, RawID: 18, Addr: 0x0, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x0, Size: 0x60, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rel.eh_frame", ID: 19, RawID: 20, Addr: 0x0, Size: 0x10, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0x150, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 21, RawID: 22, Addr: 0x0, Size: 0x3f, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 22, RawID: 23, Addr: 0x0, Size: 0xe1, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".group", ID: 0, RawID: 1, Addr: 0x0, Size: 0x8, Offset: 0x34, Align: 4, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x40, Offset: 0x3c, Align: 1, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rel.text", ID: 2, RawID: 3, Addr: 0x0, Size: 0x20, Offset: 0x580, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".data", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, Offset: 0x7c, Align: 1, Kind: SectionData, SectionFlags: SectionFlags{}},
+			{Name: ".bss", ID: 4, RawID: 5, Addr: 0x0, Size: 0x0, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
+			{Name: ".rodata", ID: 5, RawID: 6, Addr: 0x0, Size: 0x6, Offset: 0x7c, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".text.__x86.get_pc_thunk.ax", ID: 6, RawID: 7, Addr: 0x0, Size: 0x4, Offset: 0x82, Align: 1, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".debug_info", ID: 7, RawID: 8, Addr: 0x0, Size: 0xb8, Offset: 0x86, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rel.debug_info", ID: 8, RawID: 9, Addr: 0x0, Size: 0xa0, Offset: 0x5a0, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 9, RawID: 10, Addr: 0x0, Size: 0x62, Offset: 0x13e, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 10, RawID: 11, Addr: 0x0, Size: 0x20, Offset: 0x1a0, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rel.debug_aranges", ID: 11, RawID: 12, Addr: 0x0, Size: 0x10, Offset: 0x640, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 12, RawID: 13, Addr: 0x0, Size: 0x42, Offset: 0x1c0, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rel.debug_line", ID: 13, RawID: 14, Addr: 0x0, Size: 0x8, Offset: 0x650, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 14, RawID: 15, Addr: 0x0, Size: 0x145, Offset: 0x202, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".comment", ID: 15, RawID: 16, Addr: 0x0, Size: 0x2c, Offset: 0x347, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.GNU-stack", ID: 16, RawID: 17, Addr: 0x0, Size: 0x0, Offset: 0x373, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 17, RawID: 18, Addr: 0x0, Size: 0x1c, Offset: 0x374, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x0, Size: 0x60, Offset: 0x390, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rel.eh_frame", ID: 19, RawID: 20, Addr: 0x0, Size: 0x10, Offset: 0x658, Align: 4, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0x150, Offset: 0x3f0, Align: 4, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 21, RawID: 22, Addr: 0x0, Size: 0x3f, Offset: 0x540, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 22, RawID: 23, Addr: 0x0, Size: 0xe1, Offset: 0x668, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms:    20,
 		textData: parseHex(`f30f1efb8d4c240483e4f0ff71fc5589e55351e8fcffffff050100000083ec0c8d90000000005289c3e8fcffffff83c410b8000000008d65f8595b5d8d61fcc3`),
@@ -201,42 +201,42 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-static",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".note.gnu.property", ID: 0, RawID: 1, Addr: 0x400270, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x400290, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 2, RawID: 3, Addr: 0x4002b4, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.plt", ID: 3, RawID: 4, Addr: 0x4002d8, Size: 0x240, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 4, RawID: 5, Addr: 0x401000, Size: 0x1b, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 5, RawID: 6, Addr: 0x401020, Size: 0x180, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 6, RawID: 7, Addr: 0x4011a0, Size: 0x8a470, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: "__libc_freeres_fn", ID: 7, RawID: 8, Addr: 0x48b610, Size: 0x14f0, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 8, RawID: 9, Addr: 0x48cb00, Size: 0xd, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 9, RawID: 10, Addr: 0x48d000, Size: 0x1d06c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".stapsdt.base", ID: 10, RawID: 11, Addr: 0x4aa06c, Size: 0x1, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 11, RawID: 12, Addr: 0x4aa070, Size: 0xac44, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gcc_except_table", ID: 12, RawID: 13, Addr: 0x4b4cb4, Size: 0xc4, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".tdata", ID: 13, RawID: 14, Addr: 0x4b5fe0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".tbss", ID: 14, RawID: 15, Addr: 0x4b6000, Size: 0x40, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".init_array", ID: 15, RawID: 16, Addr: 0x4b6000, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 16, RawID: 17, Addr: 0x4b6010, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data.rel.ro", ID: 17, RawID: 18, Addr: 0x4b6020, Size: 0x2ed4, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 18, RawID: 19, Addr: 0x4b8ef8, Size: 0xf0, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 19, RawID: 20, Addr: 0x4b9000, Size: 0xd8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 20, RawID: 21, Addr: 0x4b90e0, Size: 0x1a70, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_subfreeres", ID: 21, RawID: 22, Addr: 0x4bab50, Size: 0x48, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_IO_vtables", ID: 22, RawID: 23, Addr: 0x4baba0, Size: 0x768, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: "__libc_atexit", ID: 23, RawID: 24, Addr: 0x4bb308, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 24, RawID: 25, Addr: 0x4bb320, Size: 0x1800, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: "__libc_freeres_ptrs", ID: 25, RawID: 26, Addr: 0x4bcb20, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.stapsdt", ID: 27, RawID: 28, Addr: 0x0, Size: 0x1390, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 28, RawID: 29, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 29, RawID: 30, Addr: 0x0, Size: 0xba, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 30, RawID: 31, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 31, RawID: 32, Addr: 0x0, Size: 0x45, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 32, RawID: 33, Addr: 0x0, Size: 0x104, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0xb2e0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x685f, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 35, RawID: 36, Addr: 0x0, Size: 0x197, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 0, RawID: 1, Addr: 0x400270, Size: 0x20, Offset: 0x270, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 1, RawID: 2, Addr: 0x400290, Size: 0x24, Offset: 0x290, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 2, RawID: 3, Addr: 0x4002b4, Size: 0x20, Offset: 0x2b4, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.plt", ID: 3, RawID: 4, Addr: 0x4002d8, Size: 0x240, Offset: 0x2d8, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 4, RawID: 5, Addr: 0x401000, Size: 0x1b, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 5, RawID: 6, Addr: 0x401020, Size: 0x180, Offset: 0x1020, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 6, RawID: 7, Addr: 0x4011a0, Size: 0x8a470, Offset: 0x11a0, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: "__libc_freeres_fn", ID: 7, RawID: 8, Addr: 0x48b610, Size: 0x14f0, Offset: 0x8b610, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 8, RawID: 9, Addr: 0x48cb00, Size: 0xd, Offset: 0x8cb00, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 9, RawID: 10, Addr: 0x48d000, Size: 0x1d06c, Offset: 0x8d000, Align: 32, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".stapsdt.base", ID: 10, RawID: 11, Addr: 0x4aa06c, Size: 0x1, Offset: 0xaa06c, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 11, RawID: 12, Addr: 0x4aa070, Size: 0xac44, Offset: 0xaa070, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gcc_except_table", ID: 12, RawID: 13, Addr: 0x4b4cb4, Size: 0xc4, Offset: 0xb4cb4, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".tdata", ID: 13, RawID: 14, Addr: 0x4b5fe0, Size: 0x20, Offset: 0xb4fe0, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagTLS}},
+			{Name: ".tbss", ID: 14, RawID: 15, Addr: 0x4b6000, Size: 0x40, Offset: 0x0, Align: 8, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized | sectionFlagTLS}},
+			{Name: ".init_array", ID: 15, RawID: 16, Addr: 0x4b6000, Size: 0x10, Offset: 0xb5000, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 16, RawID: 17, Addr: 0x4b6010, Size: 0x10, Offset: 0xb5010, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data.rel.ro", ID: 17, RawID: 18, Addr: 0x4b6020, Size: 0x2ed4, Offset: 0xb5020, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 18, RawID: 19, Addr: 0x4b8ef8, Size: 0xf0, Offset: 0xb7ef8, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 19, RawID: 20, Addr: 0x4b9000, Size: 0xd8, Offset: 0xb8000, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 20, RawID: 21, Addr: 0x4b90e0, Size: 0x1a70, Offset: 0xb80e0, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_subfreeres", ID: 21, RawID: 22, Addr: 0x4bab50, Size: 0x48, Offset: 0xb9b50, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_IO_vtables", ID: 22, RawID: 23, Addr: 0x4baba0, Size: 0x768, Offset: 0xb9ba0, Align: 32, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: "__libc_atexit", ID: 23, RawID: 24, Addr: 0x4bb308, Size: 0x8, Offset: 0xba308, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 24, RawID: 25, Addr: 0x4bb320, Size: 0x1800, Offset: 0x0, Align: 32, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: "__libc_freeres_ptrs", ID: 25, RawID: 26, Addr: 0x4bcb20, Size: 0x20, Offset: 0x0, Align: 8, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0xba310, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.stapsdt", ID: 27, RawID: 28, Addr: 0x0, Size: 0x1390, Offset: 0xba33c, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 28, RawID: 29, Addr: 0x0, Size: 0x30, Offset: 0xbb6cc, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 29, RawID: 30, Addr: 0x0, Size: 0xba, Offset: 0xbb6fc, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 30, RawID: 31, Addr: 0x0, Size: 0x62, Offset: 0xbb7b6, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 31, RawID: 32, Addr: 0x0, Size: 0x45, Offset: 0xbb818, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 32, RawID: 33, Addr: 0x0, Size: 0x104, Offset: 0xbb85d, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0xb2e0, Offset: 0xbb968, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x685f, Offset: 0xc6c48, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 35, RawID: 36, Addr: 0x0, Size: 0x197, Offset: 0xcd4a7, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 1907,
 	},
@@ -244,41 +244,41 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-dyn",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x400318, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x400338, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x400358, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x40037c, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x4003a0, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x4003c0, Size: 0x60, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x400420, Size: 0x3d, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x40045e, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x400468, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x400488, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x4004b8, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x401000, Size: 0x1b, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x401020, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x401040, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 14, RawID: 15, Addr: 0x401050, Size: 0x185, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x4011d8, Size: 0xd, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x402000, Size: 0xa, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x40200c, Size: 0x44, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x402050, Size: 0x100, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x403e10, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x403e18, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x403e20, Size: 0x1d0, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 22, RawID: 23, Addr: 0x403ff0, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x404000, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x404020, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x404030, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xba, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x45, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x104, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x690, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x212, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15f, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x400318, Size: 0x1c, Offset: 0x318, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x400338, Size: 0x20, Offset: 0x338, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x400358, Size: 0x24, Offset: 0x358, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x40037c, Size: 0x20, Offset: 0x37c, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x4003a0, Size: 0x1c, Offset: 0x3a0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x4003c0, Size: 0x60, Offset: 0x3c0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x400420, Size: 0x3d, Offset: 0x420, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x40045e, Size: 0x8, Offset: 0x45e, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x400468, Size: 0x20, Offset: 0x468, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x400488, Size: 0x30, Offset: 0x488, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x4004b8, Size: 0x18, Offset: 0x4b8, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x401000, Size: 0x1b, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x401020, Size: 0x20, Offset: 0x1020, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x401040, Size: 0x10, Offset: 0x1040, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 14, RawID: 15, Addr: 0x401050, Size: 0x185, Offset: 0x1050, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x4011d8, Size: 0xd, Offset: 0x11d8, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x402000, Size: 0xa, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x40200c, Size: 0x44, Offset: 0x200c, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x402050, Size: 0x100, Offset: 0x2050, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x403e10, Size: 0x8, Offset: 0x2e10, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x403e18, Size: 0x8, Offset: 0x2e18, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x403e20, Size: 0x1d0, Offset: 0x2e20, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 22, RawID: 23, Addr: 0x403ff0, Size: 0x10, Offset: 0x2ff0, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x404000, Size: 0x20, Offset: 0x3000, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x404020, Size: 0x10, Offset: 0x3020, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x404030, Size: 0x8, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x3030, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x30, Offset: 0x305b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xba, Offset: 0x308b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, Offset: 0x3145, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x45, Offset: 0x31a7, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x104, Offset: 0x31ec, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x690, Offset: 0x32f0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x212, Offset: 0x3980, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15f, Offset: 0x3b92, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms:    72,
 		textData: parseHex(`f30f1efa31ed4989d15e4889e24883e4f0505449c7c0d011400048c7c16011400048c7c736114000ff15722f0000f490f30f1efac3662e0f1f84000000000090b830404000483d304040007413b8000000004885c07409bf30404000ffe06690c366662e0f1f8400000000000f1f4000be304040004881ee304040004889f048c1ee3f48c1f8034801c648d1fe7411b8000000004885c07407bf30404000ffe0c366662e0f1f8400000000000f1f4000f30f1efa803d252f0000007513554889e5e87affffffc605132f0000015dc390c366662e0f1f8400000000000f1f4000f30f1efaeb8af30f1efa554889e54883ec10897dfc488975f0488d3db40e0000e8ebfeffffb800000000c9c30f1f4000f30f1efa41574c8d3da32c000041564989d641554989f541544189fc55488d2d942c0000534c29fd4883ec08e86ffeffff48c1fd03741f31db0f1f80000000004c89f24c89ee4489e741ff14df4883c3014839dd75ea4883c4085b5d415c415d415e415fc366662e0f1f840000000000f30f1efac3`),
@@ -287,34 +287,34 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-dyn-stripped",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x400318, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x400338, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x400358, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x40037c, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x4003a0, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x4003c0, Size: 0x60, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x400420, Size: 0x3d, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x40045e, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x400468, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x400488, Size: 0x30, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x4004b8, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x401000, Size: 0x1b, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x401020, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x401040, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 14, RawID: 15, Addr: 0x401050, Size: 0x185, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x4011d8, Size: 0xd, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x402000, Size: 0xa, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x40200c, Size: 0x44, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x402050, Size: 0x100, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x403e10, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x403e18, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x403e20, Size: 0x1d0, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 22, RawID: 23, Addr: 0x403ff0, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x404000, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x404020, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x404030, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 27, RawID: 28, Addr: 0x0, Size: 0x10f, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x400318, Size: 0x1c, Offset: 0x318, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x400338, Size: 0x20, Offset: 0x338, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x400358, Size: 0x24, Offset: 0x358, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x40037c, Size: 0x20, Offset: 0x37c, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x4003a0, Size: 0x1c, Offset: 0x3a0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x4003c0, Size: 0x60, Offset: 0x3c0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x400420, Size: 0x3d, Offset: 0x420, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x40045e, Size: 0x8, Offset: 0x45e, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x400468, Size: 0x20, Offset: 0x468, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x400488, Size: 0x30, Offset: 0x488, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x4004b8, Size: 0x18, Offset: 0x4b8, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x401000, Size: 0x1b, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x401020, Size: 0x20, Offset: 0x1020, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 13, RawID: 14, Addr: 0x401040, Size: 0x10, Offset: 0x1040, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 14, RawID: 15, Addr: 0x401050, Size: 0x185, Offset: 0x1050, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 15, RawID: 16, Addr: 0x4011d8, Size: 0xd, Offset: 0x11d8, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 16, RawID: 17, Addr: 0x402000, Size: 0xa, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 17, RawID: 18, Addr: 0x40200c, Size: 0x44, Offset: 0x200c, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 18, RawID: 19, Addr: 0x402050, Size: 0x100, Offset: 0x2050, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 19, RawID: 20, Addr: 0x403e10, Size: 0x8, Offset: 0x2e10, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 20, RawID: 21, Addr: 0x403e18, Size: 0x8, Offset: 0x2e18, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 21, RawID: 22, Addr: 0x403e20, Size: 0x1d0, Offset: 0x2e20, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 22, RawID: 23, Addr: 0x403ff0, Size: 0x10, Offset: 0x2ff0, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got.plt", ID: 23, RawID: 24, Addr: 0x404000, Size: 0x20, Offset: 0x3000, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x404020, Size: 0x10, Offset: 0x3020, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x404030, Size: 0x8, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x3030, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 27, RawID: 28, Addr: 0x0, Size: 0x10f, Offset: 0x305b, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 3,
 	},
@@ -322,41 +322,41 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-pie",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x318, Size: 0x1c, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x338, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x358, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x37c, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x3a0, Size: 0x24, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x3c8, Size: 0xa8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x470, Size: 0x82, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x4f2, Size: 0xe, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x500, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x520, Size: 0xc0, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x5e0, Size: 0x18, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init", ID: 11, RawID: 12, Addr: 0x1000, Size: 0x1b, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x1020, Size: 0x20, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.got", ID: 13, RawID: 14, Addr: 0x1040, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".plt.sec", ID: 14, RawID: 15, Addr: 0x1050, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".text", ID: 15, RawID: 16, Addr: 0x1060, Size: 0x185, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".fini", ID: 16, RawID: 17, Addr: 0x11e8, Size: 0xd, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".rodata", ID: 17, RawID: 18, Addr: 0x2000, Size: 0xa, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame_hdr", ID: 18, RawID: 19, Addr: 0x200c, Size: 0x44, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 19, RawID: 20, Addr: 0x2050, Size: 0x108, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
-			{Name: ".init_array", ID: 20, RawID: 21, Addr: 0x3db8, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".fini_array", ID: 21, RawID: 22, Addr: 0x3dc0, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".dynamic", ID: 22, RawID: 23, Addr: 0x3dc8, Size: 0x1f0, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".got", ID: 23, RawID: 24, Addr: 0x3fb8, Size: 0x48, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".data", ID: 24, RawID: 25, Addr: 0x4000, Size: 0x10, SectionFlags: SectionFlags{sectionFlagMapped}},
-			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x4010, Size: 0x8, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
-			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xba, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x45, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x104, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x6c0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x24d, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15a, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".interp", ID: 0, RawID: 1, Addr: 0x318, Size: 0x1c, Offset: 0x318, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 1, RawID: 2, Addr: 0x338, Size: 0x20, Offset: 0x338, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.gnu.build-id", ID: 2, RawID: 3, Addr: 0x358, Size: 0x24, Offset: 0x358, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".note.ABI-tag", ID: 3, RawID: 4, Addr: 0x37c, Size: 0x20, Offset: 0x37c, Align: 4, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.hash", ID: 4, RawID: 5, Addr: 0x3a0, Size: 0x24, Offset: 0x3a0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynsym", ID: 5, RawID: 6, Addr: 0x3c8, Size: 0xa8, Offset: 0x3c8, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".dynstr", ID: 6, RawID: 7, Addr: 0x470, Size: 0x82, Offset: 0x470, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version", ID: 7, RawID: 8, Addr: 0x4f2, Size: 0xe, Offset: 0x4f2, Align: 2, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".gnu.version_r", ID: 8, RawID: 9, Addr: 0x500, Size: 0x20, Offset: 0x500, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.dyn", ID: 9, RawID: 10, Addr: 0x520, Size: 0xc0, Offset: 0x520, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".rela.plt", ID: 10, RawID: 11, Addr: 0x5e0, Size: 0x18, Offset: 0x5e0, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init", ID: 11, RawID: 12, Addr: 0x1000, Size: 0x1b, Offset: 0x1000, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt", ID: 12, RawID: 13, Addr: 0x1020, Size: 0x20, Offset: 0x1020, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.got", ID: 13, RawID: 14, Addr: 0x1040, Size: 0x10, Offset: 0x1040, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".plt.sec", ID: 14, RawID: 15, Addr: 0x1050, Size: 0x10, Offset: 0x1050, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".text", ID: 15, RawID: 16, Addr: 0x1060, Size: 0x185, Offset: 0x1060, Align: 16, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".fini", ID: 16, RawID: 17, Addr: 0x11e8, Size: 0xd, Offset: 0x11e8, Align: 4, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rodata", ID: 17, RawID: 18, Addr: 0x2000, Size: 0xa, Offset: 0x2000, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame_hdr", ID: 18, RawID: 19, Addr: 0x200c, Size: 0x44, Offset: 0x200c, Align: 4, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 19, RawID: 20, Addr: 0x2050, Size: 0x108, Offset: 0x2050, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagReadOnly}},
+			{Name: ".init_array", ID: 20, RawID: 21, Addr: 0x3db8, Size: 0x8, Offset: 0x2db8, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".fini_array", ID: 21, RawID: 22, Addr: 0x3dc0, Size: 0x8, Offset: 0x2dc0, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".dynamic", ID: 22, RawID: 23, Addr: 0x3dc8, Size: 0x1f0, Offset: 0x2dc8, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".got", ID: 23, RawID: 24, Addr: 0x3fb8, Size: 0x48, Offset: 0x2fb8, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".data", ID: 24, RawID: 25, Addr: 0x4000, Size: 0x10, Offset: 0x3000, Align: 8, Kind: SectionData, SectionFlags: SectionFlags{sectionFlagMapped}},
+			{Name: ".bss", ID: 25, RawID: 26, Addr: 0x4010, Size: 0x8, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagMapped | sectionFlagZeroInitialized}},
+			{Name: ".comment", ID: 26, RawID: 27, Addr: 0x0, Size: 0x2b, Offset: 0x3010, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 27, RawID: 28, Addr: 0x0, Size: 0x30, Offset: 0x303b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 28, RawID: 29, Addr: 0x0, Size: 0xba, Offset: 0x306b, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 29, RawID: 30, Addr: 0x0, Size: 0x62, Offset: 0x3125, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 30, RawID: 31, Addr: 0x0, Size: 0x45, Offset: 0x3187, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 31, RawID: 32, Addr: 0x0, Size: 0x104, Offset: 0x31cc, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 32, RawID: 33, Addr: 0x0, Size: 0x6c0, Offset: 0x32d0, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 33, RawID: 34, Addr: 0x0, Size: 0x24d, Offset: 0x3990, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 34, RawID: 35, Addr: 0x0, Size: 0x15a, Offset: 0x3bdd, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms: 77,
 	},
@@ -364,27 +364,27 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-rel.o",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".text", ID: 0, RawID: 1, Addr: 0x0, Size: 0x26, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".data", ID: 2, RawID: 3, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{}},
-			{Name: ".bss", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
-			{Name: ".rodata", ID: 4, RawID: 5, Addr: 0x0, Size: 0x6, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 5, RawID: 6, Addr: 0x0, Size: 0xba, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_info", ID: 6, RawID: 7, Addr: 0x0, Size: 0x1b0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 7, RawID: 8, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 8, RawID: 9, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_aranges", ID: 9, RawID: 10, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 10, RawID: 11, Addr: 0x0, Size: 0x45, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_line", ID: 11, RawID: 12, Addr: 0x0, Size: 0x18, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 12, RawID: 13, Addr: 0x0, Size: 0x122, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".comment", ID: 13, RawID: 14, Addr: 0x0, Size: 0x2c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.GNU-stack", ID: 14, RawID: 15, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 15, RawID: 16, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 16, RawID: 17, Addr: 0x0, Size: 0x38, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.eh_frame", ID: 17, RawID: 18, Addr: 0x0, Size: 0x18, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 18, RawID: 19, Addr: 0x0, Size: 0x1b0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 19, RawID: 20, Addr: 0x0, Size: 0x29, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0xc3, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".text", ID: 0, RawID: 1, Addr: 0x0, Size: 0x26, Offset: 0x40, Align: 1, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rela.text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x30, Offset: 0x588, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".data", ID: 2, RawID: 3, Addr: 0x0, Size: 0x0, Offset: 0x66, Align: 1, Kind: SectionData, SectionFlags: SectionFlags{}},
+			{Name: ".bss", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
+			{Name: ".rodata", ID: 4, RawID: 5, Addr: 0x0, Size: 0x6, Offset: 0x66, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 5, RawID: 6, Addr: 0x0, Size: 0xba, Offset: 0x6c, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_info", ID: 6, RawID: 7, Addr: 0x0, Size: 0x1b0, Offset: 0x5b8, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 7, RawID: 8, Addr: 0x0, Size: 0x62, Offset: 0x126, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 8, RawID: 9, Addr: 0x0, Size: 0x30, Offset: 0x188, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_aranges", ID: 9, RawID: 10, Addr: 0x0, Size: 0x30, Offset: 0x768, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 10, RawID: 11, Addr: 0x0, Size: 0x45, Offset: 0x1b8, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_line", ID: 11, RawID: 12, Addr: 0x0, Size: 0x18, Offset: 0x798, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 12, RawID: 13, Addr: 0x0, Size: 0x122, Offset: 0x1fd, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".comment", ID: 13, RawID: 14, Addr: 0x0, Size: 0x2c, Offset: 0x31f, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.GNU-stack", ID: 14, RawID: 15, Addr: 0x0, Size: 0x0, Offset: 0x34b, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 15, RawID: 16, Addr: 0x0, Size: 0x20, Offset: 0x350, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 16, RawID: 17, Addr: 0x0, Size: 0x38, Offset: 0x370, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.eh_frame", ID: 17, RawID: 18, Addr: 0x0, Size: 0x18, Offset: 0x7b0, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 18, RawID: 19, Addr: 0x0, Size: 0x1b0, Offset: 0x3a8, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 19, RawID: 20, Addr: 0x0, Size: 0x29, Offset: 0x558, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0xc3, Offset: 0x7c8, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms:    17,
 		textData: parseHex(`f30f1efa554889e54883ec10897dfc488975f0488d3d00000000e800000000b800000000c9c3`),
@@ -393,27 +393,27 @@ var elfTests = []*elfTest{
 		path: "hello-gcc10.3.0-AMD64-rel-gz.o",
 		arch: arch.AMD64,
 		sections: []Section{
-			{Name: ".text", ID: 0, RawID: 1, Addr: 0x0, Size: 0x26, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".data", ID: 2, RawID: 3, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{}},
-			{Name: ".bss", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
-			{Name: ".rodata", ID: 4, RawID: 5, Addr: 0x0, Size: 0x6, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_info", ID: 5, RawID: 6, Addr: 0x0, Size: 0xba, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_info", ID: 6, RawID: 7, Addr: 0x0, Size: 0x1b0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_abbrev", ID: 7, RawID: 8, Addr: 0x0, Size: 0x62, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_aranges", ID: 8, RawID: 9, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_aranges", ID: 9, RawID: 10, Addr: 0x0, Size: 0x30, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_line", ID: 10, RawID: 11, Addr: 0x0, Size: 0x45, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.debug_line", ID: 11, RawID: 12, Addr: 0x0, Size: 0x18, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".debug_str", ID: 12, RawID: 13, Addr: 0x0, Size: 0x12b, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".comment", ID: 13, RawID: 14, Addr: 0x0, Size: 0x2c, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.GNU-stack", ID: 14, RawID: 15, Addr: 0x0, Size: 0x0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".note.gnu.property", ID: 15, RawID: 16, Addr: 0x0, Size: 0x20, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".eh_frame", ID: 16, RawID: 17, Addr: 0x0, Size: 0x38, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".rela.eh_frame", ID: 17, RawID: 18, Addr: 0x0, Size: 0x18, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".symtab", ID: 18, RawID: 19, Addr: 0x0, Size: 0x1b0, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".strtab", ID: 19, RawID: 20, Addr: 0x0, Size: 0x29, SectionFlags: SectionFlags{sectionFlagReadOnly}},
-			{Name: ".shstrtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0xc3, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".text", ID: 0, RawID: 1, Addr: 0x0, Size: 0x26, Offset: 0x40, Align: 1, Kind: SectionText, SectionFlags: SectionFlags{sectionFlagReadOnly | sectionFlagExecutable}},
+			{Name: ".rela.text", ID: 1, RawID: 2, Addr: 0x0, Size: 0x30, Offset: 0x500, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".data", ID: 2, RawID: 3, Addr: 0x0, Size: 0x0, Offset: 0x66, Align: 1, Kind: SectionData, SectionFlags: SectionFlags{}},
+			{Name: ".bss", ID: 3, RawID: 4, Addr: 0x0, Size: 0x0, Offset: 0x0, Align: 1, Kind: SectionBSS, SectionFlags: SectionFlags{sectionFlagZeroInitialized}},
+			{Name: ".rodata", ID: 4, RawID: 5, Addr: 0x0, Size: 0x6, Offset: 0x66, Align: 1, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_info", ID: 5, RawID: 6, Addr: 0x0, Size: 0xba, Offset: 0x70, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_info", ID: 6, RawID: 7, Addr: 0x0, Size: 0x1b0, Offset: 0x530, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_abbrev", ID: 7, RawID: 8, Addr: 0x0, Size: 0x62, Offset: 0xe5, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_aranges", ID: 8, RawID: 9, Addr: 0x0, Size: 0x30, Offset: 0x148, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_aranges", ID: 9, RawID: 10, Addr: 0x0, Size: 0x30, Offset: 0x6e0, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_line", ID: 10, RawID: 11, Addr: 0x0, Size: 0x45, Offset: 0x177, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.debug_line", ID: 11, RawID: 12, Addr: 0x0, Size: 0x18, Offset: 0x710, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".debug_str", ID: 12, RawID: 13, Addr: 0x0, Size: 0x12b, Offset: 0x1c0, Align: 1, Kind: SectionDebug, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".comment", ID: 13, RawID: 14, Addr: 0x0, Size: 0x2c, Offset: 0x29a, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.GNU-stack", ID: 14, RawID: 15, Addr: 0x0, Size: 0x0, Offset: 0x2c6, Align: 1, Kind: SectionOther, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".note.gnu.property", ID: 15, RawID: 16, Addr: 0x0, Size: 0x20, Offset: 0x2c8, Align: 8, Kind: SectionNote, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".eh_frame", ID: 16, RawID: 17, Addr: 0x0, Size: 0x38, Offset: 0x2e8, Align: 8, Kind: SectionROData, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".rela.eh_frame", ID: 17, RawID: 18, Addr: 0x0, Size: 0x18, Offset: 0x728, Align: 8, Kind: SectionReloc, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".symtab", ID: 18, RawID: 19, Addr: 0x0, Size: 0x1b0, Offset: 0x320, Align: 8, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".strtab", ID: 19, RawID: 20, Addr: 0x0, Size: 0x29, Offset: 0x4d0, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
+			{Name: ".shstrtab", ID: 20, RawID: 21, Addr: 0x0, Size: 0xc3, Offset: 0x740, Align: 1, Kind: SectionSymTab, SectionFlags: SectionFlags{sectionFlagReadOnly}},
 		},
 		nSyms:         17,
 		textData:      parseHex(`f30f1efa554889e54883ec10897dfc488975f0488d3d00000000e800000000b800000000c9c3`),
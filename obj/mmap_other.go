@@ -0,0 +1,27 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package obj
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoMmap = errors.New("mmap not supported on this platform")
+
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errNoMmap
+}
+
+func mmapZero(size int) ([]byte, error) {
+	return nil, errNoMmap
+}
+
+func mmapRetire(b []byte) error {
+	return errNoMmap
+}
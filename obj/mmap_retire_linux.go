@@ -0,0 +1,31 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && (386 || amd64 || arm || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64)
+// +build linux
+// +build 386 amd64 arm arm64 loong64 mips64 mips64le ppc64 ppc64le riscv64
+
+package obj
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// mmapRetire replaces the mapping b with read-only zero pages at the same
+// address. This drops the reference to the mapped file while leaving b
+// safe to read.
+func mmapRetire(b []byte) error {
+	addr := uintptr(unsafe.Pointer(&b[0]))
+	const flags = syscall.MAP_PRIVATE | syscall.MAP_ANON | syscall.MAP_FIXED | syscall.MAP_NORESERVE
+	fd := -1
+	r, _, errno := syscall.Syscall6(sysMmap, addr, uintptr(cap(b)), syscall.PROT_READ, flags, uintptr(fd), 0)
+	if errno != 0 {
+		return errno
+	}
+	if r != addr {
+		panic("MAP_FIXED mmap returned wrong address")
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (darwin || dragonfly || freebsd || linux || netbsd || openbsd) && !(linux && (386 || amd64 || arm || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64))
+// +build darwin dragonfly freebsd linux netbsd openbsd
+// +build !linux !386,!amd64,!arm,!arm64,!loong64,!mips64,!mips64le,!ppc64,!ppc64le,!riscv64
+
+package obj
+
+import "errors"
+
+// mmapRetire would replace the mapping b with zero pages, but this
+// isn't supported on this platform, so b simply remains mapped.
+func mmapRetire(b []byte) error {
+	return errors.New("retiring mappings not supported on this platform")
+}
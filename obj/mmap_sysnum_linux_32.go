@@ -0,0 +1,15 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && (386 || arm)
+// +build linux
+// +build 386 arm
+
+package obj
+
+import "syscall"
+
+// On 32-bit Linux, SYS_MMAP is the old calling convention that takes its
+// arguments in memory.
+const sysMmap = syscall.SYS_MMAP2
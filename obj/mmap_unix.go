@@ -0,0 +1,25 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package obj
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the first size bytes of f read-only.
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// mmapZero returns a read-only mapping of size zero bytes. The mapping
+// may be longer than size, rounded up to a whole number of pages.
+func mmapZero(size int) ([]byte, error) {
+	pageSize := os.Getpagesize()
+	return syscall.Mmap(-1, 0, int(roundUp2(uint64(size), uint64(pageSize))), syscall.PROT_READ, syscall.MAP_SHARED|syscall.MAP_ANON)
+}
@@ -8,8 +8,10 @@ package obj
 
 import (
 	"debug/dwarf"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/aclements/go-obj/arch"
 )
@@ -17,22 +19,44 @@ import (
 // TODO: Raw file header bytes? Generic metadata representation for headers?
 
 // Open attempts to open r as a known object file format.
+//
+// If r is a Source, the returned File reads from it directly, and
+// closing the File closes r. Otherwise, if r is an *os.File, Open
+// memory-maps it when possible, and falls back to reading r through a
+// cache. Use NewBytesSource to open a file that's already in memory.
 func Open(r io.ReaderAt) (File, error) {
-	if isElf, f, err := openElf(r); isElf {
+	src := openSource(r)
+	f, err := openSourceFile(src)
+	if err != nil && src != r {
+		src.Close()
+	}
+	return f, err
+}
+
+func openSourceFile(src Source) (File, error) {
+	if isElf, f, err := openElf(src); isElf {
 		return f, err
 	}
-	// if isPE, f, err := openPE(r); isPE {
+	// if isPE, f, err := openPE(src); isPE {
 	// 	return f, err
 	// }
 	return nil, fmt.Errorf("unrecognized object file format")
 }
 
+// ErrClosed is returned when reading data from a File that has been
+// closed.
+var ErrClosed = errors.New("object file is closed")
+
 // A File represents an object file.
 type File interface {
 	// Close closes this object file, releasing any OS resources used by it.
 	//
-	// It's possible that referencing a Data object returned from this File
-	// after closing the File will panic.
+	// Data returned from this File remain safe to access after Close,
+	// but their contents may read as zeros. To keep a Data's contents
+	// valid after Close, call Data.Retain before closing the File. In
+	// that case, resources are released once all retained Data are
+	// released. After Close, reading new data from the File returns
+	// ErrClosed. Calling Close more than once has no effect.
 	Close()
 
 	// Info returns metadata about the whole object file.
@@ -68,12 +92,29 @@ type File interface {
 	// return nil and the error.
 	sectionData(s *Section, addr, size uint64, d *Data) (*Data, error)
 
+	// SectionByName returns the first section named name, or nil if
+	// there is no such section. Some object files have more than one
+	// section with the same name; use Sections to find all of them.
+	SectionByName(name string) *Section
+
+	// SectionsByKind returns the sections of the given kind, in
+	// SectionID order.
+	SectionsByKind(kind SectionKind) []*Section
+
 	// ResolveAddr finds the Section containing the given address in the
 	// mapped address space. It returns nil if addr is not in a mapped
 	// section. Not all sections are mapped, and some types of object
 	// files don't have any mapped address space at all (for example,
 	// ELF relocatable objects).
 	//
+	// If mapped sections overlap, addr resolves to the overlapping
+	// section with the lowest SectionID. Zero-initialized TLS sections
+	// are not considered part of the mapped address space, since they
+	// occupy no memory at their addresses.
+	//
+	// To resolve addresses in other sets of sections, such as the
+	// non-mapped sections of some object files, use an AddrIndex.
+	//
 	// TODO: Reconsider this API. Eventually it would be nice to be able
 	// to handle addresses from relocated PIE images and core files. Do
 	// those just implement the File interface and present the
@@ -92,6 +133,16 @@ type File interface {
 	// If an object file has more than one symbol table, they will be
 	// concatenated. As a result, the "same" symbol may appear multiple times.
 	NumSyms() SymID
+
+	// DynamicSymByName returns the defined dynamic symbol named name.
+	// If there are several versions of the symbol, it returns the
+	// default version. It returns NoSym, false if there's no such
+	// symbol.
+	//
+	// This uses the object file's symbol hash table if possible, so it
+	// is much faster than searching or indexing all symbols. For ELF
+	// files, this works even if the section headers are stripped.
+	DynamicSymByName(name string) (SymID, bool)
 }
 
 // AsDebugDwarf is implemented by File types that can return their
@@ -151,6 +202,19 @@ type Section struct {
 	// or the section on disk may be compressed.
 	Size uint64
 
+	// Offset is the offset of this section's contents in the object
+	// file, or 0 if the section has no contents in the file (for
+	// example, because it is zero-initialized). For a compressed
+	// section, this is the offset of the compressed contents.
+	Offset uint64
+
+	// Align is the required alignment of this section's address in
+	// bytes. A value of 0 or 1 means there's no alignment constraint.
+	Align uint64
+
+	// Kind gives the general kind of this section.
+	Kind SectionKind
+
 	// SectionFlags stores flags for this section. This field is
 	// embedded so Section inherits the methods of SectionFlags.
 	SectionFlags
@@ -165,8 +229,8 @@ func (s *Section) String() string {
 }
 
 // Data reads size bytes of data from this section, starting at the
-// given address. It panics if the requested byte range is out of range
-// for the section.
+// given address. If the requested byte range is out of range for the
+// section, it returns an *ErrOutOfRange error.
 func (s *Section) Data(addr, size uint64) (*Data, error) {
 	// This approach allows the allocation of Data to be inlined into
 	// the caller, where it can often be stack-allocated.
@@ -179,7 +243,57 @@ func (s *Section) Bounds() (addr, size uint64) {
 	return s.Addr, s.Size
 }
 
-// SectionFlags is a set of symbol flags.
+// SectionKind indicates the general kind of a section. As with
+// SymKind, the mapping from different object formats to these kinds is
+// fuzzy, so different versions of the obj package may change how
+// sections are categorized.
+type SectionKind uint8
+
+const (
+	// SectionOther sections don't fit any of the other kinds.
+	SectionOther SectionKind = iota
+	// SectionText sections contain executable code.
+	SectionText
+	// SectionData sections contain writable, initialized data.
+	SectionData
+	// SectionROData sections contain read-only data.
+	SectionROData
+	// SectionBSS sections contain zero-initialized data that isn't
+	// stored in the object file.
+	SectionBSS
+	// SectionDebug sections contain debugging information, such as
+	// DWARF.
+	SectionDebug
+	// SectionSymTab sections contain symbol tables, string tables, and
+	// related metadata such as symbol hash tables and versions.
+	SectionSymTab
+	// SectionReloc sections contain relocations.
+	SectionReloc
+	// SectionNote sections contain notes, such as build IDs.
+	SectionNote
+)
+
+var sectionKindNames = [...]string{
+	SectionOther:  "other",
+	SectionText:   "text",
+	SectionData:   "data",
+	SectionROData: "rodata",
+	SectionBSS:    "bss",
+	SectionDebug:  "debug",
+	SectionSymTab: "symtab",
+	SectionReloc:  "reloc",
+	SectionNote:   "note",
+}
+
+// String returns a short lower-case name for k, such as "text".
+func (k SectionKind) String() string {
+	if int(k) < len(sectionKindNames) {
+		return sectionKindNames[k]
+	}
+	return fmt.Sprintf("SectionKind(%d)", k)
+}
+
+// SectionFlags is a set of section flags.
 type SectionFlags struct {
 	f sectionFlags
 }
@@ -190,6 +304,8 @@ const (
 	sectionFlagReadOnly sectionFlags = 1 << iota
 	sectionFlagZeroInitialized
 	sectionFlagMapped
+	sectionFlagExecutable
+	sectionFlagTLS
 )
 
 // ReadOnly indicates a section's data is read-only.
@@ -207,8 +323,15 @@ func (s *SectionFlags) SetReadOnly(v bool) {
 }
 
 // ZeroInitialized indicates a section is in a zero-initialized section.
+func (s SectionFlags) ZeroInitialized() bool {
+	return s.f&sectionFlagZeroInitialized != 0
+}
+
+// ZeroInitialize is the old, misspelled name of ZeroInitialized.
+//
+// Deprecated: Use ZeroInitialized.
 func (s SectionFlags) ZeroInitialize() bool {
-	return s.f&sectionFlagReadOnly != 0
+	return s.ZeroInitialized()
 }
 
 // SetZeroInitialized sets the ZeroInitialized flag to v.
@@ -240,6 +363,72 @@ func (s *SectionFlags) SetMapped(v bool) {
 	}
 }
 
+// Executable indicates a section contains executable code.
+func (s SectionFlags) Executable() bool {
+	return s.f&sectionFlagExecutable != 0
+}
+
+// SetExecutable sets the Executable flag to v.
+func (s *SectionFlags) SetExecutable(v bool) {
+	if v {
+		s.f |= sectionFlagExecutable
+	} else {
+		s.f &^= sectionFlagExecutable
+	}
+}
+
+// TLS indicates a section contains the initial image of thread-local
+// storage. Its addresses are offsets into each thread's TLS block
+// rather than addresses in the shared address space.
+func (s SectionFlags) TLS() bool {
+	return s.f&sectionFlagTLS != 0
+}
+
+// SetTLS sets the TLS flag to v.
+func (s *SectionFlags) SetTLS(v bool) {
+	if v {
+		s.f |= sectionFlagTLS
+	} else {
+		s.f &^= sectionFlagTLS
+	}
+}
+
+// String returns a string representation of the flags set in s.
+func (s SectionFlags) String() string {
+	if s.f == 0 {
+		return "{}"
+	}
+	var buf strings.Builder
+	var sep byte = '{'
+	if s.ReadOnly() {
+		buf.WriteByte(sep)
+		buf.WriteString("ReadOnly")
+		sep = ','
+	}
+	if s.ZeroInitialized() {
+		buf.WriteByte(sep)
+		buf.WriteString("ZeroInitialized")
+		sep = ','
+	}
+	if s.Mapped() {
+		buf.WriteByte(sep)
+		buf.WriteString("Mapped")
+		sep = ','
+	}
+	if s.Executable() {
+		buf.WriteByte(sep)
+		buf.WriteString("Executable")
+		sep = ','
+	}
+	if s.TLS() {
+		buf.WriteByte(sep)
+		buf.WriteString("TLS")
+		sep = ','
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
 // roundDown2 to rounds x down to a multiple of y, where y must be a
 // power of 2.
 func roundDown2(x, y uint64) uint64 {
@@ -35,3 +35,20 @@ func TestOpenNonObject(t *testing.T) {
 		t.Fatalf("want error %q, got %q", want, err.Error())
 	}
 }
+
+func TestSectionFlags(t *testing.T) {
+	var f SectionFlags
+	f.SetZeroInitialized(true)
+	f.SetTLS(true)
+	if !f.ZeroInitialized() || f.ReadOnly() || !f.TLS() || f.Executable() {
+		t.Errorf("want ZeroInitialized and TLS, got %v", f)
+	}
+	if got, want := f.String(), "{ZeroInitialized,TLS}"; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	f.SetZeroInitialized(false)
+	f.SetReadOnly(true)
+	if f.ZeroInitialized() || !f.ReadOnly() {
+		t.Errorf("want ReadOnly and not ZeroInitialized, got %v", f)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"io"
+	"sync"
+)
+
+// readerCachePageSize is the granularity at which readerCache reads
+// from its underlying reader. This is large enough to amortize the
+// cost of remote or otherwise slow readers, but small enough that
+// sparse accesses to large files don't read much unneeded data.
+const readerCachePageSize = 64 << 10
+
+// readerCacheMaxPages is the default bound on the number of pages a
+// readerCache keeps.
+const readerCacheMaxPages = 256
+
+// readerCache provides cached, page-granular access to an io.ReaderAt.
+// It keeps at most maxPages pages, evicting the oldest first. Evicted
+// pages remain valid for callers still using them. It is safe for
+// concurrent use.
+type readerCache struct {
+	r        io.ReaderAt
+	maxPages int
+
+	mu    sync.Mutex
+	pages map[int64][]byte // Page index -> page data
+	order []int64          // Cached page indexes, oldest first
+}
+
+func newReaderCache(r io.ReaderAt) *readerCache {
+	return &readerCache{r: r, maxPages: readerCacheMaxPages, pages: make(map[int64][]byte)}
+}
+
+// page returns the contents of page i. This may be shorter than
+// readerCachePageSize if the page is at the end of the underlying
+// reader.
+func (c *readerCache) page(i int64) ([]byte, error) {
+	c.mu.Lock()
+	p, ok := c.pages[i]
+	c.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	// Read the page without holding the lock. If there's a race, we
+	// may read the same page twice, but that's harmless.
+	p = make([]byte, readerCachePageSize)
+	n, err := c.r.ReadAt(p, i*readerCachePageSize)
+	if err != nil && !(err == io.EOF && n > 0) {
+		return nil, err
+	}
+	p = p[:n:n]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p2, ok := c.pages[i]; ok {
+		return p2, nil
+	}
+	if len(c.order) >= c.maxPages {
+		delete(c.pages, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.pages[i] = p
+	c.order = append(c.order, i)
+	return p, nil
+}
+
+// slice returns the n bytes at offset off in the underlying reader. If
+// the range falls within a single page, the result aliases the cache;
+// otherwise it is read directly into a new slice without caching.
+// Either way, the caller must not modify the result.
+func (c *readerCache) slice(off int64, n int) ([]byte, error) {
+	if n == 0 {
+		return []byte{}, nil
+	}
+	first, last := off/readerCachePageSize, (off+int64(n)-1)/readerCachePageSize
+	if first == last {
+		p, err := c.page(first)
+		if err != nil {
+			return nil, err
+		}
+		o := int(off - first*readerCachePageSize)
+		if o+n > len(p) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return p[o : o+n : o+n], nil
+	}
+
+	// Caching the pages of a large read would keep a second copy of
+	// the data, so read it directly.
+	out := make([]byte, n)
+	m, err := c.r.ReadAt(out, off)
+	if m < n {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// countingReaderAt wraps an io.ReaderAt and counts the bytes read.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func TestReaderCache(t *testing.T) {
+	const size = 3*readerCachePageSize + 100
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	r := &countingReaderAt{r: bytes.NewReader(data)}
+	c := newReaderCache(r)
+
+	check := func(off int64, n int) {
+		t.Helper()
+		got, err := c.slice(off, n)
+		if err != nil {
+			t.Errorf("slice(%d, %d): %v", off, n, err)
+		} else if !bytes.Equal(got, data[off:off+int64(n)]) {
+			t.Errorf("slice(%d, %d): wrong data", off, n)
+		}
+	}
+	check(10, 20)
+	if r.n != readerCachePageSize {
+		t.Errorf("want 1 page read, got %d bytes", r.n)
+	}
+	check(100, 20)
+	if r.n != readerCachePageSize {
+		t.Errorf("want cached page, got %d bytes read", r.n)
+	}
+	// Reads that span pages bypass the cache.
+	check(readerCachePageSize-10, 20)
+	check(readerCachePageSize-10, 2*readerCachePageSize+110)
+	if want := int64(readerCachePageSize + 20 + 2*readerCachePageSize + 110); r.n != want {
+		t.Errorf("want %d bytes read, got %d", want, r.n)
+	}
+	if len(c.pages) != 1 {
+		t.Errorf("want 1 cached page, got %d", len(c.pages))
+	}
+	// The short last page.
+	check(size-1, 1)
+	check(0, 0)
+
+	// Evict the oldest page.
+	c.maxPages = 2
+	check(readerCachePageSize, 1)
+	n := r.n
+	check(0, 1)
+	if r.n != n+readerCachePageSize {
+		t.Errorf("want evicted page to be read again, got %d bytes read", r.n-n)
+	}
+	if len(c.pages) != 2 {
+		t.Errorf("want 2 cached pages, got %d", len(c.pages))
+	}
+
+	// Past the end.
+	if _, err := c.slice(size-1, 2); err != io.ErrUnexpectedEOF {
+		t.Errorf("reading past end: want ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := c.slice(size+readerCachePageSize, 2); err == nil {
+		t.Errorf("reading past end: want error, got nil")
+	}
+}
@@ -31,6 +31,9 @@ type Reloc struct {
 	Addend int64
 }
 
+// maxRelocSize is the largest Size of any relocation type.
+const maxRelocSize = 16
+
 // RelocType gives the type of a relocation. Relocations vary widely by
 // architecture and operating system, so the interface to this is fairly opaque.
 type RelocType struct {
@@ -0,0 +1,237 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// A Source provides the raw bytes of an object file.
+//
+// Object file backends read headers and other small structures using
+// ReadAt, and read section contents using Slice. Slice is called
+// frequently for small ranges, so implementations should make it cheap,
+// for example by caching.
+//
+// Users can implement Source to supply object file bytes from other
+// places, such as archives or container image layers. Most users will
+// want one of the implementations provided by this package:
+// NewBytesSource, NewMmapSource, or NewReaderAtSource.
+type Source interface {
+	io.ReaderAt
+
+	// Slice returns the n bytes at offset off. The result may alias
+	// memory owned by the Source and must not be modified. It remains
+	// valid until the Source is closed. If the range is not entirely
+	// within the Source, Slice returns an error.
+	//
+	// A File closes its Source once the File and all Data retained
+	// from it are released. Data that weren't retained may still
+	// refer to Slice results after that, so Close should leave them
+	// safe to read, as the Sources in this package do.
+	Slice(off int64, n int) ([]byte, error)
+
+	// Close releases any resources held by the Source.
+	Close() error
+}
+
+// NewBytesSource returns a Source that reads from b. Slices of the
+// Source alias b, so the caller must not modify b while the Source is
+// in use.
+func NewBytesSource(b []byte) Source {
+	return &bytesSource{bytes.NewReader(b), b}
+}
+
+type bytesSource struct {
+	*bytes.Reader
+	b []byte
+}
+
+func (s *bytesSource) Slice(off int64, n int) ([]byte, error) {
+	return sliceBytes(s.b, off, n)
+}
+
+func (s *bytesSource) Close() error {
+	return nil
+}
+
+// sliceBytes returns b[off:off+n], or an error if that range is out of
+// bounds.
+func sliceBytes(b []byte, off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off > int64(len(b)) || int64(n) > int64(len(b))-off {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b[off : off+int64(n) : off+int64(n)], nil
+}
+
+// NewReaderAtSource returns a Source that reads from r. Slices are read
+// lazily and cached, so only the parts of r that are actually used are
+// read, and each is read only once.
+func NewReaderAtSource(r io.ReaderAt) Source {
+	return &readerAtSource{r, newReaderCache(r)}
+}
+
+type readerAtSource struct {
+	io.ReaderAt
+	cache *readerCache
+}
+
+func (s *readerAtSource) Slice(off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return s.cache.slice(off, n)
+}
+
+func (s *readerAtSource) Close() error {
+	return nil
+}
+
+// NewMmapSource returns a Source that memory-maps f. It returns an
+// error if f can't be mapped, for example because memory mapping isn't
+// supported on this platform. f must remain open while the Source is
+// in use.
+func NewMmapSource(f *os.File) (Source, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("mmapping %s: file too large", f.Name())
+	}
+	var data []byte
+	if size > 0 {
+		data, err = mmapFile(f, int(size))
+		if err != nil {
+			return nil, fmt.Errorf("mmapping %s: %w", f.Name(), err)
+		}
+		if testMmapSection != nil {
+			testMmapSection(true)
+		}
+	}
+	return &mmapSource{data: data}, nil
+}
+
+type mmapSource struct {
+	mu     sync.RWMutex
+	data   []byte
+	closed bool
+}
+
+func (s *mmapSource) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= int64(len(s.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (s *mmapSource) Slice(off int64, n int) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return sliceBytes(s.data, off, n)
+}
+
+// Close releases the mapping of the file. Slices of s may still be in
+// use by Data that weren't retained, so rather than unmapping the file,
+// which would make any access to those slices fault, this replaces the
+// mapping with zero pages. This releases the file and its page cache
+// memory, but not the address space. If that isn't possible, the file
+// remains mapped. Either way, reads through s fail after Close.
+func (s *mmapSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	data := s.data
+	s.data = nil
+	if len(data) > 0 {
+		return mmapRetire(data)
+	}
+	return nil
+}
+
+// zeroPages is a read-only, zero-filled mapping shared by all callers
+// of zeroBytes. It's never unmapped.
+var zeroPages struct {
+	sync.Mutex
+	b []byte
+}
+
+// zeroBytes returns a read-only slice of n zero bytes. When possible,
+// this is backed by a shared anonymous mapping rather than the Go heap,
+// since zero-initialized sections can be large. The result remains
+// valid forever.
+func zeroBytes(n int) []byte {
+	if n == 0 {
+		if testMmapSection != nil {
+			testMmapSection(false)
+		}
+		return []byte{}
+	}
+	zeroPages.Lock()
+	defer zeroPages.Unlock()
+	if n > len(zeroPages.b) {
+		// Grow geometrically. Old mappings may still be referenced, so
+		// we keep them, but the total is at most twice the largest
+		// request.
+		size := 1 << 20
+		for size < n && size > 0 {
+			size <<= 1
+		}
+		if size <= 0 {
+			size = n
+		}
+		b, err := mmapZero(size)
+		if err != nil {
+			if testMmapSection != nil {
+				testMmapSection(false)
+			}
+			return make([]byte, n)
+		}
+		zeroPages.b = b
+	}
+	if testMmapSection != nil {
+		testMmapSection(true)
+	}
+	return zeroPages.b[:n:n]
+}
+
+// openSource returns the Source to use for reading r. If r is already a
+// Source, it's used directly. Otherwise, openSource prefers to mmap r,
+// and falls back to cached reads.
+func openSource(r io.ReaderAt) Source {
+	switch r := r.(type) {
+	case Source:
+		return r
+	case *os.File:
+		if s, err := NewMmapSource(r); err == nil {
+			return s
+		}
+	}
+	return NewReaderAtSource(r)
+}
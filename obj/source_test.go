@@ -0,0 +1,75 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// closeTrackingSource wraps a Source and records whether it was closed.
+type closeTrackingSource struct {
+	Source
+	closed bool
+}
+
+func (s *closeTrackingSource) Close() error {
+	s.closed = true
+	return s.Source.Close()
+}
+
+func TestSources(t *testing.T) {
+	path := filepath.Join("testdata", elfTests[0].path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading test file: %v", err)
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("error opening test file: %v", err)
+	}
+	defer fp.Close()
+	ref := elfTests[0].openOrSkip(t)
+
+	mmapSrc, err := NewMmapSource(fp)
+	if err != nil {
+		t.Logf("NewMmapSource failed: %v", err)
+	}
+	for _, test := range []struct {
+		name string
+		src  Source
+	}{
+		{"bytes", NewBytesSource(data)},
+		{"readerAt", NewReaderAtSource(bytes.NewReader(data))},
+		{"mmap", mmapSrc},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.src == nil {
+				t.Skip("source not available")
+			}
+			src := &closeTrackingSource{Source: test.src}
+			f, err := Open(src)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			refSections := ref.Sections()
+			for i, s := range f.Sections() {
+				if !bytes.Equal(sectionBytes(t, s), sectionBytes(t, refSections[i])) {
+					t.Errorf("section %s: data differs", s.Name)
+				}
+			}
+			if _, err := src.Slice(int64(len(data))-1, 2); err == nil {
+				t.Errorf("Slice past end of source succeeded")
+			}
+			f.Close()
+			if !src.closed {
+				t.Errorf("closing File did not close Source")
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/internal/imap"
+)
+
+// An AddressSpace combines several object files loaded at different
+// addresses, such as a process's executable and its shared libraries.
+//
+// Each File in an AddressSpace is a Module with a load bias, which is
+// added to addresses in the File to get addresses in the AddressSpace.
+//
+// Add must not be called concurrently with other methods, but other
+// methods are safe for concurrent use.
+type AddressSpace struct {
+	modules []*Module
+
+	indexOnce *sync.Once
+	index     []spaceRange // Sorted, disjoint ranges
+}
+
+// A Module is an object file loaded into an AddressSpace.
+type Module struct {
+	// Name identifies this module, typically by its path.
+	Name string
+
+	// File is the object file of this module.
+	File File
+
+	// Bias is the difference between addresses in the AddressSpace and
+	// addresses in File.
+	Bias uint64
+}
+
+type spaceRange struct {
+	low, high uint64
+	*spaceSection
+}
+
+type spaceSection struct {
+	m *Module
+	s *Section
+}
+
+// ToFile translates address addr in the AddressSpace to an address in
+// m.File.
+func (m *Module) ToFile(addr uint64) uint64 {
+	return addr - m.Bias
+}
+
+// FromFile translates address addr in m.File to an address in the
+// AddressSpace.
+func (m *Module) FromFile(addr uint64) uint64 {
+	return addr + m.Bias
+}
+
+// NewAddressSpace returns a new, empty AddressSpace.
+func NewAddressSpace() *AddressSpace {
+	return &AddressSpace{indexOnce: new(sync.Once)}
+}
+
+// Add adds f to the address space at the given load bias, and returns
+// the new Module.
+//
+// If the mapped sections of modules overlap, addresses resolve to the
+// module that was added first.
+func (as *AddressSpace) Add(name string, f File, bias uint64) *Module {
+	m := &Module{name, f, bias}
+	as.modules = append(as.modules, m)
+	// Rebuild the index on the next lookup.
+	as.indexOnce = new(sync.Once)
+	return m
+}
+
+// Modules returns the modules in as, in the order they were added.
+func (as *AddressSpace) Modules() []*Module {
+	return as.modules
+}
+
+func (as *AddressSpace) buildIndex() {
+	// Insert sections in reverse so earlier modules take precedence.
+	var m imap.Imap
+	for i := len(as.modules) - 1; i >= 0; i-- {
+		mod := as.modules[i]
+		sections := mod.File.Sections()
+		for j := len(sections) - 1; j >= 0; j-- {
+			s := sections[j]
+			// Only mapped sections are part of the address space. Use
+			// ResolveAddr to get the same treatment of overlapping
+			// sections as the File.
+			if !s.Mapped() || s.Size == 0 || mod.File.ResolveAddr(s.Addr) != s {
+				continue
+			}
+			low, high := mod.FromFile(s.Addr), mod.FromFile(s.Addr+s.Size)
+			if high < low {
+				high = ^uint64(0)
+			}
+			m.Insert(imap.Interval{Low: low, High: high}, &spaceSection{mod, s})
+		}
+	}
+	as.index = nil
+	for it := m.Iter(0); it.Valid(); it.Next() {
+		key := it.Key()
+		as.index = append(as.index, spaceRange{key.Low, key.High, it.Value().(*spaceSection)})
+	}
+}
+
+// ResolveAddr finds the Module and Section containing address addr. The
+// address of addr within the Section is m.ToFile(addr). It returns nil,
+// nil if addr is not in a mapped section of any module.
+func (as *AddressSpace) ResolveAddr(addr uint64) (m *Module, s *Section) {
+	as.indexOnce.Do(as.buildIndex)
+	i := sort.Search(len(as.index), func(i int) bool {
+		return addr < as.index[i].high
+	})
+	if i < len(as.index) && as.index[i].low <= addr {
+		return as.index[i].m, as.index[i].s
+	}
+	return nil, nil
+}
+
+// Data reads size bytes of data starting at address addr in the
+// AddressSpace. The returned Data and its relocations use AddressSpace
+// addresses. If addr isn't in any module, it returns an *ErrNoData
+// error. If the range crosses the end of a section, it returns an
+// *ErrOutOfRange error.
+func (as *AddressSpace) Data(addr, size uint64) (*Data, error) {
+	m, s := as.ResolveAddr(addr)
+	if m == nil {
+		return nil, &ErrNoData{fmt.Sprintf("address %#x is not in any module", addr)}
+	}
+	d, err := s.Data(m.ToFile(addr), size)
+	if err != nil {
+		if err, ok := err.(*ErrOutOfRange); ok {
+			return nil, &ErrOutOfRange{addr, size, m.FromFile(err.Low), m.FromFile(err.High)}
+		}
+		return nil, err
+	}
+	if m.Bias != 0 {
+		d.Addr = m.FromFile(d.Addr)
+		r := make([]Reloc, len(d.R))
+		for i, rel := range d.R {
+			rel.Addr = m.FromFile(rel.Addr)
+			r[i] = rel
+		}
+		d.R = r
+	}
+	return d, nil
+}
+
+// A Mapping is a range of a process's address space that is mapped
+// from a file.
+type Mapping struct {
+	// Start and End are the address range [Start, End) of the mapping.
+	Start, End uint64
+
+	// Offset is the offset in the file that is mapped at Start.
+	Offset uint64
+
+	// Path is the path of the mapped file. For anonymous and special
+	// mappings, this may be empty or a name like "[heap]".
+	Path string
+}
+
+// ParseProcMaps parses mappings in the format of Linux's
+// /proc/PID/maps.
+func ParseProcMaps(r io.Reader) ([]Mapping, error) {
+	var out []Mapping
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		// The format is:
+		//   start-end perms offset dev inode [path]
+		// The path may contain spaces.
+		fields := strings.SplitN(line, " ", 6)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("malformed maps line: %q", line)
+		}
+		addrs := strings.SplitN(fields[0], "-", 2)
+		if len(addrs) != 2 {
+			return nil, fmt.Errorf("malformed maps line: %q", line)
+		}
+		var m Mapping
+		var err1, err2, err3 error
+		m.Start, err1 = strconv.ParseUint(addrs[0], 16, 64)
+		m.End, err2 = strconv.ParseUint(addrs[1], 16, 64)
+		m.Offset, err3 = strconv.ParseUint(fields[2], 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("malformed maps line: %q", line)
+		}
+		if len(fields) == 6 {
+			m.Path = strings.TrimLeft(fields[5], " ")
+		}
+		out = append(out, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CoreMappings returns the file mappings recorded in core file f. For
+// ELF core files, this is the NT_FILE note.
+func CoreMappings(f File) ([]Mapping, error) {
+	switch f := f.(type) {
+	case *elfFile:
+		return f.coreMappings()
+	}
+	return nil, fmt.Errorf("core file mappings are not supported for this object file format")
+}
+
+// A Thread is the state of a thread recorded in a core file.
+type Thread struct {
+	// ID is the thread's ID. On Linux, this is the TID.
+	ID int
+
+	// Regs gives the values of the thread's registers. Registers are
+	// numbered according to the architecture's arch.Regs.
+	Regs map[arch.Reg]uint64
+}
+
+// CoreThreads returns the threads recorded in core file f. For ELF core
+// files, these are the NT_PRSTATUS notes.
+func CoreThreads(f File) ([]Thread, error) {
+	switch f := f.(type) {
+	case *elfFile:
+		return f.coreThreads()
+	}
+	return nil, fmt.Errorf("core file threads are not supported for this object file format")
+}
+
+// CoreMemory returns the memory contents recorded in core file f. The
+// offsets passed to the returned ReaderAt are addresses in the process.
+// Reading memory that isn't recorded in f returns an *ErrNoData error.
+// Typically, core files omit unmodified file mappings, such as program
+// text, which can be read from an AddressSpace instead.
+//
+// The returned ReaderAt reads from f, so it must not be used after f is
+// closed.
+func CoreMemory(f File) (io.ReaderAt, error) {
+	switch f := f.(type) {
+	case *elfFile:
+		m, err := f.coreMemory()
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("core file memory is not supported for this object file format")
+}
+
+// NewAddressSpaceFromMappings constructs an AddressSpace from the file
+// mappings of a process, such as those returned by ParseProcMaps or
+// CoreMappings.
+//
+// It calls open for each distinct mapped path, in order of first
+// appearance, to open the object file at that path. open may return
+// nil, nil to skip a path, for example because it's not an object
+// file. Mappings whose paths don't begin with "/" are skipped without
+// calling open.
+//
+// The load bias of each file is computed from the mapping that contains
+// the file's first mapped section with data. Files with no such mapping
+// are closed and skipped.
+//
+// The caller is responsible for closing the Files of the returned
+// AddressSpace's modules. If NewAddressSpaceFromMappings returns an
+// error, it closes any Files it opened.
+func NewAddressSpaceFromMappings(maps []Mapping, open func(path string) (File, error)) (*AddressSpace, error) {
+	as := NewAddressSpace()
+	byPath := make(map[string][]Mapping)
+	var paths []string
+	for _, m := range maps {
+		if !strings.HasPrefix(m.Path, "/") {
+			continue
+		}
+		if _, ok := byPath[m.Path]; !ok {
+			paths = append(paths, m.Path)
+		}
+		byPath[m.Path] = append(byPath[m.Path], m)
+	}
+	for _, path := range paths {
+		f, err := open(path)
+		if err != nil {
+			for _, m := range as.Modules() {
+				m.File.Close()
+			}
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		bias, ok := mappingBias(f, byPath[path])
+		if !ok {
+			// None of the mapped parts of the file contain sections.
+			f.Close()
+			continue
+		}
+		as.Add(path, f, bias)
+	}
+	return as, nil
+}
+
+// mappingBias computes the load bias of f given its mappings.
+func mappingBias(f File, maps []Mapping) (uint64, bool) {
+	for _, s := range f.Sections() {
+		if !s.Mapped() || s.ZeroInitialized() || s.Size == 0 {
+			continue
+		}
+		for _, m := range maps {
+			if m.Offset <= s.Offset && s.Offset-m.Offset < m.End-m.Start {
+				return m.Start + (s.Offset - m.Offset) - s.Addr, true
+			}
+		}
+	}
+	return 0, false
+}
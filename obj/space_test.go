@@ -0,0 +1,367 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/arch"
+)
+
+func TestAddressSpace(t *testing.T) {
+	exe := findElfTest(t, "hello-gcc10.3.0-AMD64-dyn").openOrSkip(t)
+	lib := findElfTest(t, "hello-gcc10.3.0-AMD64-pie").openOrSkip(t)
+	const bias = 0x7f0000000000
+	as := NewAddressSpace()
+	mExe := as.Add("exe", exe, 0)
+	mLib := as.Add("lib", lib, bias)
+	if got := as.Modules(); !reflect.DeepEqual(got, []*Module{mExe, mLib}) {
+		t.Fatalf("want modules %v, got %v", []*Module{mExe, mLib}, got)
+	}
+
+	for _, test := range []struct {
+		m *Module
+		f File
+	}{{mExe, exe}, {mLib, lib}} {
+		text := test.f.SectionByName(".text")
+		addr := test.m.FromFile(text.Addr + 0x10)
+		m, s := as.ResolveAddr(addr)
+		if m != test.m || s != text {
+			t.Errorf("ResolveAddr(%#x): want %s %s, got %v %v", addr, test.m.Name, text, m, s)
+			continue
+		}
+		d, err := as.Data(addr, 0x20)
+		if err != nil {
+			t.Errorf("Data(%#x): %v", addr, err)
+			continue
+		}
+		want, _ := text.Data(text.Addr+0x10, 0x20)
+		if d.Addr != addr || !bytes.Equal(d.B, want.B) {
+			t.Errorf("Data(%#x): want %#x/%x, got %#x/%x", addr, addr, want.B, d.Addr, d.B)
+		}
+		for i := range d.R {
+			if d.R[i].Addr != test.m.FromFile(want.R[i].Addr) {
+				t.Errorf("Data(%#x): relocation %d not translated", addr, i)
+			}
+		}
+
+		// Reading past the end of the section.
+		end := test.m.FromFile(text.Addr + text.Size)
+		_, err = as.Data(end-1, 2)
+		if err, ok := err.(*ErrOutOfRange); !ok || err.High != end {
+			t.Errorf("Data past end of section: want *ErrOutOfRange with High %#x, got %v", end, err)
+		}
+	}
+
+	if m, s := as.ResolveAddr(bias - 1); m != nil || s != nil {
+		t.Errorf("ResolveAddr of unmapped address: want nil, got %v %v", m, s)
+	}
+	if _, err := as.Data(bias-1, 1); err == nil {
+		t.Errorf("Data of unmapped address: want error")
+	} else if _, ok := err.(*ErrNoData); !ok {
+		t.Errorf("Data of unmapped address: want *ErrNoData, got %v", err)
+	}
+}
+
+func TestParseProcMaps(t *testing.T) {
+	const maps = `55d6e5a00000-55d6e5a02000 r--p 00000000 fd:01 1234                       /usr/bin/cat
+55d6e5a02000-55d6e5a07000 r-xp 00002000 fd:01 1234                       /usr/bin/cat
+55d6e6c6b000-55d6e6c8c000 rw-p 00000000 00:00 0                          [heap]
+7f5b8b400000-7f5b8b428000 r--p 00000000 fd:01 5678                       /usr/lib/with space.so
+7ffd1e5f1000-7ffd1e5f3000 r-xp 00000000 00:00 0
+`
+	got, err := ParseProcMaps(strings.NewReader(maps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mapping{
+		{0x55d6e5a00000, 0x55d6e5a02000, 0, "/usr/bin/cat"},
+		{0x55d6e5a02000, 0x55d6e5a07000, 0x2000, "/usr/bin/cat"},
+		{0x55d6e6c6b000, 0x55d6e6c8c000, 0, "[heap]"},
+		{0x7f5b8b400000, 0x7f5b8b428000, 0, "/usr/lib/with space.so"},
+		{0x7ffd1e5f1000, 0x7ffd1e5f3000, 0, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := ParseProcMaps(strings.NewReader("bad line\n")); err == nil {
+		t.Errorf("malformed maps: want error")
+	}
+}
+
+func TestAddressSpaceFromProcMaps(t *testing.T) {
+	mapsFile, err := os.Open("/proc/self/maps")
+	if err != nil {
+		t.Skipf("can't read /proc/self/maps: %v", err)
+	}
+	defer mapsFile.Close()
+	maps, err := ParseProcMaps(mapsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("can't find test executable: %v", err)
+	}
+
+	// Only open the test executable itself.
+	as, err := NewAddressSpaceFromMappings(maps, func(path string) (File, error) {
+		if path != exe {
+			return nil, nil
+		}
+		fp, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return Open(fp)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as.Modules()) != 1 {
+		t.Fatalf("want 1 module, got %d", len(as.Modules()))
+	}
+	defer as.Modules()[0].File.Close()
+
+	pc := reflect.ValueOf(TestAddressSpaceFromProcMaps).Pointer()
+	m, s := as.ResolveAddr(uint64(pc))
+	if m == nil || s.Name != ".text" {
+		t.Fatalf("ResolveAddr(%#x): want .text of %s, got %v %v", pc, exe, m, s)
+	}
+	d, err := as.Data(uint64(pc), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Addr != uint64(pc) || len(d.B) != 16 {
+		t.Errorf("Data(%#x, 16): got %d bytes at %#x", pc, len(d.B), d.Addr)
+	}
+}
+
+func TestAddressSpaceFromMappingsError(t *testing.T) {
+	// If open fails partway, the files opened so far are closed. So is a
+	// file whose mappings don't contain any sections.
+	f1, src1 := openCloseTest(t)
+	f2, src2 := openCloseTest(t)
+	maps := []Mapping{
+		{Start: 0x10000, End: 0x100000, Offset: 0, Path: "/a"},
+		{Start: 0x200000, End: 0x201000, Offset: 1 << 40, Path: "/b"},
+		{Start: 0x300000, End: 0x301000, Offset: 0, Path: "/c"},
+	}
+	errOpen := fmt.Errorf("open failed")
+	_, err := NewAddressSpaceFromMappings(maps, func(path string) (File, error) {
+		switch path {
+		case "/a":
+			return f1, nil
+		case "/b":
+			return f2, nil
+		}
+		return nil, errOpen
+	})
+	if err != errOpen {
+		t.Fatalf("want error %v, got %v", errOpen, err)
+	}
+	if !src1.closed {
+		t.Errorf("module file not closed after error")
+	}
+	if !src2.closed {
+		t.Errorf("skipped file not closed")
+	}
+}
+
+type coreNote struct {
+	name string
+	typ  uint32
+	desc []byte
+}
+
+type coreLoad struct {
+	addr uint64
+	data []byte
+}
+
+// buildCore constructs a minimal 64-bit x86-64 ELF core file containing
+// notes and loadable segments.
+func buildCore(notes []coreNote, loads []coreLoad) []byte {
+	var note bytes.Buffer
+	order := binary.LittleEndian
+	pad := func(b []byte) []byte {
+		return append(b, make([]byte, (4-len(b)%4)%4)...)
+	}
+	for _, n := range notes {
+		binary.Write(&note, order, [3]uint32{uint32(len(n.name) + 1), uint32(len(n.desc)), n.typ})
+		note.Write(pad(append([]byte(n.name), 0)))
+		note.Write(pad(n.desc))
+	}
+
+	const ehsize, phsize = 64, 56
+	var buf bytes.Buffer
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_CORE),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     ehsize,
+		Ehsize:    ehsize,
+		Phentsize: phsize,
+		Phnum:     uint16(1 + len(loads)),
+		Shentsize: 64,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	binary.Write(&buf, order, hdr)
+	off := uint64(ehsize + phsize*(1+len(loads)))
+	binary.Write(&buf, order, elf.Prog64{
+		Type:   uint32(elf.PT_NOTE),
+		Off:    off,
+		Filesz: uint64(note.Len()),
+		Align:  4,
+	})
+	off += uint64(note.Len())
+	for _, l := range loads {
+		binary.Write(&buf, order, elf.Prog64{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    off,
+			Vaddr:  l.addr,
+			Filesz: uint64(len(l.data)),
+			Memsz:  uint64(len(l.data)),
+		})
+		off += uint64(len(l.data))
+	}
+	buf.Write(note.Bytes())
+	for _, l := range loads {
+		buf.Write(l.data)
+	}
+	return buf.Bytes()
+}
+
+func TestCoreMappings(t *testing.T) {
+	var desc []byte
+	words := []uint64{2, 0x1000, 0x400000, 0x401000, 0, 0x7f0000000000, 0x7f0000002000, 3}
+	for _, w := range words {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], w)
+		desc = append(desc, b[:]...)
+	}
+	desc = append(desc, "/bin/exe\x00/lib/libc.so\x00"...)
+	f, err := Open(NewBytesSource(buildCore([]coreNote{{"CORE", 0x46494c45, desc}}, nil)))
+	if err != nil {
+		t.Fatalf("opening core: %v", err)
+	}
+	defer f.Close()
+	got, err := CoreMappings(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mapping{
+		{0x400000, 0x401000, 0, "/bin/exe"},
+		{0x7f0000000000, 0x7f0000002000, 0x3000, "/lib/libc.so"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	// Truncated note.
+	f, err = Open(NewBytesSource(buildCore([]coreNote{{"CORE", 0x46494c45, desc[:40]}}, nil)))
+	if err != nil {
+		t.Fatalf("opening core: %v", err)
+	}
+	defer f.Close()
+	if _, err := CoreMappings(f); err == nil {
+		t.Errorf("truncated NT_FILE: want error")
+	}
+
+	// Not a core file.
+	if _, err := CoreMappings(elfTests[0].openOrSkip(t)); err == nil {
+		t.Errorf("CoreMappings of non-core file: want error")
+	}
+}
+
+func TestCoreThreads(t *testing.T) {
+	var notes []coreNote
+	for tid := 100; tid < 102; tid++ {
+		desc := make([]byte, 336)
+		binary.LittleEndian.PutUint32(desc[32:], uint32(tid))
+		// rbp is register 4 and rip is register 16 in
+		// user_regs_struct.
+		binary.LittleEndian.PutUint64(desc[112+4*8:], 0x7ffe0000)
+		binary.LittleEndian.PutUint64(desc[112+16*8:], 0x401000+uint64(tid))
+		notes = append(notes, coreNote{"CORE", 1, desc})
+	}
+	f, err := Open(NewBytesSource(buildCore(notes, nil)))
+	if err != nil {
+		t.Fatalf("opening core: %v", err)
+	}
+	defer f.Close()
+	threads, err := CoreThreads(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("want 2 threads, got %d", len(threads))
+	}
+	regs := arch.AMD64.Regs
+	rbp, _ := regs.ByName("rbp")
+	for i, th := range threads {
+		if th.ID != 100+i {
+			t.Errorf("thread %d: want ID %d, got %d", i, 100+i, th.ID)
+		}
+		if got := th.Regs[regs.PC]; got != 0x401064+uint64(i) {
+			t.Errorf("thread %d: want rip %#x, got %#x", i, 0x401064+i, got)
+		}
+		if got := th.Regs[rbp]; got != 0x7ffe0000 {
+			t.Errorf("thread %d: want rbp 0x7ffe0000, got %#x", i, got)
+		}
+	}
+}
+
+func TestCoreMemory(t *testing.T) {
+	f, err := Open(NewBytesSource(buildCore(nil, []coreLoad{
+		{0x1000, []byte("abcd")},
+		{0x1004, []byte("efgh")},
+		{0x2000, []byte("ijkl")},
+	})))
+	if err != nil {
+		t.Fatalf("opening core: %v", err)
+	}
+	defer f.Close()
+	mem, err := CoreMemory(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		addr int64
+		n    int
+		want string
+	}{
+		{0x1001, 2, "bc"},
+		// Contiguous segments.
+		{0x1002, 4, "cdef"},
+		{0x2000, 4, "ijkl"},
+	} {
+		buf := make([]byte, test.n)
+		if _, err := mem.ReadAt(buf, test.addr); err != nil {
+			t.Errorf("ReadAt(%#x): %v", test.addr, err)
+		} else if string(buf) != test.want {
+			t.Errorf("ReadAt(%#x): want %q, got %q", test.addr, test.want, buf)
+		}
+	}
+	for _, addr := range []int64{0x1006, 0x0fff, 0x2004} {
+		buf := make([]byte, 4)
+		if _, err := mem.ReadAt(buf, addr); err == nil {
+			t.Errorf("ReadAt(%#x): want error", addr)
+		} else if _, ok := err.(*ErrNoData); !ok {
+			t.Errorf("ReadAt(%#x): want *ErrNoData, got %v", addr, err)
+		}
+	}
+}
@@ -95,9 +95,8 @@ type symFlags uint8
 const (
 	symFlagLocal symFlags = 1 << iota
 	symFlagSizeSynthesized
-
-	// TODO: Indicate which symbol table this comes from for formats that
-	// support more than one? (ELF has static and dynamic symbols.)
+	symFlagWeak
+	symFlagDynamic
 )
 
 // Local indicates a symbol's name is only meaningful withing its defining
@@ -129,6 +128,39 @@ func (s *SymFlags) SetSizeSynthesized(v bool) {
 	}
 }
 
+// Weak indicates a symbol has weak binding. A weak definition may be
+// overridden by a non-weak definition, and a weak undefined symbol does
+// not need to be resolved.
+func (s SymFlags) Weak() bool {
+	return s.f&symFlagWeak != 0
+}
+
+// SetWeak sets the Weak flag to v.
+func (s *SymFlags) SetWeak(v bool) {
+	if v {
+		s.f |= symFlagWeak
+	} else {
+		s.f &^= symFlagWeak
+	}
+}
+
+// Dynamic indicates a symbol is from the dynamic symbol table, for
+// formats that have separate static and dynamic symbol tables (such as
+// ELF). Dynamic symbols are the symbols that are visible to the dynamic
+// linker.
+func (s SymFlags) Dynamic() bool {
+	return s.f&symFlagDynamic != 0
+}
+
+// SetDynamic sets the Dynamic flag to v.
+func (s *SymFlags) SetDynamic(v bool) {
+	if v {
+		s.f |= symFlagDynamic
+	} else {
+		s.f &^= symFlagDynamic
+	}
+}
+
 // String returns a string representation of the flags set in s.
 func (s SymFlags) String() string {
 	if s.f == 0 {
@@ -146,6 +178,16 @@ func (s SymFlags) String() string {
 		buf.WriteString("SizeSynthesized")
 		sep = ','
 	}
+	if s.Weak() {
+		buf.WriteByte(sep)
+		buf.WriteString("Weak")
+		sep = ','
+	}
+	if s.Dynamic() {
+		buf.WriteByte(sep)
+		buf.WriteString("Dynamic")
+		sep = ','
+	}
 	buf.WriteByte('}')
 	return buf.String()
 }
@@ -160,8 +202,8 @@ func (s *Sym) String() string {
 
 // Data reads size bytes of data from this symbol, starting at the given
 // address. If s is an undefined symbol or otherwise not backed by data,
-// it returns an ErrNoData error. It panics if the requested byte range
-// is out of range for the section.
+// it returns an ErrNoData error. If the requested byte range is out of
+// range for the symbol, it returns an *ErrOutOfRange error.
 func (s *Sym) Data(addr, size uint64) (*Data, error) {
 	if s.Section == nil {
 		// We return an error rather than panic so that "Data" is useful
@@ -174,8 +216,8 @@ func (s *Sym) Data(addr, size uint64) (*Data, error) {
 		}
 		return nil, &ErrNoData{"unknown reason"}
 	}
-	if addr < s.Value || addr+size > s.Value+s.Size {
-		panic(fmt.Sprintf("requested data [0x%x, 0x%x) is outside symbol [0x%x, 0x%x)", addr, addr+size, s.Value, s.Value+s.Size))
+	if addr+size < addr || addr < s.Value || addr+size > s.Value+s.Size {
+		return nil, &ErrOutOfRange{addr, size, s.Value, s.Value + s.Size}
 	}
 	return s.Section.Data(addr, size)
 }
@@ -82,6 +82,11 @@ var elfTests = []*elfTest{
 	c.compressDWARF = true
 	c.build()
 
+	// Build shared libraries with both kinds of ELF hash table.
+	for _, arch := range []string{"I386", "AMD64"} {
+		buildHashLib(arch)
+	}
+
 	fmt.Fprintf(&testBuf, `}`)
 
 	testSource, err := format.Source(testBuf.Bytes())
@@ -168,6 +173,26 @@ func (c config) build() {
 	c.printElfTest(outName)
 }
 
+// buildHashLib builds hashlib-<arch>.so, which has both a .hash and a
+// .gnu.hash section. This isn't included in elfTests.
+func buildHashLib(arch string) {
+	flags := []string{"-O2", "-fPIC", "-nostdlib", "-shared", "-fno-asynchronous-unwind-tables",
+		"-Wl,--hash-style=both", "-Wl,--version-script=hashlib.map"}
+	switch arch {
+	case "I386":
+		flags = append(flags, "-m32")
+	case "AMD64":
+		flags = append(flags, "-m64")
+	}
+	flags = append(flags, "-o", "hashlib-"+arch+".so", "hashlib.c")
+	cmd := exec.Command("gcc", flags...)
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func (c config) printElfTest(path string) {
 	b := &testBuf
 	fmt.Fprintf(b, `	{
@@ -189,14 +214,21 @@ func (c config) printElfTest(path string) {
 			continue
 		}
 		info := strings.Fields(m[2])
-		name := info[0]
+		name, typ := info[0], info[1]
 		addr := atoi("0x" + info[2])
+		off := atoi("0x" + info[3])
 		size := atoi("0x" + info[4])
-		flags := info[6]
+		flags := ""
+		if len(info) == 10 {
+			// The flags column is empty for sections with no flags.
+			flags = info[6]
+		}
+		align := atoi(info[len(info)-1])
 		if strings.Contains(flags, "C") {
 			// Compressed section. Obj hides this. Let readelf decompress it.
 			d := sectionData(path, name)
 			size = len(d)
+			align = compressedAlign(path, name)
 		}
 		sectionFlags := []string{}
 		if strings.Contains(flags, "A") && c.typ != "rel" {
@@ -205,10 +237,18 @@ func (c config) printElfTest(path string) {
 		if !strings.Contains(flags, "W") {
 			sectionFlags = append(sectionFlags, "sectionFlagReadOnly")
 		}
-		if info[1] == "NOBITS" {
+		if typ == "NOBITS" {
 			sectionFlags = append(sectionFlags, "sectionFlagZeroInitialized")
+			off = 0
+		}
+		if strings.Contains(flags, "X") {
+			sectionFlags = append(sectionFlags, "sectionFlagExecutable")
 		}
-		fmt.Fprintf(b, "{Name: %q, ID: %d, RawID: %d, Addr: %#x, Size: %#x, SectionFlags: SectionFlags{%s}},\n", name, rawID-1, rawID, addr, size, strings.Join(sectionFlags, "|"))
+		if strings.Contains(flags, "T") {
+			sectionFlags = append(sectionFlags, "sectionFlagTLS")
+		}
+		kind := sectionKind(name, typ, flags)
+		fmt.Fprintf(b, "{Name: %q, ID: %d, RawID: %d, Addr: %#x, Size: %#x, Offset: %#x, Align: %d, Kind: %s, SectionFlags: SectionFlags{%s}},\n", name, rawID-1, rawID, addr, size, off, align, kind, strings.Join(sectionFlags, "|"))
 	}
 	fmt.Fprintf(b, "},\n")
 
@@ -231,6 +271,46 @@ func (c config) printElfTest(path string) {
 	fmt.Fprintf(b, "},\n")
 }
 
+// sectionKind returns the expected SectionKind constant for a section
+// given its readelf name, type, and flags.
+func sectionKind(name, typ, flags string) string {
+	switch typ {
+	case "NOBITS":
+		return "SectionBSS"
+	case "SYMTAB", "DYNSYM", "SYMTAB_SHNDX", "STRTAB", "HASH", "GNU_HASH", "VERSYM", "VERDEF", "VERNEED":
+		return "SectionSymTab"
+	case "REL", "RELA":
+		return "SectionReloc"
+	case "NOTE":
+		return "SectionNote"
+	}
+	if strings.HasPrefix(name, ".debug") || strings.HasPrefix(name, ".zdebug") || strings.HasPrefix(name, ".stab") {
+		return "SectionDebug"
+	}
+	if strings.Contains(flags, "A") {
+		if strings.Contains(flags, "X") {
+			return "SectionText"
+		}
+		if strings.Contains(flags, "W") {
+			return "SectionData"
+		}
+		return "SectionROData"
+	}
+	return "SectionOther"
+}
+
+// compressedAlign returns the uncompressed alignment of a compressed
+// section.
+func compressedAlign(path, section string) int {
+	details := runOut("readelf", "--section-details", "--wide", path)
+	re := regexp.MustCompile(`(?m)^ *\[[ \d]+\] ` + regexp.QuoteMeta(section) + `\n(?:.*\n){2} *\w+, *[0-9a-f]+, *(\d+)`)
+	m := re.FindStringSubmatch(details)
+	if m == nil {
+		log.Fatalf("can't find compression header for %s in %s", section, path)
+	}
+	return atoi(m[1])
+}
+
 func sectionData(path, section string) []byte {
 	hex := runOut("readelf", "-x", section, "-z", path)
 	var out []byte
@@ -0,0 +1,19 @@
+// hashlib.c is a shared library with enough dynamic symbols to exercise
+// the ELF hash tables.
+
+#define F(n) int n(void) { return __LINE__; }
+
+F(alpha) F(bravo) F(charlie) F(delta) F(echo) F(foxtrot) F(golf)
+F(hotel) F(india) F(juliett) F(kilo) F(lima) F(mike) F(november)
+F(oscar) F(papa) F(quebec) F(romeo) F(sierra) F(tango) F(uniform)
+F(victor) F(whiskey) F(xray) F(yankee) F(zulu)
+
+int data_sym = 42;
+
+int versioned_v1(void) { return 1; }
+int versioned_v2(void) { return 2; }
+__asm__(".symver versioned_v1, versioned@V1");
+__asm__(".symver versioned_v2, versioned@@V2");
+
+extern int undefined_sym(void);
+int call_undefined(void) { return undefined_sym(); }
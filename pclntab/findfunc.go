@@ -0,0 +1,83 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import (
+	"fmt"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// The findfunctab divides text into buckets of pcBucketSize bytes,
+// each of which is divided into subbuckets. Each bucket records the
+// index of the first function in the bucket, plus a byte offset from
+// that for each subbucket.
+const (
+	pcBucketSize     = 4096
+	pcSubbuckets     = 16
+	pcSubbucketSize  = pcBucketSize / pcSubbuckets
+	findFuncBucketSz = 4 + pcSubbuckets
+)
+
+type findFuncTab struct {
+	data  *obj.Data
+	minPC uint64
+}
+
+// SetFindFuncTab configures t to use the runtime's findfunctab at
+// address addr in section s to speed up FindFunc. The findfunctab is
+// found at the runtime.findfunctab symbol or in the module data.
+func (t *Table) SetFindFuncTab(s *obj.Section, addr uint64) error {
+	if t.nfunc == 0 {
+		return nil
+	}
+	minPC, maxPC := t.MinPC(), t.MaxPC()
+	if maxPC < minPC {
+		return fmt.Errorf("pclntab function table is not sorted")
+	}
+	// The linker computes the table size from the end of the last
+	// function symbol, which may be slightly before MaxPC, so the table
+	// may be short by a bucket. find falls back to a search in that
+	// case.
+	nbuckets := (maxPC - minPC + pcBucketSize - 1) / pcBucketSize
+	size := nbuckets * findFuncBucketSz
+	if addr < s.Addr || addr >= s.Addr+s.Size {
+		return fmt.Errorf("findfunctab address %#x is not in section %s", addr, s.Name)
+	}
+	if avail := s.Addr + s.Size - addr; size > avail {
+		size = avail
+	}
+	d, err := s.Data(addr, size)
+	if err != nil {
+		return fmt.Errorf("reading findfunctab: %w", err)
+	}
+	t.find = &findFuncTab{d, minPC}
+	return nil
+}
+
+// find returns the index of the function containing pc, which must be
+// in [t.MinPC(), t.MaxPC()).
+func (ff *findFuncTab) find(t *Table, pc uint64) int {
+	x := pc - ff.minPC
+	off := (x / pcBucketSize) * findFuncBucketSz
+	if off+findFuncBucketSz > uint64(len(ff.data.B)) {
+		return t.search(pc)
+	}
+	b := ff.data.B[off:]
+	i := int(t.Layout.Uint32(b)) + int(b[4+(x%pcBucketSize)/pcSubbucketSize])
+	// The bucket gives a lower bound. Advance to the function
+	// containing pc.
+	if i >= t.nfunc {
+		i = t.nfunc - 1
+	}
+	for i > 0 && t.entryPC(i) > pc {
+		// Malformed table. Back up.
+		i--
+	}
+	for i+1 < t.nfunc && t.entryPC(i+1) <= pc {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,322 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import (
+	"fmt"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// Indexes of the runtime's funcdata.
+const (
+	FuncDataArgsPointerMaps    = 0
+	FuncDataLocalsPointerMaps  = 1
+	FuncDataStackObjects       = 2
+	FuncDataInlTree            = 3
+	FuncDataOpenCodedDeferInfo = 4
+	FuncDataArgInfo            = 5
+	FuncDataArgLiveInfo        = 6
+	FuncDataWrapInfo           = 7
+)
+
+// NumFuncData returns the number of funcdata slots of f.
+func (f Func) NumFuncData() int {
+	if f.t.legacyFunc {
+		return int(int32(f.field(7)))
+	}
+	return int(f.flagsByte(3))
+}
+
+// FuncData returns the address of f's i'th funcdata, such as
+// FuncDataInlTree. It returns false if f doesn't have funcdata i. For
+// Go 1.18 and later tables, it returns an error if the table's GoFunc
+// is unknown.
+func (f Func) FuncData(i int) (uint64, bool, error) {
+	if i < 0 || i >= f.NumFuncData() {
+		return 0, false, nil
+	}
+	t := f.t
+	off := f.fixedSize() + 4*f.NumPCData()
+	if t.Version >= Version118 {
+		// Offsets from GoFunc.
+		v := f.u32(off + 4*i)
+		if v == ^uint32(0) {
+			return 0, false, nil
+		}
+		if t.GoFunc == 0 {
+			return 0, false, fmt.Errorf("funcdata base address is unknown")
+		}
+		return t.GoFunc + uint64(v), true, nil
+	}
+
+	// Pointers, aligned to the pointer size.
+	ptrSize := t.Layout.WordSize()
+	if ptrSize == 8 && (t.data.Addr+f.off+uint64(off))%8 != 0 {
+		off += 4
+	}
+	o := f.off + uint64(off+ptrSize*i)
+	if o+uint64(ptrSize) > uint64(len(t.data.B)) {
+		return 0, false, fmt.Errorf("funcdata %d of %s out of range", i, f.Name())
+	}
+	v := t.Layout.Word(t.data.B[o:])
+	return v, v != 0, nil
+}
+
+// read returns size bytes of data at address addr in t's object file.
+func (t *Table) read(addr, size uint64) (*obj.Data, error) {
+	if t.file == nil {
+		return nil, fmt.Errorf("no object file to read funcdata from")
+	}
+	s := t.file.ResolveAddr(addr)
+	if s == nil {
+		return nil, &obj.ErrNoData{Detail: fmt.Sprintf("address %#x is not in any section", addr)}
+	}
+	d, err := s.Data(addr, size)
+	if err != nil {
+		return nil, err
+	}
+	// Use the table's layout in case the file's is unknown.
+	return &obj.Data{Addr: d.Addr, B: d.B, Layout: t.Layout}, nil
+}
+
+// A BitVector is a pointer bitmap, where each bit indicates whether a
+// word holds a pointer.
+type BitVector struct {
+	// N is the number of bits.
+	N int
+
+	// Bits stores the bits, least significant bit first.
+	Bits []byte
+}
+
+// Ptr reports whether bit i is set.
+func (v BitVector) Ptr(i int) bool {
+	return v.Bits[i/8]&(1<<(i%8)) != 0
+}
+
+// A StackMap is a set of pointer bitmaps for a function's arguments or
+// locals. The bitmap for each PC is selected by the
+// PCDataStackMapIndex table.
+type StackMap struct {
+	// Bitmaps are the bitmaps, indexed by stack map index.
+	Bitmaps []BitVector
+}
+
+// StackMap decodes the pointer bitmaps in f's funcdata i, which must be
+// FuncDataArgsPointerMaps or FuncDataLocalsPointerMaps. It returns nil
+// if f doesn't have funcdata i.
+func (f Func) StackMap(i int) (*StackMap, error) {
+	addr, ok, err := f.FuncData(i)
+	if !ok || err != nil {
+		return nil, err
+	}
+	d, err := f.t.read(addr, 8)
+	if err != nil {
+		return nil, err
+	}
+	n, nbit := int(int32(d.Layout.Uint32(d.B))), int(int32(d.Layout.Uint32(d.B[4:])))
+	if n < 0 || nbit < 0 {
+		return nil, fmt.Errorf("bad stack map at %#x", addr)
+	}
+	nbytes := (nbit + 7) / 8
+	d, err = f.t.read(addr+8, uint64(n*nbytes))
+	if err != nil {
+		return nil, err
+	}
+	m := &StackMap{Bitmaps: make([]BitVector, n)}
+	for j := range m.Bitmaps {
+		m.Bitmaps[j] = BitVector{nbit, d.B[j*nbytes : (j+1)*nbytes]}
+	}
+	return m, nil
+}
+
+// LocalsPointers returns the pointer bitmap of f's locals at pc. Bit i
+// covers the word at offset (i - N) * pointer size from the top of the
+// locals area. It returns false if f has no pointer information at pc,
+// such as at an unsafe point.
+func (f Func) LocalsPointers(pc uint64) (BitVector, bool, error) {
+	return f.pointers(FuncDataLocalsPointerMaps, pc)
+}
+
+// ArgsPointers returns the pointer bitmap of f's arguments at pc. Bit i
+// covers the i'th word of the arguments. It returns false if f has no
+// pointer information at pc.
+func (f Func) ArgsPointers(pc uint64) (BitVector, bool, error) {
+	return f.pointers(FuncDataArgsPointerMaps, pc)
+}
+
+func (f Func) pointers(i int, pc uint64) (BitVector, bool, error) {
+	idx, err := f.PCDataValue(PCDataStackMapIndex, pc)
+	if err != nil || idx < 0 {
+		return BitVector{}, false, err
+	}
+	m, err := f.StackMap(i)
+	if err != nil || m == nil {
+		return BitVector{}, false, err
+	}
+	if int(idx) >= len(m.Bitmaps) {
+		return BitVector{}, false, fmt.Errorf("stack map index %d out of range", idx)
+	}
+	return m.Bitmaps[idx], true, nil
+}
+
+// An InlinedCall is an entry in a function's inline tree, which
+// describes a call that was inlined into the function.
+type InlinedCall struct {
+	// FuncID is the runtime function ID of the inlined function.
+	FuncID uint8
+
+	// Name is the name of the inlined function.
+	Name string
+
+	// ParentPC is the address of an instruction whose source position
+	// is the call site of the inlined function.
+	ParentPC uint64
+
+	// StartLine is the line number of the start of the inlined
+	// function. This is only recorded by Go 1.20 and later.
+	StartLine int
+
+	// Parent is the index of the inlined call containing this call, or
+	// -1 if the call is in the outermost function. File and Line give
+	// the position of the call. These are only recorded before Go
+	// 1.20. In later versions, use ParentPC.
+	Parent int
+	File   string
+	Line   int
+}
+
+// InlineTree decodes f's inline tree. Entries are indexed by the values
+// of the PCDataInlTreeIndex table. It returns nil if f has no inlined
+// calls.
+func (f Func) InlineTree() ([]InlinedCall, error) {
+	addr, ok, err := f.FuncData(FuncDataInlTree)
+	if !ok || err != nil {
+		return nil, err
+	}
+	// The tree has no recorded length, so find the largest index used.
+	n := 0
+	it := f.PCData(PCDataInlTreeIndex)
+	for it.Next() {
+		if int(it.Value) >= n {
+			n = int(it.Value) + 1
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	t := f.t
+	size := 20
+	if t.Version >= Version120 {
+		size = 16
+	}
+	d, err := t.read(addr, uint64(n*size))
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	tree := make([]InlinedCall, n)
+	for i := range tree {
+		call := &tree[i]
+		if t.Version >= Version120 {
+			// funcID uint8; _ [3]byte; nameOff, parentPc, startLine int32
+			call.FuncID = r.Uint8()
+			r.Skip(3)
+			call.Name, err = t.string(t.funcnametab + uint64(r.Uint32()))
+			call.ParentPC = f.Entry + uint64(r.Int32())
+			call.StartLine = int(r.Int32())
+			call.Parent = -1
+		} else {
+			// parent int16; funcID uint8; _ byte; file, line, func_,
+			// parentPc int32
+			call.Parent = int(r.Int16())
+			call.FuncID = r.Uint8()
+			r.Skip(1)
+			file := int(r.Int32())
+			call.Line = int(r.Int32())
+			call.Name, err = t.string(t.funcnametab + uint64(r.Uint32()))
+			call.ParentPC = f.Entry + uint64(r.Int32())
+			if err == nil {
+				call.File, err = f.File(file)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("inline tree entry %d of %s: %w", i, f.Name(), err)
+		}
+	}
+	return tree, nil
+}
+
+// InlineStack returns the calls inlined at pc in f, from innermost to
+// outermost. It returns nil if pc isn't in inlined code.
+func (f Func) InlineStack(pc uint64) ([]InlinedCall, error) {
+	tree, err := f.InlineTree()
+	if err != nil || tree == nil {
+		return nil, err
+	}
+	var stack []InlinedCall
+	idx, err := f.PCDataValue(PCDataInlTreeIndex, pc)
+	for err == nil && idx >= 0 {
+		if int(idx) >= len(tree) || len(stack) > len(tree) {
+			return nil, fmt.Errorf("bad inline tree index %d", idx)
+		}
+		call := tree[idx]
+		stack = append(stack, call)
+		// The parent's index is the tree index at the call's
+		// ParentPC.
+		idx, err = f.PCDataValue(PCDataInlTreeIndex, call.ParentPC)
+	}
+	return stack, err
+}
+
+// A StackObject describes a stack variable that may be address-taken.
+type StackObject struct {
+	// Off is the offset of the object in the frame. If negative, it's
+	// relative to the top of the locals area. Otherwise, it's relative
+	// to the start of the arguments.
+	Off int
+
+	// Size is the size of the object in bytes.
+	Size int
+
+	// PtrBytes is the number of bytes of the object that may contain
+	// pointers.
+	PtrBytes int
+
+	// GCDataOff is the offset of the object's pointer bitmap from the
+	// start of the module's read-only data.
+	GCDataOff uint32
+}
+
+// StackObjects decodes f's stack object records. This is only supported
+// for Go 1.18 and later tables.
+func (f Func) StackObjects() ([]StackObject, error) {
+	t := f.t
+	if t.Version < Version118 {
+		return nil, fmt.Errorf("stack objects are not supported for %s tables", t.Version)
+	}
+	addr, ok, err := f.FuncData(FuncDataStackObjects)
+	if !ok || err != nil {
+		return nil, err
+	}
+	ptrSize := uint64(t.Layout.WordSize())
+	d, err := t.read(addr, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+	n := d.Layout.Word(d.B)
+	d, err = t.read(addr+ptrSize, n*16)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	objs := make([]StackObject, n)
+	for i := range objs {
+		objs[i] = StackObject{int(r.Int32()), int(r.Int32()), int(r.Int32()), r.Uint32()}
+	}
+	return objs, nil
+}
@@ -0,0 +1,575 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pclntab decodes the Go runtime's PC-line table, which maps
+// PCs to functions, files, and line numbers, and records per-function
+// metadata used by the runtime.
+//
+// This supports the table layouts introduced in Go 1.2, Go 1.16, Go
+// 1.18, and Go 1.20. Go 1.2 tables written before Go 1.12 use an older
+// per-function layout, which Parse detects. The pclntab is stored in
+// the .gopclntab section of Go binaries, or between the runtime.pclntab
+// and runtime.epclntab symbols.
+//
+// Reference: runtime/symtab.go and cmd/link/internal/ld/pcln.go in the
+// Go source tree.
+package pclntab
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/obj"
+)
+
+// ErrNoPCLNTab is returned when an object file doesn't contain a Go
+// pclntab.
+var ErrNoPCLNTab = errors.New("no Go pclntab")
+
+// Version is a pclntab layout version. Each version is named for the Go
+// release that introduced it.
+type Version int
+
+const (
+	VersionUnknown Version = iota
+	Version12
+	Version116
+	Version118
+	Version120
+)
+
+func (v Version) String() string {
+	switch v {
+	case Version12:
+		return "go1.2"
+	case Version116:
+		return "go1.16"
+	case Version118:
+		return "go1.18"
+	case Version120:
+		return "go1.20"
+	}
+	return fmt.Sprintf("Version(%d)", int(v))
+}
+
+// Header magic numbers.
+const (
+	magic12  = 0xfffffffb
+	magic116 = 0xfffffffa
+	magic118 = 0xfffffff0
+	magic120 = 0xfffffff1
+)
+
+// A Table is a decoded Go pclntab.
+type Table struct {
+	// Version is the layout version of the table.
+	Version Version
+
+	// Layout is the byte order and pointer size of the table.
+	Layout arch.Layout
+
+	// Quantum is the minimum instruction size. PC deltas in pc-value
+	// tables are multiples of Quantum.
+	Quantum int
+
+	// TextStart is the address of the start of the module's text.
+	// Starting with Go 1.18, function entry points are stored as
+	// offsets from TextStart.
+	TextStart uint64
+
+	// GoFunc is the address funcdata offsets are relative to in Go
+	// 1.18 and later tables. This is the go:func.* symbol, or 0 if
+	// unknown.
+	GoFunc uint64
+
+	file   obj.File // File to read funcdata from, or nil
+	data   *obj.Data
+	nfunc  int
+	nfiles int
+
+	// Offsets in data of each subtable. In Go 1.2 tables, all of these
+	// except filetab are 0.
+	funcnametab, cutab, filetab, pctab, funcdata, functab uint64
+
+	// legacyFunc indicates a Go 1.2 table with the _func layout from
+	// before Go 1.12, which has no funcID or flag bytes.
+	legacyFunc bool
+
+	find *findFuncTab
+}
+
+// NewTable finds and decodes the Go pclntab in f. It returns
+// ErrNoPCLNTab if f doesn't contain a pclntab.
+//
+// For Go 1.18 and later tables, NewTable takes the start of text from
+// the runtime.text symbol, or the .text section if f has no symbols. If
+// f has a runtime.findfunctab symbol, FindFunc uses it to speed up
+// lookups. It takes GoFunc from the go:func.* symbol.
+func NewTable(f obj.File) (*Table, error) {
+	syms := lookupSyms(f, "runtime.pcheader", "runtime.pclntab", "runtime.epclntab", "runtime.text", "runtime.findfunctab", "go:func.*", "go.func.*")
+
+	var d *obj.Data
+	var err error
+	if s := f.SectionByName(".gopclntab"); s != nil {
+		d, err = s.Data(s.Bounds())
+	} else {
+		start, ok := syms["runtime.pcheader"]
+		if !ok {
+			start, ok = syms["runtime.pclntab"]
+		}
+		end, ok2 := syms["runtime.epclntab"]
+		if !ok || !ok2 || end.Value < start.Value || start.Section == nil {
+			return nil, ErrNoPCLNTab
+		}
+		d, err = start.Section.Data(start.Value, end.Value-start.Value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var textStart uint64
+	if sym, ok := syms["runtime.text"]; ok {
+		textStart = sym.Value
+	} else if s := f.SectionByName(".text"); s != nil {
+		textStart = s.Addr
+	}
+
+	t, err := Parse(d, textStart)
+	if err != nil {
+		return nil, err
+	}
+	t.file = f
+	if sym, ok := syms["go:func.*"]; ok {
+		t.GoFunc = sym.Value
+	} else if sym, ok := syms["go.func.*"]; ok {
+		// Before Go 1.20.
+		t.GoFunc = sym.Value
+	}
+
+	if sym, ok := syms["runtime.findfunctab"]; ok && sym.Section != nil {
+		if err := t.SetFindFuncTab(sym.Section, sym.Value); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// lookupSyms returns the symbols in f with the given names.
+func lookupSyms(f obj.File, names ...string) map[string]obj.Sym {
+	want := make(map[string]bool)
+	for _, name := range names {
+		want[name] = true
+	}
+	syms := make(map[string]obj.Sym)
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		sym := f.Sym(i)
+		if want[sym.Name] && sym.Kind != obj.SymUndef {
+			if _, ok := syms[sym.Name]; !ok {
+				syms[sym.Name] = sym
+			}
+		}
+	}
+	return syms
+}
+
+// Parse decodes the pclntab in d. The byte order and pointer size are
+// taken from the table's header, rather than from d.Layout. textStart
+// gives the start of the module's text, which is needed to decode Go
+// 1.18 and later tables. If textStart is 0, Parse uses the text start
+// recorded in the header, which only Go 1.18 and 1.19 record.
+func Parse(d *obj.Data, textStart uint64) (*Table, error) {
+	b := d.B
+	if len(b) < 16 {
+		return nil, fmt.Errorf("pclntab too short")
+	}
+	var order binary.ByteOrder
+	var version Version
+	for _, o := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch o.Uint32(b) {
+		case magic12:
+			version = Version12
+		case magic116:
+			version = Version116
+		case magic118:
+			version = Version118
+		case magic120:
+			version = Version120
+		}
+		if version != VersionUnknown {
+			order = o
+			break
+		}
+	}
+	if version == VersionUnknown || b[4] != 0 || b[5] != 0 {
+		return nil, fmt.Errorf("bad pclntab header magic %#x", b[:6])
+	}
+	quantum, ptrSize := int(b[6]), int(b[7])
+	if quantum < 1 || (ptrSize != 4 && ptrSize != 8) {
+		return nil, fmt.Errorf("bad pclntab header (quantum %d, pointer size %d)", quantum, ptrSize)
+	}
+
+	t := &Table{Version: version, Layout: arch.NewLayout(order, ptrSize), Quantum: quantum, TextStart: textStart}
+	t.data = &obj.Data{Addr: d.Addr, B: b, Layout: t.Layout}
+	r := obj.NewCheckedReader(t.data)
+	r.SetOffset(8)
+	switch version {
+	case Version12:
+		t.nfunc = int(r.Word())
+		t.functab = uint64(8 + ptrSize)
+	case Version116:
+		t.nfunc = int(r.Word())
+		t.nfiles = int(r.Word())
+		t.funcnametab = r.Word()
+		t.cutab = r.Word()
+		t.filetab = r.Word()
+		t.pctab = r.Word()
+		t.functab = r.Word()
+		t.funcdata = t.functab
+	case Version118, Version120:
+		t.nfunc = int(r.Word())
+		t.nfiles = int(r.Word())
+		if start := r.Word(); start != 0 && t.TextStart == 0 {
+			// Go 1.18 and 1.19 recorded the text start.
+			t.TextStart = start
+		}
+		t.funcnametab = r.Word()
+		t.cutab = r.Word()
+		t.filetab = r.Word()
+		t.pctab = r.Word()
+		t.functab = r.Word()
+		t.funcdata = t.functab
+	}
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("reading pclntab header: %w", err)
+	}
+
+	// Check the function table fits.
+	size := uint64(t.nfunc*2+1) * uint64(t.functabFieldSize())
+	if t.nfunc < 0 || t.functab > uint64(len(b)) || size > uint64(len(b))-t.functab {
+		return nil, fmt.Errorf("pclntab function table out of range")
+	}
+
+	if version == Version12 {
+		// The file table offset follows the function table.
+		r.SetOffset(int(t.functab + size))
+		t.filetab = uint64(r.Uint32())
+		r.SetOffset(int(t.filetab))
+		t.nfiles = int(r.Uint32())
+		if err := r.Err(); err != nil {
+			return nil, fmt.Errorf("reading pclntab file table: %w", err)
+		}
+	}
+	for _, off := range []uint64{t.funcnametab, t.cutab, t.filetab, t.pctab} {
+		if off > uint64(len(b)) {
+			return nil, fmt.Errorf("pclntab subtable offset %#x out of range", off)
+		}
+	}
+	if version == Version12 {
+		t.legacyFunc = t.detectLegacyFunc()
+	}
+	return t, nil
+}
+
+// detectLegacyFunc reports whether the _func structures of Go 1.2 table
+// t use the layout from before Go 1.12. The layouts have the same size,
+// but before Go 1.12 the last field is nfuncdata as an int32, while
+// later it's the funcID, flag, and nfuncdata bytes. The pclntab doesn't
+// record which Go release wrote it, so this infers the layout: as an
+// int32, the last field is small for every function only in the old
+// layout, since most functions have funcdata.
+func (t *Table) detectLegacyFunc() bool {
+	var any bool
+	for i := 0; i < t.nfunc; i++ {
+		v := t.Func(i).field(7)
+		if v >= 1<<8 {
+			return false
+		}
+		any = any || v != 0
+	}
+	return any
+}
+
+// Data returns the raw pclntab data.
+func (t *Table) Data() *obj.Data {
+	return t.data
+}
+
+// functabFieldSize returns the size of each field of a function table
+// entry.
+func (t *Table) functabFieldSize() int {
+	if t.Version >= Version118 {
+		return 4
+	}
+	return t.Layout.WordSize()
+}
+
+// functabField returns the i'th field of the function table.
+func (t *Table) functabField(i int) uint64 {
+	sz := t.functabFieldSize()
+	b := t.data.B[t.functab+uint64(i*sz):]
+	if sz == 4 {
+		return uint64(t.Layout.Uint32(b))
+	}
+	return t.Layout.Uint64(b)
+}
+
+// entryPC returns the entry PC of the i'th function. If i is NumFuncs,
+// it returns the end PC of the last function.
+func (t *Table) entryPC(i int) uint64 {
+	pc := t.functabField(2 * i)
+	if t.Version >= Version118 {
+		pc += t.TextStart
+	}
+	return pc
+}
+
+// NumFuncs returns the number of functions in t.
+func (t *Table) NumFuncs() int {
+	return t.nfunc
+}
+
+// Func returns the i'th function in t. Functions are sorted by entry
+// PC.
+func (t *Table) Func(i int) Func {
+	if i < 0 || i >= t.nfunc {
+		panic(fmt.Sprintf("function index %d out of range [0,%d)", i, t.nfunc))
+	}
+	return Func{
+		Index: i,
+		Entry: t.entryPC(i),
+		End:   t.entryPC(i + 1),
+		t:     t,
+		off:   t.funcdata + t.functabField(2*i+1),
+	}
+}
+
+// MinPC returns the entry PC of the first function in t.
+func (t *Table) MinPC() uint64 {
+	return t.entryPC(0)
+}
+
+// MaxPC returns the end PC of the last function in t.
+func (t *Table) MaxPC() uint64 {
+	return t.entryPC(t.nfunc)
+}
+
+// FindFunc returns the function containing pc. It returns false if pc
+// isn't in any function.
+func (t *Table) FindFunc(pc uint64) (Func, bool) {
+	if t.nfunc == 0 || pc < t.MinPC() || pc >= t.MaxPC() {
+		return Func{}, false
+	}
+	var i int
+	if t.find != nil {
+		i = t.find.find(t, pc)
+	} else {
+		i = t.search(pc)
+	}
+	if i >= t.nfunc {
+		return Func{}, false
+	}
+	return t.Func(i), true
+}
+
+// search returns the index of the function containing pc using a
+// binary search of the function table.
+func (t *Table) search(pc uint64) int {
+	return sort.Search(t.nfunc, func(i int) bool {
+		return pc < t.entryPC(i+1)
+	})
+}
+
+// NumFiles returns the number of entries in t's file table.
+func (t *Table) NumFiles() int {
+	if t.Version == Version12 {
+		// Entry 0 is the count itself.
+		return t.nfiles - 1
+	}
+	return t.nfiles
+}
+
+// Files returns the names of all files in t's file table.
+func (t *Table) Files() ([]string, error) {
+	files := make([]string, 0, t.NumFiles())
+	r := obj.NewCheckedReader(t.data)
+	if t.Version == Version12 {
+		for i := 1; i < t.nfiles; i++ {
+			r.SetOffset(int(t.filetab) + 4*i)
+			name, err := t.string(uint64(r.Uint32()))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, name)
+		}
+		return files, r.Err()
+	}
+	r.SetOffset(int(t.filetab))
+	for i := 0; i < t.nfiles; i++ {
+		files = append(files, string(r.CString()))
+	}
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("reading pclntab file table: %w", err)
+	}
+	return files, nil
+}
+
+// string returns the NUL-terminated string at offset off in t.
+func (t *Table) string(off uint64) (string, error) {
+	b := t.data.B
+	if off >= uint64(len(b)) {
+		return "", fmt.Errorf("pclntab string offset %#x out of range", off)
+	}
+	b = b[off:]
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		return string(b[:i]), nil
+	}
+	return "", fmt.Errorf("unterminated pclntab string at %#x", off)
+}
+
+// A Func is a function in a pclntab.
+type Func struct {
+	// Index is the index of this function in the table.
+	Index int
+
+	// Entry and End give the range of PCs [Entry, End) of this
+	// function.
+	Entry, End uint64
+
+	t   *Table
+	off uint64 // Offset of the _func structure in t.data
+}
+
+// Table returns the table containing f.
+func (f Func) Table() *Table {
+	return f.t
+}
+
+// field returns the n'th 32-bit field of f's _func, after the entry
+// field. Field 0 is the name offset.
+func (f Func) field(n int) uint32 {
+	return f.u32(f.entrySize() + 4*n)
+}
+
+// u32 returns the 32-bit value at offset off in f's _func. If the
+// value is out of range, it returns 0.
+func (f Func) u32(off int) uint32 {
+	o := f.off + uint64(off)
+	b := f.t.data.B
+	if o+4 > uint64(len(b)) {
+		return 0
+	}
+	return f.t.Layout.Uint32(b[o:])
+}
+
+// flagsField returns the index of the field containing the funcID,
+// flag, and nfuncdata bytes. In Go 1.2 tables from before Go 1.12, this
+// field is nfuncdata as an int32.
+func (f Func) flagsField() int {
+	switch f.t.Version {
+	case Version12:
+		return 7
+	case Version116, Version118:
+		return 8
+	}
+	return 9
+}
+
+// flagsByte returns byte i of f's flags field.
+func (f Func) flagsByte(i int) uint8 {
+	o := f.off + uint64(f.entrySize()+4*f.flagsField()+i)
+	if o >= uint64(len(f.t.data.B)) {
+		return 0
+	}
+	return f.t.data.B[o]
+}
+
+// fixedSize returns the size of the fixed part of f's _func, which is
+// followed by the pcdata and funcdata arrays.
+func (f Func) fixedSize() int {
+	return f.entrySize() + 4*(f.flagsField()+1)
+}
+
+// entrySize returns the size of the entry field of _func.
+func (f Func) entrySize() int {
+	if f.t.Version >= Version118 {
+		return 4
+	}
+	return f.t.Layout.WordSize()
+}
+
+// Name returns f's name. If the name can't be decoded, it returns "".
+func (f Func) Name() string {
+	name, _ := f.t.string(f.t.funcnametab + uint64(f.field(0)))
+	return name
+}
+
+// Args returns the size of f's arguments and results, or a negative
+// value if unknown.
+func (f Func) Args() int {
+	return int(int32(f.field(1)))
+}
+
+// FuncID returns f's runtime function ID, which identifies certain
+// special runtime functions. It is 0 for ordinary functions, and for
+// all functions in tables from before Go 1.12.
+func (f Func) FuncID() uint8 {
+	if f.t.legacyFunc {
+		return 0
+	}
+	return f.flagsByte(0)
+}
+
+// StartLine returns the line number of the start of f. This is only
+// recorded by Go 1.20 and later, so it returns 0 for older tables.
+func (f Func) StartLine() int {
+	if f.t.Version < Version120 {
+		return 0
+	}
+	return int(int32(f.field(8)))
+}
+
+// File returns the name of the file with index file in f's file
+// table. File indexes are the values of the pcfile table.
+func (f Func) File(file int) (string, error) {
+	t := f.t
+	if file < 0 {
+		return "", fmt.Errorf("bad file index %d", file)
+	}
+	switch t.Version {
+	case Version12:
+		if file == 0 || file >= t.nfiles {
+			return "", fmt.Errorf("file index %d out of range", file)
+		}
+		off := t.filetab + 4*uint64(file)
+		if off+4 > uint64(len(t.data.B)) {
+			return "", fmt.Errorf("file index %d out of range", file)
+		}
+		return t.string(uint64(t.Layout.Uint32(t.data.B[off:])))
+	}
+	// Go 1.16 and later map file indexes to file table offsets through
+	// the compilation unit table.
+	off := t.cutab + 4*(uint64(f.cuOffset())+uint64(file))
+	if off+4 > uint64(len(t.data.B)) {
+		return "", fmt.Errorf("file index %d out of range", file)
+	}
+	fileOff := t.Layout.Uint32(t.data.B[off:])
+	if fileOff == ^uint32(0) {
+		return "", fmt.Errorf("file index %d has no file", file)
+	}
+	return t.string(t.filetab + uint64(fileOff))
+}
+
+// cuOffset returns the index of f's compilation unit in the cutab.
+func (f Func) cuOffset() uint32 {
+	return f.field(7)
+}
+
+// String returns f's name.
+func (f Func) String() string {
+	return f.Name()
+}
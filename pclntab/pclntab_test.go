@@ -0,0 +1,515 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import (
+	"bytes"
+	"compress/gzip"
+	"debug/gosym"
+	"encoding/binary"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/symtab"
+)
+
+const helloGo = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+`
+
+// checkGosym checks that tab agrees with debug/gosym's decoding of the
+// same table.
+func checkGosym(t *testing.T, tab *Table) {
+	t.Helper()
+	gt, err := gosym.NewTable(nil, gosym.NewLineTable(tab.Data().B, tab.TextStart))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tab.NumFuncs() != len(gt.Funcs) {
+		t.Fatalf("want %d functions, got %d", len(gt.Funcs), tab.NumFuncs())
+	}
+	for i, want := range gt.Funcs {
+		f := tab.Func(i)
+		if f.Name() != want.Name || f.Entry != want.Entry || f.End != want.End {
+			t.Errorf("function %d: want %s [%#x,%#x), got %s [%#x,%#x)", i, want.Name, want.Entry, want.End, f.Name(), f.Entry, f.End)
+			continue
+		}
+		// Check lookups at the ends of the function. Zero-sized
+		// functions can't be found.
+		if f.Entry == f.End {
+			continue
+		}
+		for _, pc := range []uint64{f.Entry, f.End - 1} {
+			if got, ok := tab.FindFunc(pc); !ok || got.Index != i {
+				t.Errorf("FindFunc(%#x): want function %d %s, got %d %s", pc, i, f.Name(), got.Index, got.Name())
+			}
+		}
+	}
+	if _, ok := tab.FindFunc(tab.MaxPC()); ok {
+		t.Errorf("FindFunc(MaxPC) unexpectedly succeeded")
+	}
+}
+
+func TestGo(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		flags []string
+	}{
+		{"default", nil},
+		{"pie", []string{"-buildmode=pie"}},
+		{"stripped", []string{"-ldflags=-s -w"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := gotest.Open(t, gotest.Build(t, helloGo, test.flags...))
+			tab, err := NewTable(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tab.Version < Version120 {
+				t.Errorf("want version >= %s, got %s", Version120, tab.Version)
+			}
+			if test.name != "stripped" && tab.find == nil {
+				t.Errorf("findfunctab not found")
+			}
+			checkGosym(t, tab)
+
+			// Check main.main and its file.
+			var main Func
+			for i := 0; i < tab.NumFuncs(); i++ {
+				if f := tab.Func(i); f.Name() == "main.main" {
+					main = f
+				}
+			}
+			if main.t == nil {
+				t.Fatal("main.main not found")
+			}
+			var found bool
+			for i := 0; i < 4 && !found; i++ {
+				name, _ := main.File(i)
+				found = strings.HasSuffix(name, "/hello.go")
+			}
+			if !found {
+				t.Errorf("hello.go not found in file table of main.main")
+			}
+
+			files, err := tab.Files()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tab.NumFiles() {
+				t.Errorf("want %d files, got %d", tab.NumFiles(), len(files))
+			}
+			found = false
+			for _, name := range files {
+				found = found || strings.HasSuffix(name, "runtime/proc.go")
+			}
+			if !found {
+				t.Errorf("runtime/proc.go not found in file table")
+			}
+		})
+	}
+}
+
+func TestSyms(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, helloGo))
+	tab, err := NewTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[uint64][]obj.Sym)
+	for i := obj.SymID(0); i < f.NumSyms(); i++ {
+		if sym := f.Sym(i); sym.Kind == obj.SymText {
+			want[sym.Value] = append(want[sym.Value], sym)
+		}
+	}
+	syms := tab.Syms()
+	if len(syms) != tab.NumFuncs() {
+		t.Fatalf("want %d symbols, got %d", tab.NumFuncs(), len(syms))
+	}
+	for i, sym := range syms {
+		if fn := tab.Func(i); sym.Kind != obj.SymText || sym.Value != fn.Entry || sym.Size != fn.End-fn.Entry {
+			t.Errorf("symbol %d: want %s at [%#x,%#x), got %s %v at [%#x,%#x)", i, fn.Name(), fn.Entry, fn.End, sym.Name, sym.Kind, sym.Value, sym.Value+sym.Size)
+		}
+		// The symbol table names ABI0 functions with a ".abi0"
+		// suffix, and its sizes exclude padding.
+		var found bool
+		for _, w := range want[sym.Value] {
+			if w.Name == sym.Name || w.Name == sym.Name+".abi0" {
+				found = true
+				if sym.Section != w.Section || sym.Size < w.Size {
+					t.Errorf("want %s in %s at [%#x,%#x), got %s at [%#x,%#x)", w.Name, w.Section, w.Value, w.Value+w.Size, sym.Section, sym.Value, sym.Value+sym.Size)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("symbol %s at %#x not in symbol table, have %v", sym.Name, sym.Value, want[sym.Value])
+		}
+	}
+}
+
+func TestRecoverSyms(t *testing.T) {
+	full := gotest.Open(t, gotest.Build(t, helloGo))
+	stripped := gotest.Open(t, gotest.Build(t, helloGo, "-ldflags=-s -w"))
+
+	// Symbols of an unstripped binary aren't recovered.
+	syms := symtab.FileSyms(full)
+	if extra := RecoverSyms(full, syms); extra != nil {
+		t.Errorf("want no recovered symbols, got %d", len(extra))
+	}
+
+	if stripped.NumSyms() != 0 {
+		t.Fatalf("stripped binary has %d symbols", stripped.NumSyms())
+	}
+	tab := symtab.NewTable(RecoverSyms(stripped, nil))
+	// Stripping doesn't move text, so look up functions by their
+	// addresses in the unstripped binary.
+	fullTab := symtab.NewTable(syms)
+	for _, name := range []string{"main.main", "runtime.main", "fmt.Fprintln"} {
+		id := fullTab.Name(name)
+		if id == obj.NoSym {
+			t.Errorf("%s not found in unstripped binary", name)
+			continue
+		}
+		sym := syms[id]
+		for _, addr := range []uint64{sym.Value, sym.Value + sym.Size - 1} {
+			got := tab.Addr(nil, addr)
+			if got == obj.NoSym || tab.Syms()[got].Name != name {
+				t.Errorf("Addr(%#x): want %s, got %v", addr, name, got)
+			}
+		}
+		if got := tab.Name(name); got == obj.NoSym || tab.Syms()[got].Value != sym.Value {
+			t.Errorf("Name(%s): want symbol at %#x, got %v", name, sym.Value, got)
+		}
+	}
+}
+
+// readGzipTable parses the gzipped amd64 pclntab in file path.
+func readGzipTable(t *testing.T, path string) *Table {
+	t.Helper()
+	z, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err := Parse(&obj.Data{B: b, Layout: arch.AMD64.Layout}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tab
+}
+
+func TestGo111(t *testing.T) {
+	// The .gopclntab section of a Go 1.11.13 linux/amd64 hello world
+	// built from /tmp/hello.go. Go 1.11 uses the Go 1.2 table format, but
+	// with the _func layout from before Go 1.12.
+	tab := readGzipTable(t, "testdata/go111.pclntab.gz")
+	if tab.Version != Version12 {
+		t.Errorf("want version %s, got %s", Version12, tab.Version)
+	}
+	if !tab.legacyFunc {
+		t.Errorf("want pre-Go 1.12 _func layout")
+	}
+	checkGosym(t, tab)
+
+	f, ok := tab.FindFunc(0x485030)
+	if !ok || f.Name() != "main.main" || f.Entry != 0x485030 {
+		t.Errorf("FindFunc(0x485030): want main.main, got %s at %#x", f.Name(), f.Entry)
+	}
+	if file, line, err := f.FileLine(f.Entry); file != "/tmp/hello.go" || line != 5 || err != nil {
+		t.Errorf("FileLine(%#x): want /tmp/hello.go:5, got %s:%d, %v", f.Entry, file, line, err)
+	}
+	// nfuncdata is a full int32 in this layout. Reading it as the Go 1.12
+	// bytes would give 0 funcdata and a funcID of 4.
+	if n := f.NumFuncData(); n != 4 {
+		t.Errorf("NumFuncData: want 4, got %d", n)
+	}
+	if id := f.FuncID(); id != 0 {
+		t.Errorf("FuncID: want 0, got %d", id)
+	}
+	for i := 0; i < f.NumFuncData(); i++ {
+		if _, _, err := f.FuncData(i); err != nil {
+			t.Errorf("FuncData(%d): %v", i, err)
+		}
+	}
+}
+
+func TestGo115(t *testing.T) {
+	// A Go 1.15 pclntab for a hello world program built in /tmp/hello.go,
+	// from the Go distribution's debug/gosym tests.
+	tab := readGzipTable(t, "testdata/go115.pclntab.gz")
+	if tab.Version != Version12 {
+		t.Errorf("want version %s, got %s", Version12, tab.Version)
+	}
+	if tab.legacyFunc {
+		t.Errorf("want Go 1.12 _func layout")
+	}
+	checkGosym(t, tab)
+
+	f, ok := tab.FindFunc(0x105c280)
+	if !ok || f.Name() != "main.main" || f.Entry != 0x105c280 {
+		t.Errorf("FindFunc(0x105c280): want main.main, got %s at %#x", f.Name(), f.Entry)
+	}
+	if file, line, err := f.FileLine(f.Entry); file != "/tmp/hello.go" || line != 3 || err != nil {
+		t.Errorf("FileLine(%#x): want /tmp/hello.go:3, got %s:%d, %v", f.Entry, file, line, err)
+	}
+	files, err := tab.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for i, name := range files {
+		if name == "/tmp/hello.go" {
+			found = true
+			// File indexes in Go 1.2 tables start at 1.
+			if got, err := f.File(i + 1); got != name || err != nil {
+				t.Errorf("File(%d): want %s, got %s, %v", i+1, name, got, err)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("/tmp/hello.go not found in file table")
+	}
+}
+
+func TestGo117(t *testing.T) {
+	// The .gopclntab section of the Go 1.17 hello world binary from the
+	// Go distribution's debug/buildinfo tests, which was built with
+	// -trimpath in module example.com/go117. Go 1.17 uses the Go 1.16
+	// table format.
+	tab := readGzipTable(t, "testdata/go117.pclntab.gz")
+	if tab.Version != Version116 {
+		t.Errorf("want version %s, got %s", Version116, tab.Version)
+	}
+	checkGosym(t, tab)
+
+	f, ok := tab.FindFunc(0x455380)
+	if !ok || f.Name() != "main.main" || f.Entry != 0x455380 {
+		t.Errorf("FindFunc(0x455380): want main.main, got %s at %#x", f.Name(), f.Entry)
+	}
+	if file, line, err := f.FileLine(f.Entry); file != "example.com/go117/main.go" || line != 7 || err != nil {
+		t.Errorf("FileLine(%#x): want example.com/go117/main.go:7, got %s:%d, %v", f.Entry, file, line, err)
+	}
+	files, err := tab.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != tab.NumFiles() {
+		t.Errorf("want %d files, got %d", tab.NumFiles(), len(files))
+	}
+
+	// Check the SP delta table of a function with a frame.
+	var found bool
+	for i := 0; i < tab.NumFuncs(); i++ {
+		f := tab.Func(i)
+		if f.Name() != "runtime.main" {
+			continue
+		}
+		found = true
+		if delta, err := f.SPDelta(f.Entry); delta != 0 || err != nil {
+			t.Errorf("SPDelta(%#x): want 0, got %d, %v", f.Entry, delta, err)
+		}
+		var max int32
+		var pc uint64
+		it := f.PCSP()
+		for it.Next() {
+			if it.Value > max {
+				max, pc = it.Value, it.Low
+			}
+		}
+		if err := it.Err(); err != nil || max <= 0 {
+			t.Fatalf("want positive SP delta in runtime.main, got %d, %v", max, err)
+		}
+		if delta, err := f.SPDelta(pc); delta != int(max) || err != nil {
+			t.Errorf("SPDelta(%#x): want %d, got %d, %v", pc, max, delta, err)
+		}
+	}
+	if !found {
+		t.Errorf("runtime.main not found")
+	}
+}
+
+func TestGo118(t *testing.T) {
+	// The .gopclntab section of a Go 1.18.10 linux/amd64 hello world
+	// built with -trimpath in module example.com/go118.
+	tab := readGzipTable(t, "testdata/go118.pclntab.gz")
+	if tab.Version != Version118 {
+		t.Errorf("want version %s, got %s", Version118, tab.Version)
+	}
+	if tab.TextStart != 0x401000 {
+		t.Errorf("want text start 0x401000, got %#x", tab.TextStart)
+	}
+	checkGosym(t, tab)
+
+	f, ok := tab.FindFunc(0x47e100)
+	if !ok || f.Name() != "main.main" || f.Entry != 0x47e100 {
+		t.Errorf("FindFunc(0x47e100): want main.main, got %s at %#x", f.Name(), f.Entry)
+	}
+	if file, line, err := f.FileLine(f.Entry); file != "example.com/go118/hello.go" || line != 5 || err != nil {
+		t.Errorf("FileLine(%#x): want example.com/go118/hello.go:5, got %s:%d, %v", f.Entry, file, line, err)
+	}
+	if n := f.NumFuncData(); n != 4 {
+		t.Errorf("NumFuncData: want 4, got %d", n)
+	}
+
+	g, ok := tab.FindFunc(0x432240)
+	if !ok || g.Name() != "runtime.main" {
+		t.Fatalf("FindFunc(0x432240): want runtime.main, got %s", g.Name())
+	}
+	if id := g.FuncID(); id == 0 {
+		t.Errorf("runtime.main: want nonzero FuncID")
+	}
+	var max int32
+	it := g.PCSP()
+	for it.Next() {
+		if it.Value > max {
+			max = it.Value
+		}
+	}
+	if err := it.Err(); err != nil || max <= 0 {
+		t.Errorf("want positive SP delta in runtime.main, got %d, %v", max, err)
+	}
+}
+
+// synthFunc describes a function in a synthesized pclntab.
+type synthFunc struct {
+	name       string
+	entry, end uint64
+	file       string
+}
+
+// synthesize encodes a pclntab of the given version. Functions must be
+// contiguous and each function is in its own compilation unit. This
+// only encodes the parts of the table needed to test layout decoding.
+func synthesize(version Version, order binary.ByteOrder, ptrSize int, textStart uint64, funcs []synthFunc) []byte {
+	var magic uint32
+	switch version {
+	case Version116:
+		magic = magic116
+	case Version118:
+		magic = magic118
+	case Version120:
+		magic = magic120
+	}
+	putWord := func(b *bytes.Buffer, v uint64) {
+		if ptrSize == 4 {
+			binary.Write(b, order, uint32(v))
+		} else {
+			binary.Write(b, order, v)
+		}
+	}
+	fieldSize := ptrSize
+	if version >= Version118 {
+		fieldSize = 4
+	}
+	putField := func(b *bytes.Buffer, v uint64) {
+		if fieldSize == 4 {
+			binary.Write(b, order, uint32(v))
+		} else {
+			binary.Write(b, order, v)
+		}
+	}
+
+	var names, cutab, filetab, functab, funcs_ bytes.Buffer
+	funcOff := uint64((2*len(funcs) + 1) * fieldSize)
+	for i, fn := range funcs {
+		entry := fn.entry
+		if version >= Version118 {
+			entry -= textStart
+		}
+		putField(&functab, entry)
+		putField(&functab, funcOff+uint64(funcs_.Len()))
+
+		// _func
+		putField(&funcs_, entry)
+		fields := []uint32{uint32(names.Len()), 0, 0, 0, 0, 0, 0, uint32(i)}
+		if version >= Version120 {
+			fields = append(fields, 0) // startLine
+		}
+		fields = append(fields, 0) // funcID, flag, nfuncdata
+		binary.Write(&funcs_, order, fields)
+
+		names.WriteString(fn.name + "\x00")
+		binary.Write(&cutab, order, uint32(filetab.Len()))
+		filetab.WriteString(fn.file + "\x00")
+	}
+	last := funcs[len(funcs)-1].end
+	if version >= Version118 {
+		last -= textStart
+	}
+	putField(&functab, last)
+
+	nhdr := 8 + 7*ptrSize
+	if version >= Version118 {
+		nhdr += ptrSize
+	}
+	var hdr bytes.Buffer
+	binary.Write(&hdr, order, magic)
+	hdr.Write([]byte{0, 0, 1, byte(ptrSize)})
+	putWord(&hdr, uint64(len(funcs)))
+	putWord(&hdr, uint64(len(funcs)))
+	if version >= Version118 {
+		putWord(&hdr, 0) // textStart
+	}
+	off := uint64(nhdr)
+	for _, tab := range []*bytes.Buffer{&names, &cutab, &filetab} {
+		putWord(&hdr, off)
+		off += uint64(tab.Len())
+	}
+	putWord(&hdr, off) // pctab (empty)
+	putWord(&hdr, off) // functab
+	for _, tab := range []*bytes.Buffer{&names, &cutab, &filetab, &functab, &funcs_} {
+		hdr.Write(tab.Bytes())
+	}
+	return hdr.Bytes()
+}
+
+// TestSynthesized checks layout decoding for table versions, byte
+// orders, and pointer sizes that aren't covered by real tables.
+func TestSynthesized(t *testing.T) {
+	const textStart = 0x401000
+	funcs := []synthFunc{
+		{"runtime.a", 0x401000, 0x401020, "a.go"},
+		{"main.b", 0x401020, 0x401100, "b.go"},
+		{"main.c", 0x401100, 0x402140, "c.go"},
+	}
+	for _, version := range []Version{Version116, Version118, Version120} {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			for _, ptrSize := range []int{4, 8} {
+				b := synthesize(version, order, ptrSize, textStart, funcs)
+				tab, err := Parse(&obj.Data{B: b, Layout: arch.AMD64.Layout}, textStart)
+				if err != nil {
+					t.Errorf("%s/%s/%d: %v", version, order, ptrSize, err)
+					continue
+				}
+				if tab.Version != version || tab.Layout != arch.NewLayout(order, ptrSize) {
+					t.Errorf("%s/%s/%d: got version %s, layout %v", version, order, ptrSize, tab.Version, tab.Layout)
+				}
+				checkGosym(t, tab)
+				for i, want := range funcs {
+					f := tab.Func(i)
+					if file, err := f.File(0); file != want.file || err != nil {
+						t.Errorf("%s/%s/%d: %s: want file %s, got %s, %v", version, order, ptrSize, want.name, want.file, file, err)
+					}
+				}
+			}
+		}
+	}
+}
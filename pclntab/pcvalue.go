@@ -0,0 +1,192 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import "fmt"
+
+// Indexes of the runtime's pc-value tables in a function's PCData.
+const (
+	PCDataUnsafePoint   = 0
+	PCDataStackMapIndex = 1
+	PCDataInlTreeIndex  = 2
+	PCDataArgLiveIndex  = 3
+)
+
+// A PCIter iterates over the entries of a pc-value table. Each entry
+// gives the value of the table over a range of PCs. A PCIter starts
+// before the first entry, so Next must be called to advance to the
+// first entry:
+//
+//	for it := f.PCSP(); it.Next(); {
+//		... it.Low, it.High, it.Value ...
+//	}
+//	if err := it.Err(); err != nil { ... }
+type PCIter struct {
+	// Low and High give the range of PCs [Low, High) of the current
+	// entry.
+	Low, High uint64
+
+	// Value is the value of the table over [Low, High).
+	Value int32
+
+	quantum uint64
+	p       []byte
+	first   bool
+	err     error
+}
+
+// newPCIter returns an iterator over the pc-value table at offset off
+// in t's pctab, for a function starting at entry. If off is 0, the
+// table is empty.
+func (t *Table) newPCIter(off uint32, entry uint64) *PCIter {
+	it := &PCIter{High: entry, Value: -1, quantum: uint64(t.Quantum), first: true}
+	if off == 0 {
+		return it
+	}
+	start := t.pctab + uint64(off)
+	if start >= uint64(len(t.data.B)) {
+		it.err = fmt.Errorf("pc-value table offset %#x out of range", off)
+		return it
+	}
+	it.p = t.data.B[start:]
+	return it
+}
+
+// Next advances to the next entry in the table. It returns false at
+// the end of the table or if there's a decoding error.
+func (it *PCIter) Next() bool {
+	if it.err != nil || it.p == nil {
+		return false
+	}
+	uvdelta, ok := it.uvarint()
+	if !ok {
+		return false
+	}
+	if uvdelta == 0 && !it.first {
+		it.p = nil
+		return false
+	}
+	it.first = false
+	// The value delta is zig-zag encoded.
+	vdelta := int32(uvdelta >> 1)
+	if uvdelta&1 != 0 {
+		vdelta = ^vdelta
+	}
+	pcdelta, ok := it.uvarint()
+	if !ok {
+		return false
+	}
+	it.Value += vdelta
+	it.Low = it.High
+	it.High += uint64(pcdelta) * it.quantum
+	return true
+}
+
+func (it *PCIter) uvarint() (uint32, bool) {
+	var v uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		if len(it.p) == 0 {
+			break
+		}
+		b := it.p[0]
+		it.p = it.p[1:]
+		v |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, true
+		}
+	}
+	it.err = fmt.Errorf("malformed pc-value table")
+	it.p = nil
+	return 0, false
+}
+
+// Err returns the first decoding error encountered by it, or nil.
+func (it *PCIter) Err() error {
+	return it.err
+}
+
+// find returns the value of the table at pc. It returns false if pc
+// isn't covered by the table.
+func (it *PCIter) find(pc uint64) (int32, bool, error) {
+	for it.Next() {
+		if it.Low <= pc && pc < it.High {
+			return it.Value, true, nil
+		}
+	}
+	return 0, false, it.Err()
+}
+
+// PCSP returns an iterator over f's SP delta table. The value is the
+// number of bytes the function has pushed on the stack at each PC,
+// relative to its entry. This does not include the return address.
+func (f Func) PCSP() *PCIter {
+	return f.t.newPCIter(f.field(3), f.Entry)
+}
+
+// PCFile returns an iterator over f's file table. The value is a file
+// index, which can be passed to File.
+func (f Func) PCFile() *PCIter {
+	return f.t.newPCIter(f.field(4), f.Entry)
+}
+
+// PCLine returns an iterator over f's line number table.
+func (f Func) PCLine() *PCIter {
+	return f.t.newPCIter(f.field(5), f.Entry)
+}
+
+// NumPCData returns the number of auxiliary pc-value tables of f.
+func (f Func) NumPCData() int {
+	return int(f.field(6))
+}
+
+// PCData returns an iterator over f's i'th auxiliary pc-value table,
+// such as PCDataStackMapIndex. If f doesn't have table i, the iterator
+// is empty.
+func (f Func) PCData(i int) *PCIter {
+	if i < 0 || i >= f.NumPCData() {
+		return f.t.newPCIter(0, f.Entry)
+	}
+	return f.t.newPCIter(f.u32(f.fixedSize()+4*i), f.Entry)
+}
+
+// SPDelta returns the SP delta of f at pc, as given by the PCSP table.
+func (f Func) SPDelta(pc uint64) (int, error) {
+	v, ok, err := f.PCSP().find(pc)
+	if err == nil && !ok {
+		err = fmt.Errorf("PC %#x not in SP delta table of %s", pc, f.Name())
+	}
+	return int(v), err
+}
+
+// FileLine returns the file and line number of pc in f.
+func (f Func) FileLine(pc uint64) (string, int, error) {
+	fileIdx, ok, err := f.PCFile().find(pc)
+	if err == nil && !ok {
+		err = fmt.Errorf("PC %#x not in file table of %s", pc, f.Name())
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	line, ok, err := f.PCLine().find(pc)
+	if err == nil && !ok {
+		err = fmt.Errorf("PC %#x not in line table of %s", pc, f.Name())
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	file, err := f.File(int(fileIdx))
+	return file, int(line), err
+}
+
+// PCDataValue returns the value of f's i'th auxiliary pc-value table
+// at pc. If the table doesn't cover pc, it returns -1, which the
+// runtime treats as "no value".
+func (f Func) PCDataValue(i int, pc uint64) (int32, error) {
+	v, ok, err := f.PCData(i).find(pc)
+	if !ok {
+		v = -1
+	}
+	return v, err
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import (
+	"debug/gosym"
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/cfi"
+	"github.com/aclements/go-obj/internal/gotest"
+)
+
+const funcsGo = `package main
+
+import "fmt"
+
+type obj struct {
+	p *int
+	x [8]int
+}
+
+//go:noinline
+func use(o *obj) int { return *o.p + o.x[1] }
+
+func inlined(x int) int {
+	return x * 3
+}
+
+//go:noinline
+func frame(n int) int {
+	var o obj
+	o.p = &n
+	o.x[1] = use(&o)
+	return inlined(o.x[1]) + use(&o)
+}
+
+func main() { fmt.Println(frame(5)) }
+`
+
+func findFunc(t *testing.T, tab *Table, name string) Func {
+	t.Helper()
+	for i := 0; i < tab.NumFuncs(); i++ {
+		if f := tab.Func(i); f.Name() == name {
+			return f
+		}
+	}
+	t.Fatalf("function %s not found", name)
+	return Func{}
+}
+
+func TestPCValue(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, funcsGo))
+	tab, err := NewTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gt, err := gosym.NewTable(nil, gosym.NewLineTable(tab.Data().B, tab.TextStart))
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugFrame, err := cfi.NewDebugFrame(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < tab.NumFuncs(); i++ {
+		fn := tab.Func(i)
+		if !strings.HasPrefix(fn.Name(), "main.") && !strings.HasPrefix(fn.Name(), "runtime.m") {
+			continue
+		}
+
+		// Check the line table against debug/gosym.
+		it := fn.PCLine()
+		for it.Next() {
+			file, line, err := fn.FileLine(it.Low)
+			if err != nil {
+				t.Errorf("%s: FileLine(%#x): %v", fn.Name(), it.Low, err)
+				break
+			}
+			wantFile, wantLine, _ := gt.PCToLine(it.Low)
+			if file != wantFile || line != wantLine || int(it.Value) != line {
+				t.Errorf("%s: FileLine(%#x): want %s:%d, got %s:%d (table %d)", fn.Name(), it.Low, wantFile, wantLine, file, line, it.Value)
+			}
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%s: %v", fn.Name(), err)
+		}
+
+		// Check the SP delta table against the CFA offset from the
+		// linker's .debug_frame, which is derived from it.
+		fde, err := debugFrame.FindFDE(fn.Entry)
+		if err != nil || fde == nil {
+			t.Errorf("%s: no FDE: %v", fn.Name(), err)
+			continue
+		}
+		it = fn.PCSP()
+		var n int
+		for it.Next() {
+			if it.Low >= fde.High {
+				break
+			}
+			row, err := fde.RowAt(it.Low)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := row.CFA.Offset - 8; int64(it.Value) != want {
+				t.Errorf("%s: SP delta at %#x: want %d, got %d", fn.Name(), it.Low, want, it.Value)
+			}
+			if sp, err := fn.SPDelta(it.Low); err != nil || sp != int(it.Value) {
+				t.Errorf("%s: SPDelta(%#x): want %d, got %d, %v", fn.Name(), it.Low, it.Value, sp, err)
+			}
+			n++
+		}
+		if n == 0 || it.Err() != nil {
+			t.Errorf("%s: empty or bad SP delta table: %v", fn.Name(), it.Err())
+		}
+
+		// Check stack map indexes are in range.
+		for _, fd := range []int{FuncDataArgsPointerMaps, FuncDataLocalsPointerMaps} {
+			m, err := fn.StackMap(fd)
+			if err != nil {
+				t.Errorf("%s: StackMap(%d): %v", fn.Name(), fd, err)
+				continue
+			}
+			if m == nil {
+				continue
+			}
+			for it := fn.PCData(PCDataStackMapIndex); it.Next(); {
+				if int(it.Value) >= len(m.Bitmaps) {
+					t.Errorf("%s: stack map index %d at %#x out of range", fn.Name(), it.Value, it.Low)
+				}
+			}
+		}
+	}
+}
+
+func TestFuncData(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, funcsGo))
+	tab, err := NewTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := findFunc(t, tab, "main.frame")
+
+	// Find the inlined call to main.inlined.
+	tree, err := fn.InlineTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || tree[0].Name != "main.inlined" {
+		t.Fatalf("want inline tree [main.inlined], got %+v", tree)
+	}
+	var found bool
+	for it := fn.PCData(PCDataInlTreeIndex); it.Next(); {
+		if it.Value < 0 {
+			continue
+		}
+		found = true
+		stack, err := fn.InlineStack(it.Low)
+		if err != nil || len(stack) != 1 || stack[0].Name != "main.inlined" {
+			t.Errorf("InlineStack(%#x): want [main.inlined], got %+v, %v", it.Low, stack, err)
+		}
+		if _, line, _ := fn.FileLine(it.Low); line != 14 {
+			t.Errorf("line at %#x: want 14, got %d", it.Low, line)
+		}
+		if _, line, _ := fn.FileLine(stack[0].ParentPC); line != 22 {
+			t.Errorf("line at parent PC %#x: want 22, got %d", stack[0].ParentPC, line)
+		}
+	}
+	if !found {
+		t.Errorf("no inlined code found in main.frame")
+	}
+	if stack, err := fn.InlineStack(fn.Entry); stack != nil || err != nil {
+		t.Errorf("InlineStack(entry): want nil, got %+v, %v", stack, err)
+	}
+
+	// o is address-taken, so it's a stack object with one pointer.
+	objs, err := fn.StackObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || objs[0].Size != 72 || objs[0].PtrBytes != 8 {
+		t.Errorf("want 1 72-byte stack object with 8 pointer bytes, got %+v", objs)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pclntab
+
+import "github.com/aclements/go-obj/obj"
+
+// Syms returns a SymText symbol for each function in t, in function
+// table order. This recovers function symbols from binaries that have
+// been stripped of their symbol tables, since the pclntab must still
+// name every function for the runtime.
+//
+// Symbol names are the pclntab's function names. These differ from the
+// symbol table's names for ABI0 functions, which the symbol table
+// marks with a ".abi0" suffix.
+//
+// Each symbol's size spans from the function's entry PC to the next
+// function's entry PC, so it includes any padding between functions.
+// If t was created by NewTable, each symbol's Section is the section
+// of t's file that contains the function. Otherwise, Section is nil.
+func (t *Table) Syms() []obj.Sym {
+	syms := make([]obj.Sym, t.nfunc)
+	var sect *obj.Section
+	for i := range syms {
+		fn := t.Func(i)
+		if t.file != nil && (sect == nil || fn.Entry < sect.Addr || fn.Entry >= sect.Addr+sect.Size) {
+			sect = t.file.ResolveAddr(fn.Entry)
+		}
+		syms[i] = obj.Sym{
+			Name:    fn.Name(),
+			Section: sect,
+			Value:   fn.Entry,
+			Size:    fn.End - fn.Entry,
+			Kind:    obj.SymText,
+		}
+	}
+	return syms
+}
+
+// RecoverSyms returns function symbols recovered from f's pclntab if f
+// has been stripped of its static symbol table, or nil otherwise. syms
+// are f's own symbols. RecoverSyms considers f stripped if it has a
+// pclntab but syms has no text symbols within the pclntab's PC range.
+//
+// Recovering symbols is best-effort, so RecoverSyms returns nil if it
+// can't read f's pclntab. It can be passed to symtab.NewSpaceExtra.
+func RecoverSyms(f obj.File, syms []obj.Sym) []obj.Sym {
+	t, err := NewTable(f)
+	if err != nil || t.NumFuncs() == 0 {
+		return nil
+	}
+	min, max := t.MinPC(), t.MaxPC()
+	for _, sym := range syms {
+		if sym.Kind == obj.SymText && sym.Value >= min && sym.Value < max {
+			return nil
+		}
+	}
+	return t.Syms()
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtype
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aclements/go-obj/moduledata"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/symtab"
+)
+
+// An Itab is a decoded runtime itab, which records how a concrete type
+// implements an interface type. The linker creates an itab for each
+// conversion from a concrete type to a non-empty interface type.
+type Itab struct {
+	// Addr is the address of the itab.
+	Addr uint64
+
+	// Inter is the interface type and Type is the concrete type.
+	Inter, Type *Type
+
+	// Hash is a copy of Type.Hash.
+	Hash uint32
+
+	// Fun lists the entry PCs of Type's implementations of Inter's
+	// methods, in the order of Inter.IMethods. It is nil if Type
+	// doesn't implement Inter.
+	Fun []uint64
+}
+
+// String returns a description of it in the form "Type,Inter", which
+// matches the itab's symbol name.
+func (it *Itab) String() string {
+	return it.Type.Name + "," + it.Inter.Name
+}
+
+// Methods returns the symbols of it's method implementations, in the
+// order of Fun, by looking up each entry PC in syms. Entries that don't
+// correspond to a symbol are obj.NoSym.
+func (it *Itab) Methods(syms *symtab.Table) []obj.SymID {
+	ids := make([]obj.SymID, len(it.Fun))
+	for i, pc := range it.Fun {
+		ids[i] = syms.Addr(nil, pc)
+	}
+	return ids
+}
+
+// Itab decodes the itab at address addr.
+func (t *Table) Itab(addr uint64) (*Itab, error) {
+	it, _, err := t.itab(addr)
+	return it, err
+}
+
+// itab decodes the itab at addr and also returns its size.
+func (t *Table) itab(addr uint64) (*Itab, uint64, error) {
+	ws := uint64(t.layout.WordSize())
+	// inter, _type, hash (padded), fun[0]
+	d, err := t.data(addr, 4*ws)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading itab at %#x: %w", addr, err)
+	}
+	r := obj.NewReader(d)
+	inter, typ := t.ptr(r), t.ptr(r)
+	it := &Itab{Addr: addr, Hash: r.Uint32()}
+	if it.Inter, err = t.Type(inter); err != nil {
+		return nil, 0, err
+	}
+	if it.Type, err = t.Type(typ); err != nil {
+		return nil, 0, err
+	}
+	if it.Inter.Kind != Interface {
+		return nil, 0, fmt.Errorf("itab at %#x has non-interface type %s", addr, it.Inter)
+	}
+	n := uint64(len(it.Inter.IMethods))
+	if n == 0 {
+		return it, 4 * ws, nil
+	}
+	d, err = t.data(addr+3*ws, n*ws)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading itab at %#x: %w", addr, err)
+	}
+	r = obj.NewReader(d)
+	if fun0 := t.ptr(r); fun0 != 0 {
+		// A 0 first method means the type doesn't implement the
+		// interface.
+		it.Fun = make([]uint64, n)
+		it.Fun[0] = fun0
+		for i := range it.Fun[1:] {
+			it.Fun[i+1] = t.ptr(r)
+		}
+		return it, (3 + n) * ws, nil
+	}
+	return it, 4 * ws, nil
+}
+
+// Itabs returns all of the itabs in the module, sorted by address.
+// These are listed in the module's itablinks or, for newer layouts,
+// stored contiguously after the type descriptors. If the module's
+// object file has symbols, Itabs also includes the go:itab.* symbols.
+func (t *Table) Itabs() ([]*Itab, error) {
+	md := t.ModuleData
+	seen := make(map[uint64]bool)
+	var itabs []*Itab
+	add := func(addr uint64) (uint64, error) {
+		it, size, err := t.itab(addr)
+		if err != nil {
+			return 0, err
+		}
+		if !seen[addr] {
+			seen[addr] = true
+			itabs = append(itabs, it)
+		}
+		return size, nil
+	}
+
+	if md.Version >= moduledata.Version127 {
+		end := md.Types + md.ItabOffset + md.ItabSize
+		for addr := md.Types + md.ItabOffset; addr < end; {
+			size, err := add(addr)
+			if err != nil {
+				return nil, err
+			}
+			addr += size
+		}
+	} else {
+		for _, addr := range md.Itablinks {
+			if _, err := add(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.file != nil {
+		for i := obj.SymID(0); i < t.file.NumSyms(); i++ {
+			sym := t.file.Sym(i)
+			// Before Go 1.20, itab symbols were named go.itab.*.
+			if sym.Kind == obj.SymUndef || !(strings.HasPrefix(sym.Name, "go:itab.") || strings.HasPrefix(sym.Name, "go.itab.")) {
+				continue
+			}
+			if _, err := add(sym.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(itabs, func(i, j int) bool {
+		return itabs[i].Addr < itabs[j].Addr
+	})
+	return itabs, nil
+}
+
+// Implementations returns the concrete types in the module that
+// implement interface type inter, sorted by address. This includes the
+// types of inter's itabs, and the types whose method sets include all
+// of inter's methods. Methods the linker determined are unreachable
+// can't be matched, so types that implement inter only through such
+// methods are omitted unless they have an itab.
+func (t *Table) Implementations(inter *Type) ([]*Type, error) {
+	if inter.Kind != Interface {
+		return nil, fmt.Errorf("type %s is not an interface", inter)
+	}
+	impls := make(map[*Type]bool)
+	itabs, err := t.Itabs()
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range itabs {
+		if it.Inter == inter && it.Fun != nil {
+			impls[it.Type] = true
+		}
+	}
+
+	types, err := t.Types()
+	if err != nil {
+		return nil, err
+	}
+	for _, typ := range types {
+		if typ.Kind != Interface && implements(typ, inter) {
+			impls[typ] = true
+		}
+	}
+
+	list := make([]*Type, 0, len(impls))
+	for typ := range impls {
+		list = append(list, typ)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Addr < list[j].Addr
+	})
+	return list, nil
+}
+
+// implements reports whether typ's method set includes all of inter's
+// methods.
+func implements(typ, inter *Type) bool {
+	if len(typ.Methods) < len(inter.IMethods) {
+		return false
+	}
+	methods := make(map[string]*Method, len(typ.Methods))
+	for i := range typ.Methods {
+		methods[typ.Methods[i].Name] = &typ.Methods[i]
+	}
+	for _, im := range inter.IMethods {
+		m := methods[im.Name]
+		if m == nil || m.PkgPath != im.PkgPath || m.Type == nil || m.Type != im.Type {
+			return false
+		}
+	}
+	return true
+}
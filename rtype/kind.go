@@ -0,0 +1,135 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtype
+
+import "fmt"
+
+// A Kind is the kind of a Go type. These match reflect.Kind.
+type Kind uint8
+
+const (
+	Invalid Kind = iota
+	Bool
+	Int
+	Int8
+	Int16
+	Int32
+	Int64
+	Uint
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	Uintptr
+	Float32
+	Float64
+	Complex64
+	Complex128
+	Array
+	Chan
+	Func
+	Interface
+	Map
+	Pointer
+	Slice
+	String
+	Struct
+	UnsafePointer
+)
+
+var kindNames = []string{
+	Invalid:       "invalid",
+	Bool:          "bool",
+	Int:           "int",
+	Int8:          "int8",
+	Int16:         "int16",
+	Int32:         "int32",
+	Int64:         "int64",
+	Uint:          "uint",
+	Uint8:         "uint8",
+	Uint16:        "uint16",
+	Uint32:        "uint32",
+	Uint64:        "uint64",
+	Uintptr:       "uintptr",
+	Float32:       "float32",
+	Float64:       "float64",
+	Complex64:     "complex64",
+	Complex128:    "complex128",
+	Array:         "array",
+	Chan:          "chan",
+	Func:          "func",
+	Interface:     "interface",
+	Map:           "map",
+	Pointer:       "ptr",
+	Slice:         "slice",
+	String:        "string",
+	Struct:        "struct",
+	UnsafePointer: "unsafe.Pointer",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Bits of the kind byte of a type descriptor. Newer releases store
+// these flags in TFlag instead, and don't use GC programs.
+const (
+	kindMask        = 1<<5 - 1
+	kindDirectIface = 1 << 5
+	kindGCProg      = 1 << 6
+)
+
+// A TFlag is a set of flags in a type descriptor.
+type TFlag uint8
+
+const (
+	// TFlagUncommon indicates the descriptor is followed by an
+	// uncommon type record, which records the package path and methods.
+	TFlagUncommon TFlag = 1 << 0
+
+	// TFlagExtraStar indicates the type's name has an extra "*" prefix
+	// that should be removed.
+	TFlagExtraStar TFlag = 1 << 1
+
+	// TFlagNamed indicates the type has a name.
+	TFlagNamed TFlag = 1 << 2
+
+	// TFlagRegularMemory indicates equality and hashing can treat the
+	// type as a single region of memory.
+	TFlagRegularMemory TFlag = 1 << 3
+
+	// TFlagGCMaskOnDemand indicates the type's pointer bitmap is
+	// computed at run time. Go 1.24 and later.
+	TFlagGCMaskOnDemand TFlag = 1 << 4
+
+	// TFlagDirectIface indicates the type is stored directly in the
+	// data word of an interface value. Older releases record this in
+	// the kind byte.
+	TFlagDirectIface TFlag = 1 << 5
+)
+
+// A ChanDir is the direction of a channel type.
+type ChanDir int
+
+const (
+	RecvDir ChanDir = 1 << iota
+	SendDir
+	BothDir = RecvDir | SendDir
+)
+
+func (d ChanDir) String() string {
+	switch d {
+	case RecvDir:
+		return "<-chan"
+	case SendDir:
+		return "chan<-"
+	case BothDir:
+		return "chan"
+	}
+	return fmt.Sprintf("ChanDir(%d)", int(d))
+}
@@ -0,0 +1,855 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rtype decodes the Go runtime's type descriptors, which
+// describe the types a Go program may convert to interfaces, inspect
+// with reflection, or allocate on the heap, and its itabs, which
+// record how concrete types implement interfaces.
+//
+// Type descriptors are found through the module data, either from its
+// typelinks or, in newer releases, by walking the contiguous type
+// descriptors, and by following references between descriptors.
+//
+// The layout of type descriptors changes between Go releases. This
+// supports the layouts used by Go 1.16 and later.
+//
+// Reference: internal/abi/type.go (runtime/type.go before Go 1.21) and
+// reflect/type.go in the Go source tree.
+package rtype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/moduledata"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/pclntab"
+)
+
+// latestMinor is the newest Go 1.x release whose layouts this package
+// knows. Development versions are assumed to use these layouts.
+const latestMinor = 27
+
+// A Table decodes the type descriptors of a Go module.
+//
+// A Table caches decoded types, so it's not safe for concurrent use.
+type Table struct {
+	// ModuleData is the module containing the types.
+	ModuleData *moduledata.ModuleData
+
+	// GoVersion is the version of Go that built the module.
+	GoVersion string
+
+	mem    moduledata.Memory
+	file   obj.File // For resolving symbolic relocations, or nil
+	layout arch.Layout
+	minor  int // Go 1.x minor version
+
+	types map[uint64]*Type
+}
+
+// NewTable returns a Table for the types of the Go executable f.
+func NewTable(f obj.File) (*Table, error) {
+	md, err := moduledata.Find(f)
+	if err != nil {
+		return nil, err
+	}
+	v, err := moduledata.GoVersion(f)
+	if err != nil {
+		return nil, err
+	}
+	t, err := New(moduledata.FileMemory(f), md, v)
+	if err != nil {
+		return nil, err
+	}
+	t.file = f
+	return t, nil
+}
+
+// New returns a Table for the types of module md, which reads type
+// descriptors from mem. goVersion is the version of Go that built the
+// module, as returned by moduledata.GoVersion, and determines the
+// layout of type descriptors.
+func New(mem moduledata.Memory, md *moduledata.ModuleData, goVersion string) (*Table, error) {
+	minor, ok := goMinor(goVersion)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized Go version %q", goVersion)
+	}
+	if minor < 16 {
+		return nil, fmt.Errorf("unsupported Go version %s", goVersion)
+	}
+	return &Table{
+		ModuleData: md,
+		GoVersion:  goVersion,
+		mem:        mem,
+		layout:     md.Layout,
+		minor:      minor,
+		types:      make(map[uint64]*Type),
+	}, nil
+}
+
+// goMinor returns the minor version of Go version string v, such as
+// 21 for "go1.21.3".
+func goMinor(v string) (int, bool) {
+	if strings.HasPrefix(v, "devel ") {
+		v = strings.TrimPrefix(v, "devel ")
+		if !strings.HasPrefix(v, "go1.") {
+			// Old-style development version.
+			return latestMinor, true
+		}
+	}
+	if !strings.HasPrefix(v, "go1.") {
+		return 0, false
+	}
+	v = v[len("go1."):]
+	i := 0
+	for i < len(v) && '0' <= v[i] && v[i] <= '9' {
+		i++
+	}
+	minor, err := strconv.Atoi(v[:i])
+	return minor, err == nil
+}
+
+// A Type is a decoded type descriptor.
+type Type struct {
+	// Addr is the address of the type descriptor.
+	Addr uint64
+
+	// Kind is the kind of this type.
+	Kind Kind
+
+	// TFlag records extra information about this type.
+	TFlag TFlag
+
+	// Size is the size of a value of this type in bytes.
+	Size uint64
+
+	// PtrBytes is the length of the prefix of a value of this type
+	// that can contain pointers.
+	PtrBytes uint64
+
+	// Hash is the runtime's hash of this type.
+	Hash uint32
+
+	// Align and FieldAlign are the alignment of a variable and a
+	// struct field of this type.
+	Align, FieldAlign int
+
+	// Name is the string form of this type, such as "main.T" or
+	// "[]int".
+	Name string
+
+	// PkgPath is the import path of the package defining this type. It
+	// is "" for unnamed types and predeclared types.
+	PkgPath string
+
+	// Methods lists the methods of this type, sorted by name. For
+	// interface types, see IMethods.
+	Methods []Method
+
+	// DirectIface indicates that values of this type are stored
+	// directly in the data word of an interface value.
+	DirectIface bool
+
+	// GCData is the address of the type's pointer bitmap or GC
+	// program. See GCMask and GCProgram.
+	GCData uint64
+
+	// GCProg indicates GCData points to a GC program rather than a
+	// bitmap. This is only used before Go 1.24.
+	GCProg bool
+
+	// PtrToThis is the type of pointers to this type, or nil if that
+	// type isn't in the binary.
+	PtrToThis *Type
+
+	// Elem is the element type of an array, channel, map, pointer, or
+	// slice type.
+	Elem *Type
+
+	// Key is the key type of a map type.
+	Key *Type
+
+	// Len is the length of an array type.
+	Len uint64
+
+	// Dir is the direction of a channel type.
+	Dir ChanDir
+
+	// Fields lists the fields of a struct type.
+	Fields []StructField
+
+	// In and Out list the parameter and result types of a function
+	// type. If Variadic is set, the last parameter is a "..."
+	// parameter, and its type is a slice type.
+	In, Out  []*Type
+	Variadic bool
+
+	// IMethods lists the methods of an interface type, sorted by name.
+	IMethods []IMethod
+
+	t        *Table
+	baseSize uint64 // Size of the kind-specific descriptor
+	mcount   int    // Total number of methods
+}
+
+// A Method is a method of a non-interface type.
+type Method struct {
+	// Name is the method's name. PkgPath is the import path of the
+	// package that qualifies an unexported name.
+	Name, PkgPath string
+
+	// Exported indicates the method's name is exported.
+	Exported bool
+
+	// Type is the method's function type without the receiver, or nil
+	// if the linker determined the method is unreachable.
+	Type *Type
+
+	// IFn is the entry PC of the method's implementation used for
+	// interface calls, which take a pointer receiver. TFn is the entry
+	// PC of the implementation used for direct calls. These are 0 if
+	// the method is unreachable.
+	IFn, TFn uint64
+}
+
+// An IMethod is a method of an interface type.
+type IMethod struct {
+	// Name is the method's name. PkgPath is the import path of the
+	// package that qualifies an unexported name.
+	Name, PkgPath string
+
+	// Exported indicates the method's name is exported.
+	Exported bool
+
+	// Type is the method's function type.
+	Type *Type
+}
+
+// A StructField is a field of a struct type.
+type StructField struct {
+	// Name is the field's name. For embedded fields, this is the name
+	// of the type. PkgPath is the import path of the package that
+	// qualifies an unexported name.
+	Name, PkgPath string
+
+	// Tag is the field's tag string.
+	Tag string
+
+	// Type is the type of the field.
+	Type *Type
+
+	// Offset is the byte offset of the field in the struct.
+	Offset uint64
+
+	// Exported indicates the field's name is exported.
+	Exported bool
+
+	// Embedded indicates the field is an embedded field.
+	Embedded bool
+}
+
+// String returns typ's name.
+func (typ *Type) String() string {
+	return typ.Name
+}
+
+// Named reports whether typ is a named type.
+func (typ *Type) Named() bool {
+	return typ.TFlag&TFlagNamed != 0
+}
+
+// A typeError is an error decoding the type descriptor at addr.
+type typeError struct {
+	addr uint64
+	err  error
+}
+
+func (e *typeError) Error() string {
+	return fmt.Sprintf("type descriptor at %#x: %v", e.addr, e.err)
+}
+
+func (e *typeError) Unwrap() error {
+	return e.err
+}
+
+// Type returns the type whose descriptor is at address addr. It also
+// decodes all types reachable from that type.
+func (t *Table) Type(addr uint64) (*Type, error) {
+	if addr == 0 {
+		return nil, fmt.Errorf("nil type descriptor")
+	}
+	return t.typ(addr)
+}
+
+func (t *Table) typ(addr uint64) (*Type, error) {
+	if addr == 0 {
+		return nil, nil
+	}
+	if typ, ok := t.types[addr]; ok {
+		return typ, nil
+	}
+	// Add typ to the cache before decoding it to break cycles.
+	typ := &Type{Addr: addr, t: t}
+	t.types[addr] = typ
+	if err := t.decode(typ); err != nil {
+		delete(t.types, addr)
+		if _, ok := err.(*typeError); !ok {
+			err = &typeError{addr, err}
+		}
+		return nil, err
+	}
+	return typ, nil
+}
+
+// typeOff returns the type at offset off from the module's types.
+func (t *Table) typeOff(off int32) (*Type, error) {
+	if off == 0 || off == -1 {
+		// -1 is the linker's sentinel for unreachable types.
+		return nil, nil
+	}
+	return t.typ(t.ModuleData.Types + uint64(int64(off)))
+}
+
+// Types returns all of the types in the module, sorted by address. This
+// includes the types in the module's typelinks or, for newer layouts,
+// the equivalent contiguous type descriptors, and all types reachable
+// from those types.
+func (t *Table) Types() ([]*Type, error) {
+	md := t.ModuleData
+	if md.Version >= moduledata.Version127 {
+		ws := uint64(t.layout.WordSize())
+		// The descriptors start one word after runtime.types.
+		end := md.Types + md.TypeDescLen
+		for addr := md.Types + ws; addr < end; {
+			addr = (addr + ws - 1) &^ (ws - 1)
+			typ, err := t.typ(addr)
+			if err != nil {
+				return nil, err
+			}
+			addr += typ.descriptorSize()
+		}
+	} else {
+		for _, addr := range md.Typelinks {
+			if _, err := t.typ(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	types := make([]*Type, 0, len(t.types))
+	for _, typ := range t.types {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Addr < types[j].Addr
+	})
+	return types, nil
+}
+
+// data reads size bytes at addr.
+func (t *Table) data(addr, size uint64) (*obj.Data, error) {
+	d, err := t.mem.Data(addr, size)
+	if err != nil {
+		return nil, err
+	}
+	d.Layout = t.layout
+	return d, nil
+}
+
+// dataUpTo reads up to size bytes at addr, stopping early at the end of
+// the section containing addr.
+func (t *Table) dataUpTo(addr, size uint64) (*obj.Data, error) {
+	d, err := t.data(addr, size)
+	if e, ok := err.(*obj.ErrOutOfRange); ok && e.Low <= addr && addr < e.High {
+		return t.data(addr, e.High-addr)
+	}
+	return d, err
+}
+
+// ptr reads a pointer from r, applying any relocation.
+func (t *Table) ptr(r *obj.Reader) uint64 {
+	sym, val := r.Ptr()
+	if sym != obj.NoSym && t.file != nil {
+		val += t.file.Sym(sym).Value
+	}
+	return val
+}
+
+// headerSize returns the size of the common type descriptor.
+func (t *Table) headerSize() uint64 {
+	// size, ptrdata, hash, tflag, align, fieldAlign, kind, equal,
+	// gcdata, str, ptrToThis
+	return 4*uint64(t.layout.WordSize()) + 16
+}
+
+// mapTypeSize returns the size of a map type descriptor.
+func (t *Table) mapTypeSize() uint64 {
+	ws := uint64(t.layout.WordSize())
+	// key, elem, bucket or group, hasher
+	size := t.headerSize() + 4*ws
+	switch {
+	case t.minor < 24:
+		// keysize, valuesize uint8; bucketsize uint16; flags uint32
+		return size + 8
+	case t.minor < 27:
+		// groupSize, slotSize, elemOff; flags uint32
+		return size + 3*ws + ws
+	}
+	// groupSize, keysOff, keyStride, elemsOff, elemStride, elemOff;
+	// flags uint32
+	return size + 6*ws + ws
+}
+
+// decode decodes typ's descriptor.
+func (t *Table) decode(typ *Type) error {
+	ws := uint64(t.layout.WordSize())
+	md := t.ModuleData
+	d, err := t.data(typ.Addr, t.headerSize())
+	if err != nil {
+		return err
+	}
+	r := obj.NewReader(d)
+	typ.Size = r.Word()
+	typ.PtrBytes = r.Word()
+	typ.Hash = r.Uint32()
+	typ.TFlag = TFlag(r.Uint8())
+	typ.Align = int(r.Uint8())
+	typ.FieldAlign = int(r.Uint8())
+	kind := r.Uint8()
+	t.ptr(r) // equal
+	typ.GCData = t.ptr(r)
+	str, ptrToThis := r.Int32(), r.Int32()
+
+	typ.Kind = Kind(kind & kindMask)
+	typ.DirectIface = kind&kindDirectIface != 0 || typ.TFlag&TFlagDirectIface != 0
+	typ.GCProg = kind&kindGCProg != 0
+	n, err := t.name(md.Types + uint64(int64(str)))
+	if err != nil {
+		return fmt.Errorf("reading name: %w", err)
+	}
+	typ.Name = n.name
+	if typ.TFlag&TFlagExtraStar != 0 {
+		typ.Name = strings.TrimPrefix(typ.Name, "*")
+	}
+
+	// Decode the kind-specific fields. Function parameters and
+	// interface methods are decoded below, once we know the uncommon
+	// type.
+	base := typ.Addr + t.headerSize()
+	var size, nparams, imethods, nimethods, fields, nfields uint64
+	var interfacePkgPath, structPkgPath uint64
+	switch typ.Kind {
+	case Array:
+		size = 3 * ws
+		d, err = t.data(base, size)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		typ.Elem, err = t.typ(t.ptr(r))
+		t.ptr(r) // slice
+		typ.Len = r.Word()
+	case Chan:
+		size = 2 * ws
+		d, err = t.data(base, size)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		typ.Elem, err = t.typ(t.ptr(r))
+		typ.Dir = ChanDir(r.Word())
+	case Func:
+		size = ws // inCount, outCount, padded
+		d, err = t.data(base, 4)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		in, out := r.Uint16(), r.Uint16()
+		typ.Variadic = out&(1<<15) != 0
+		out &^= 1 << 15
+		typ.In = make([]*Type, in)
+		typ.Out = make([]*Type, out)
+		nparams = uint64(in) + uint64(out)
+	case Interface:
+		size = 4 * ws
+		d, err = t.data(base, size)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		interfacePkgPath = t.ptr(r)
+		imethods, nimethods = t.ptr(r), r.Word()
+	case Map:
+		size = t.mapTypeSize() - t.headerSize()
+		d, err = t.data(base, 2*ws)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		typ.Key, err = t.typ(t.ptr(r))
+		if err == nil {
+			typ.Elem, err = t.typ(t.ptr(r))
+		}
+	case Pointer, Slice:
+		size = ws
+		d, err = t.data(base, size)
+		if err != nil {
+			return err
+		}
+		typ.Elem, err = t.typ(t.ptr(obj.NewReader(d)))
+	case Struct:
+		size = 4 * ws
+		d, err = t.data(base, size)
+		if err != nil {
+			return err
+		}
+		r = obj.NewReader(d)
+		structPkgPath = t.ptr(r)
+		fields, nfields = t.ptr(r), r.Word()
+	}
+	if err != nil {
+		return err
+	}
+	typ.baseSize = t.headerSize() + size
+
+	// Decode the uncommon type, which follows the kind-specific
+	// descriptor.
+	next := typ.Addr + typ.baseSize
+	if typ.TFlag&TFlagUncommon != 0 {
+		if err := t.decodeUncommon(typ, next); err != nil {
+			return err
+		}
+		next += 16
+	}
+
+	switch {
+	case typ.Kind == Func:
+		d, err := t.data(next, nparams*ws)
+		if err != nil {
+			return err
+		}
+		r := obj.NewReader(d)
+		for i := range typ.In {
+			if typ.In[i], err = t.typ(t.ptr(r)); err != nil {
+				return err
+			}
+		}
+		for i := range typ.Out {
+			if typ.Out[i], err = t.typ(t.ptr(r)); err != nil {
+				return err
+			}
+		}
+	case typ.Kind == Interface:
+		pkgPath, err := t.namePtr(interfacePkgPath)
+		if err != nil {
+			return err
+		}
+		if typ.IMethods, err = t.imethods(imethods, nimethods, pkgPath); err != nil {
+			return err
+		}
+	case typ.Kind == Struct:
+		pkgPath, err := t.namePtr(structPkgPath)
+		if err != nil {
+			return err
+		}
+		if typ.Fields, err = t.fields(fields, nfields, pkgPath); err != nil {
+			return err
+		}
+	}
+
+	typ.PtrToThis, err = t.typeOff(ptrToThis)
+	return err
+}
+
+// decodeUncommon decodes the uncommon type at addr, which records typ's
+// package path and methods.
+func (t *Table) decodeUncommon(typ *Type, addr uint64) error {
+	d, err := t.data(addr, 16)
+	if err != nil {
+		return err
+	}
+	r := obj.NewReader(d)
+	pkgPath := r.Int32()
+	mcount := r.Uint16()
+	r.Uint16() // xcount
+	moff := r.Uint32()
+
+	md := t.ModuleData
+	if pkgPath != 0 {
+		n, err := t.name(md.Types + uint64(int64(pkgPath)))
+		if err != nil {
+			return err
+		}
+		typ.PkgPath = n.name
+	}
+	typ.mcount = int(mcount)
+	if mcount == 0 {
+		return nil
+	}
+	d, err = t.data(addr+uint64(moff), uint64(mcount)*16)
+	if err != nil {
+		return err
+	}
+	r = obj.NewReader(d)
+	typ.Methods = make([]Method, mcount)
+	for i := range typ.Methods {
+		m := &typ.Methods[i]
+		nameOff, mtyp, ifn, tfn := r.Int32(), r.Int32(), r.Int32(), r.Int32()
+		n, err := t.name(md.Types + uint64(int64(nameOff)))
+		if err != nil {
+			return err
+		}
+		m.Name, m.PkgPath, m.Exported = n.name, n.pkgPath, n.exported
+		if !m.Exported && m.PkgPath == "" {
+			m.PkgPath = typ.PkgPath
+		}
+		if m.Type, err = t.typeOff(mtyp); err != nil {
+			return err
+		}
+		if ifn != -1 {
+			m.IFn = md.TextAddr(uint32(ifn))
+		}
+		if tfn != -1 {
+			m.TFn = md.TextAddr(uint32(tfn))
+		}
+	}
+	return nil
+}
+
+func (t *Table) imethods(addr, n uint64, pkgPath string) ([]IMethod, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if n > 1<<16 {
+		return nil, fmt.Errorf("too many interface methods (%d)", n)
+	}
+	d, err := t.data(addr, n*8)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	ms := make([]IMethod, n)
+	for i := range ms {
+		m := &ms[i]
+		nameOff, typ := r.Int32(), r.Int32()
+		nm, err := t.name(t.ModuleData.Types + uint64(int64(nameOff)))
+		if err != nil {
+			return nil, err
+		}
+		m.Name, m.PkgPath, m.Exported = nm.name, nm.pkgPath, nm.exported
+		if !m.Exported && m.PkgPath == "" {
+			m.PkgPath = pkgPath
+		}
+		if m.Type, err = t.typeOff(typ); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+func (t *Table) fields(addr, n uint64, pkgPath string) ([]StructField, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if n > 1<<24 {
+		return nil, fmt.Errorf("too many struct fields (%d)", n)
+	}
+	ws := uint64(t.layout.WordSize())
+	d, err := t.data(addr, n*3*ws)
+	if err != nil {
+		return nil, err
+	}
+	r := obj.NewReader(d)
+	fs := make([]StructField, n)
+	for i := range fs {
+		f := &fs[i]
+		nameAddr, typ, off := t.ptr(r), t.ptr(r), r.Word()
+		nm, err := t.namePtrFull(nameAddr)
+		if err != nil {
+			return nil, err
+		}
+		f.Name, f.PkgPath, f.Tag, f.Exported = nm.name, nm.pkgPath, nm.tag, nm.exported
+		if !f.Exported && f.PkgPath == "" {
+			f.PkgPath = pkgPath
+		}
+		if t.minor >= 19 {
+			f.Offset, f.Embedded = off, nm.embedded
+		} else {
+			// Before Go 1.19, the low bit of the offset indicated an
+			// embedded field.
+			f.Offset, f.Embedded = off>>1, off&1 != 0
+		}
+		if f.Type, err = t.typ(typ); err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+
+// namePtr returns the name string at addr, or "" if addr is 0.
+func (t *Table) namePtr(addr uint64) (string, error) {
+	n, err := t.namePtrFull(addr)
+	return n.name, err
+}
+
+func (t *Table) namePtrFull(addr uint64) (name, error) {
+	if addr == 0 {
+		return name{}, nil
+	}
+	return t.name(addr)
+}
+
+// A name is a decoded runtime name, which records a name, an optional
+// tag, and flags.
+type name struct {
+	name, tag string
+	pkgPath   string
+	exported  bool
+	embedded  bool
+}
+
+// Bits of the first byte of a name.
+const (
+	nameExported = 1 << 0
+	nameHasTag   = 1 << 1
+	nameHasPkg   = 1 << 2
+	nameEmbedded = 1 << 3 // Go 1.19 and later
+)
+
+// name decodes the name at addr.
+func (t *Table) name(addr uint64) (name, error) {
+	// Read the flags and name length, then the name and tag length,
+	// then the tag and package path.
+	d, err := t.dataUpTo(addr, 1+binary.MaxVarintLen32)
+	if err != nil {
+		return name{}, err
+	}
+	flags := d.B[0]
+	nameOff, nameLen, err := t.nameLen(d.B, 1)
+	if err != nil {
+		return name{}, err
+	}
+	end := nameOff + nameLen
+	tagOff := end
+	if flags&nameHasTag != 0 {
+		if d, err = t.dataUpTo(addr, uint64(end+binary.MaxVarintLen32)); err != nil {
+			return name{}, err
+		}
+		var tagLen int
+		tagOff, tagLen, err = t.nameLen(d.B, end)
+		if err != nil {
+			return name{}, err
+		}
+		end = tagOff + tagLen
+	}
+	pkgEnd := end
+	if flags&nameHasPkg != 0 {
+		pkgEnd += 4
+	}
+	if d, err = t.data(addr, uint64(pkgEnd)); err != nil {
+		return name{}, err
+	}
+	n := name{
+		name:     string(d.B[nameOff : nameOff+nameLen]),
+		exported: flags&nameExported != 0,
+		embedded: t.minor >= 19 && flags&nameEmbedded != 0,
+	}
+	if flags&nameHasTag != 0 {
+		n.tag = string(d.B[tagOff:end])
+	}
+	if flags&nameHasPkg != 0 {
+		off := int32(t.layout.Uint32(d.B[end:]))
+		pkg, err := t.name(t.ModuleData.Types + uint64(int64(off)))
+		if err != nil {
+			return name{}, err
+		}
+		n.pkgPath = pkg.name
+	}
+	return n, nil
+}
+
+// nameLen decodes the length of a string in a name starting at offset
+// off in b. It returns the offset of the string and its length. Go 1.17
+// and later encode lengths as varints. Earlier releases use 2-byte
+// big-endian lengths.
+func (t *Table) nameLen(b []byte, off int) (int, int, error) {
+	if off > len(b) {
+		return 0, 0, fmt.Errorf("name truncated")
+	}
+	if t.minor >= 17 {
+		n, k := binary.Uvarint(b[off:])
+		if k <= 0 || n > 1<<30 {
+			return 0, 0, fmt.Errorf("bad name length")
+		}
+		return off + k, int(n), nil
+	}
+	if off+2 > len(b) {
+		return 0, 0, fmt.Errorf("name truncated")
+	}
+	return off + 2, int(binary.BigEndian.Uint16(b[off:])), nil
+}
+
+// descriptorSize returns the total size of typ's descriptor, including
+// its uncommon type and trailing arrays.
+func (typ *Type) descriptorSize() uint64 {
+	ws := uint64(typ.t.layout.WordSize())
+	size := typ.baseSize
+	if typ.TFlag&TFlagUncommon != 0 {
+		size += 16
+	}
+	switch typ.Kind {
+	case Func:
+		size += uint64(len(typ.In)+len(typ.Out)) * ws
+	case Interface:
+		size += uint64(len(typ.IMethods)) * 8
+	case Struct:
+		size += uint64(len(typ.Fields)) * 3 * ws
+	}
+	return size + uint64(typ.mcount)*16
+}
+
+// GCMask returns typ's pointer bitmap. Bit i indicates whether word i
+// of a value of typ may contain a pointer. The bitmap covers PtrBytes.
+// It returns an error if typ uses a GC program or its bitmap is
+// computed at run time.
+func (typ *Type) GCMask() (pclntab.BitVector, error) {
+	if typ.PtrBytes == 0 {
+		return pclntab.BitVector{}, nil
+	}
+	if typ.GCProg {
+		return pclntab.BitVector{}, fmt.Errorf("type %s uses a GC program", typ)
+	}
+	if typ.TFlag&TFlagGCMaskOnDemand != 0 {
+		return pclntab.BitVector{}, fmt.Errorf("pointer bitmap of type %s is computed at run time", typ)
+	}
+	n := int(typ.PtrBytes / uint64(typ.t.layout.WordSize()))
+	d, err := typ.t.data(typ.GCData, uint64(n+7)/8)
+	if err != nil {
+		return pclntab.BitVector{}, err
+	}
+	return pclntab.BitVector{N: n, Bits: d.B}, nil
+}
+
+// GCProgram returns typ's GC program, which constructs its pointer
+// bitmap. It returns an error if typ doesn't use a GC program.
+func (typ *Type) GCProgram() ([]byte, error) {
+	if !typ.GCProg {
+		return nil, fmt.Errorf("type %s doesn't use a GC program", typ)
+	}
+	d, err := typ.t.data(typ.GCData, 4)
+	if err != nil {
+		return nil, err
+	}
+	n := d.Layout.Uint32(d.B)
+	d, err = typ.t.data(typ.GCData+4, uint64(n))
+	if err != nil {
+		return nil, err
+	}
+	return d.B, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtype
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/pclntab"
+	"github.com/aclements/go-obj/symtab"
+)
+
+const typesGo = `package main
+
+import "fmt"
+
+type Point struct {
+	X, Y int
+	name string ` + "`json:\"name\"`" + `
+	*Inner
+}
+
+type Inner struct{ P *int }
+
+type Shape interface {
+	Area() float64
+	Name() string
+}
+
+func (p *Point) Area() float64 { return float64(p.X * p.Y) }
+func (p *Point) Name() string  { return p.name }
+
+type M map[string][]byte
+
+func (m M) Len() int { return len(m) }
+
+type Lener interface{ Len() int }
+
+// Call methods dynamically so the linker keeps them.
+//
+//go:noinline
+func call(s Shape, l Lener) { fmt.Println(s.Area(), s.Name(), l.Len()) }
+
+var sink []interface{}
+
+func main() {
+	var s Shape = &Point{X: 1}
+	sink = append(sink, []Shape{s}, M{}, make(chan<- int), [3]uint16{}, func(int, ...string) error { return nil })
+	call(s, M{})
+}
+`
+
+func TestTypes(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		flags []string
+	}{
+		{"default", nil},
+		{"stripped", []string{"-ldflags=-s -w"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := gotest.Open(t, gotest.Build(t, typesGo, test.flags...))
+			tab, err := NewTable(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			types, err := tab.Types()
+			if err != nil {
+				t.Fatal(err)
+			}
+			byName := make(map[string]*Type)
+			for _, typ := range types {
+				if typ.Name == "" || typ.Kind == Invalid || typ.Kind > UnsafePointer {
+					t.Errorf("bad type at %#x: %q kind %v", typ.Addr, typ.Name, typ.Kind)
+				}
+				byName[typ.Name] = typ
+			}
+			get := func(name string, kind Kind) *Type {
+				t.Helper()
+				typ := byName[name]
+				if typ == nil {
+					t.Fatalf("type %s not found", name)
+				}
+				if typ.Kind != kind {
+					t.Errorf("%s: want kind %v, got %v", name, kind, typ.Kind)
+				}
+				return typ
+			}
+			pt, err := pclntab.NewTable(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			funcName := func(pc uint64) string {
+				fn, ok := pt.FindFunc(pc)
+				if !ok || fn.Entry != pc {
+					return ""
+				}
+				return fn.Name()
+			}
+
+			point := get("main.Point", Struct)
+			if point.Size != 40 || point.PtrBytes != 40 || point.PkgPath != "main" || !point.Named() {
+				t.Errorf("main.Point: got size %d, ptr bytes %d, pkg path %q", point.Size, point.PtrBytes, point.PkgPath)
+			}
+			type field struct {
+				name, typ, tag     string
+				off                uint64
+				exported, embedded bool
+			}
+			want := []field{
+				{"X", "int", "", 0, true, false},
+				{"Y", "int", "", 8, true, false},
+				{"name", "string", `json:"name"`, 16, false, false},
+				{"Inner", "*main.Inner", "", 32, true, true},
+			}
+			if len(point.Fields) != len(want) {
+				t.Fatalf("main.Point: want %d fields, got %d", len(want), len(point.Fields))
+			}
+			for i, w := range want {
+				f := point.Fields[i]
+				got := field{f.Name, f.Type.Name, f.Tag, f.Offset, f.Exported, f.Embedded}
+				if got != w {
+					t.Errorf("main.Point field %d: want %+v, got %+v", i, w, got)
+				}
+			}
+			if f := point.Fields[2]; f.PkgPath != "main" {
+				t.Errorf("main.Point.name: want pkg path main, got %q", f.PkgPath)
+			}
+			if mask, err := point.GCMask(); err != nil {
+				t.Error(err)
+			} else if mask.N != 5 || mask.Bits[0] != 0x14 {
+				t.Errorf("main.Point: want GC mask 10100, got %d bits %x", mask.N, mask.Bits)
+			}
+
+			ptr := get("*main.Point", Pointer)
+			if ptr.Elem != point || point.PtrToThis != ptr {
+				t.Errorf("*main.Point: bad Elem %v or PtrToThis %v", ptr.Elem, point.PtrToThis)
+			}
+			if len(ptr.Methods) != 2 || ptr.Methods[0].Name != "Area" || ptr.Methods[1].Name != "Name" {
+				t.Fatalf("*main.Point: want methods Area, Name, got %+v", ptr.Methods)
+			}
+			if m := ptr.Methods[0]; m.Type == nil || m.Type.Name != "func() float64" || funcName(m.IFn) != "main.(*Point).Area" || funcName(m.TFn) != "main.(*Point).Area" {
+				t.Errorf("*main.Point.Area: got type %v, IFn %#x %s, TFn %#x", m.Type, m.IFn, funcName(m.IFn), m.TFn)
+			}
+
+			shape := get("main.Shape", Interface)
+			if len(shape.IMethods) != 2 || shape.IMethods[0].Name != "Area" || shape.IMethods[1].Name != "Name" {
+				t.Fatalf("main.Shape: want methods Area, Name, got %+v", shape.IMethods)
+			}
+			if m := shape.IMethods[1]; m.Type.Name != "func() string" || !m.Exported {
+				t.Errorf("main.Shape.Name: got type %v, exported %v", m.Type, m.Exported)
+			}
+
+			m := get("main.M", Map)
+			if m.Key.Name != "string" || m.Elem.Name != "[]uint8" || m.Elem.Elem.Kind != Uint8 {
+				t.Errorf("main.M: got key %v, elem %v", m.Key, m.Elem)
+			}
+			if len(m.Methods) != 1 || m.Methods[0].Name != "Len" || funcName(m.Methods[0].TFn) != "main.M.Len" {
+				t.Errorf("main.M: want method Len, got %+v", m.Methods)
+			}
+
+			ch := get("chan<- int", Chan)
+			if ch.Dir != SendDir || ch.Elem.Kind != Int {
+				t.Errorf("chan<- int: got dir %v, elem %v", ch.Dir, ch.Elem)
+			}
+
+			arr := get("[3]uint16", Array)
+			if arr.Len != 3 || arr.Size != 6 || arr.Elem.Name != "uint16" || arr.PtrBytes != 0 {
+				t.Errorf("[3]uint16: got len %d, size %d, elem %v", arr.Len, arr.Size, arr.Elem)
+			}
+
+			fn := get("func(int, ...string) error", Func)
+			if !fn.Variadic || len(fn.In) != 2 || fn.In[0].Name != "int" || fn.In[1].Name != "[]string" || len(fn.Out) != 1 || fn.Out[0].Kind != Interface {
+				t.Errorf("func(int, ...string) error: got in %v, out %v, variadic %v", fn.In, fn.Out, fn.Variadic)
+			}
+			if !fn.DirectIface {
+				t.Errorf("func(int, ...string) error: want DirectIface")
+			}
+		})
+	}
+}
+
+func TestGoMinor(t *testing.T) {
+	for v, want := range map[string]int{
+		"go1.16":                  16,
+		"go1.21.3":                21,
+		"go1.22rc1":               22,
+		"devel go1.27-abcdef Tue": 27,
+		"devel +abcdef":           latestMinor,
+		"weird":                   -1,
+	} {
+		got, ok := goMinor(v)
+		if !ok {
+			got = -1
+		}
+		if got != want {
+			t.Errorf("goMinor(%q): want %d, got %d", v, want, got)
+		}
+	}
+}
+
+func TestItabs(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, typesGo))
+	tab, err := NewTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itabs, err := tab.Itabs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := make([]obj.Sym, f.NumSyms())
+	for i := range syms {
+		syms[i] = f.Sym(obj.SymID(i))
+	}
+	st := symtab.NewTable(syms)
+
+	var found bool
+	for _, it := range itabs {
+		if it.Inter.Kind != Interface || (it.Fun != nil && len(it.Fun) != len(it.Inter.IMethods)) {
+			t.Errorf("bad itab %s at %#x", it, it.Addr)
+		}
+		if it.String() != "*main.Point,main.Shape" {
+			continue
+		}
+		found = true
+		if it.Hash != it.Type.Hash {
+			t.Errorf("%s: want hash %#x, got %#x", it, it.Type.Hash, it.Hash)
+		}
+		var names []string
+		for _, id := range it.Methods(st) {
+			if id == obj.NoSym {
+				names = append(names, "?")
+			} else {
+				names = append(names, syms[id].Name)
+			}
+		}
+		if want := "main.(*Point).Area main.(*Point).Name"; strings.Join(names, " ") != want {
+			t.Errorf("%s: want methods %s, got %s", it, want, names)
+		}
+	}
+	if !found {
+		t.Fatal("itab for *main.Point,main.Shape not found")
+	}
+
+	types, err := tab.Types()
+	if err != nil {
+		t.Fatal(err)
+	}
+	impls := make(map[string][]string)
+	for _, typ := range types {
+		if typ.Name != "main.Shape" && typ.Name != "main.Lener" {
+			continue
+		}
+		list, err := tab.Implementations(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, impl := range list {
+			impls[typ.Name] = append(impls[typ.Name], impl.Name)
+		}
+	}
+	if got := impls["main.Shape"]; len(got) != 1 || got[0] != "*main.Point" {
+		t.Errorf("want main.Shape implemented by *main.Point, got %v", got)
+	}
+	var foundM bool
+	for _, name := range impls["main.Lener"] {
+		foundM = foundM || name == "main.M"
+	}
+	if !foundM {
+		t.Errorf("want main.Lener implemented by main.M, got %v", impls["main.Lener"])
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package symname parses the names of Go symbols into their components.
+//
+// The Go toolchain names a function symbol by its package path,
+// followed by its receiver type, name, and enclosing closures, each
+// separated by ".". For example, in
+//
+//	example.com/x/y.(*T[go.shape.int]).Method.func2.1
+//
+// the package path is "example.com/x/y", the receiver is *T
+// instantiated with the shape type go.shape.int, the method is
+// "Method", and the symbol is the closure "1" nested in the closure
+// "func2" in Method.
+//
+// Reference: cmd/internal/objabi/path.go and cmd/compile/internal/ir in
+// the Go source tree.
+package symname
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aclements/go-obj/obj"
+)
+
+// A Name is a parsed Go symbol name.
+type Name struct {
+	// Package is the package path of the symbol, with any escaping
+	// undone. It is "" for symbols that don't have a package, such as
+	// C symbols. Symbols generated by the linker and compiler, such as
+	// go:itab.* and type:* symbols, have package "go" or "type".
+	Package string
+
+	// Receiver is the name of the method receiver type, without any
+	// type arguments, or "" if the symbol isn't a method.
+	Receiver string
+
+	// PtrReceiver indicates the receiver is a pointer type.
+	PtrReceiver bool
+
+	// TypeArgs lists the type arguments of the receiver type or, if
+	// the symbol isn't a method, the function. For generic code, these
+	// are typically shape types such as "go.shape.int".
+	TypeArgs []string
+
+	// Func is the name of the function or method. For symbols with
+	// package "go" or "type", this is the rest of the symbol name.
+	Func string
+
+	// Closures lists the closures enclosing the symbol, from
+	// outermost to innermost, such as "func2" and "1". The symbol is
+	// the last closure. This also includes the "deferwrap" and
+	// "gowrap" functions the compiler generates for go and defer
+	// statements, and the "range" functions it generates for
+	// range-over-func loop bodies.
+	Closures []string
+
+	// MethodValue indicates the symbol is a wrapper for a method value
+	// (marked with a "-fm" suffix).
+	MethodValue bool
+
+	// ABI0 indicates the symbol is an ABI0 wrapper or assembly
+	// function (marked with a ".abi0" suffix).
+	ABI0 bool
+}
+
+// Parse parses Go symbol name sym. It never fails, but symbols that
+// aren't Go symbols may not parse into meaningful components.
+//
+// Parse also accepts names in assembly syntax, which use "·" in place
+// of "." and "∕" in place of "/".
+//
+// Value receivers are ambiguous with enclosing functions: "p.T.M" is
+// method M of type T, while "p.F.func1" is a closure in function F.
+// Parse treats an element as a closure if it has the form of a
+// compiler-generated closure name.
+func Parse(sym string) Name {
+	var n Name
+	sym = strings.NewReplacer("·", ".", "∕", "/").Replace(sym)
+	if strings.HasSuffix(sym, ".abi0") {
+		n.ABI0 = true
+		sym = sym[:len(sym)-len(".abi0")]
+	}
+
+	// Compiler- and linker-generated symbols. Go 1.20 changed the
+	// "go." and "type." prefixes to "go:" and "type:". The old "go."
+	// prefix is ambiguous with package paths such as
+	// "go.uber.org/zap", so it's only recognized for known kinds of
+	// generated symbols.
+	if isGenerated(sym) {
+		i := strings.IndexAny(sym, ":.")
+		n.Package, n.Func = sym[:i], sym[i+1:]
+		return n
+	}
+
+	// The package path ends at the first "." after the last "/". The
+	// linker escapes any "." in the last path element.
+	prefix := sym
+	if i := strings.IndexAny(prefix, "[("); i >= 0 {
+		prefix = prefix[:i]
+	}
+	slash := strings.LastIndexByte(prefix, '/')
+	dot := strings.IndexByte(prefix[slash+1:], '.')
+	if dot < 0 {
+		n.Func = sym
+		return n
+	}
+	dot += slash + 1
+	n.Package = unescape(sym[:dot])
+
+	elems := split(sym[dot+1:])
+	if last := len(elems) - 1; last > 0 && elems[last] == "fm" {
+		n.MethodValue = true
+		elems = elems[:last]
+	}
+	if len(elems) == 0 {
+		return n
+	}
+
+	// Find the function and receiver.
+	switch e := elems[0]; {
+	case strings.HasPrefix(e, "(*") && strings.HasSuffix(e, ")"):
+		n.PtrReceiver = true
+		n.Receiver, n.TypeArgs = typeArgs(e[2 : len(e)-1])
+		elems = elems[1:]
+	case e == "glob" && len(elems) > 1 && elems[1] == "":
+		// Closures in package-level variable initializers before Go
+		// 1.22 are in "glob.".
+		elems[1] = "glob."
+		elems = elems[1:]
+	case len(elems) > 1 && !isClosure(elems[1]) && e != "init":
+		n.Receiver, n.TypeArgs = typeArgs(e)
+		elems = elems[1:]
+	}
+	if len(elems) == 0 {
+		return n
+	}
+	if n.Receiver != "" {
+		n.Func = elems[0]
+	} else {
+		n.Func, n.TypeArgs = typeArgs(elems[0])
+	}
+	elems = elems[1:]
+	if n.Func == "init" && n.Receiver == "" && len(elems) > 0 && isDigits(elems[0]) {
+		// Packages with multiple init functions number them
+		// "init.0", "init.1", and so on.
+		n.Func += "." + elems[0]
+		elems = elems[1:]
+	}
+	if len(elems) > 0 {
+		n.Closures = elems
+	}
+	return n
+}
+
+// IsMethod reports whether n is a method or a closure within a method.
+func (n Name) IsMethod() bool {
+	return n.Receiver != ""
+}
+
+// IsClosure reports whether n is a closure or other function nested in
+// a function.
+func (n Name) IsClosure() bool {
+	return len(n.Closures) > 0
+}
+
+// split splits s at "." and "-" separators that aren't in brackets or
+// parentheses, such as in type arguments or receivers.
+func split(s string) []string {
+	var elems []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case '.', '-':
+			if depth == 0 {
+				elems = append(elems, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(elems, s[start:])
+}
+
+// typeArgs splits a possibly instantiated type or function name into
+// the name and its type arguments.
+func typeArgs(s string) (string, []string) {
+	i := strings.IndexByte(s, '[')
+	if i < 0 || !strings.HasSuffix(s, "]") {
+		return s, nil
+	}
+	name, list := s[:i], s[i+1:len(s)-1]
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return name, append(args, list[start:])
+}
+
+// isClosure reports whether s has the form of a compiler-generated
+// nested function name.
+func isClosure(s string) bool {
+	for _, prefix := range []string{"func", "deferwrap", "gowrap", "range"} {
+		if strings.HasPrefix(s, prefix) && isDigits(s[len(prefix):]) {
+			return true
+		}
+	}
+	return isDigits(s)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// goGenerated lists the kinds of symbols the compiler and linker
+// generated with a "go." prefix before Go 1.20.
+var goGenerated = []string{
+	"buildid", "buildinfo", "builtin.", "constinfo.", "cuinfo.",
+	"debuglines.", "dict.", "func.", "importpath.", "info.",
+	"itab.", "link.", "loc.", "map.zero", "range.",
+	"shape.", "string.", "track.", "weak.",
+}
+
+// isGenerated reports whether sym is a compiler- or linker-generated
+// symbol with a "go" or "type" prefix.
+func isGenerated(sym string) bool {
+	if strings.HasPrefix(sym, "go:") || strings.HasPrefix(sym, "type:") || strings.HasPrefix(sym, "type.") {
+		return true
+	}
+	if !strings.HasPrefix(sym, "go.") {
+		return false
+	}
+	for _, kind := range goGenerated {
+		if strings.HasPrefix(sym[len("go."):], kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// unescape undoes the linker's %xx escaping of package paths.
+func unescape(s string) string {
+	if strings.IndexByte(s, '%') < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if hi, lo := unhex(s[i+1]), unhex(s[i+2]); hi >= 0 && lo >= 0 {
+				b.WriteByte(byte(hi<<4 | lo))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func unhex(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return -1
+}
+
+// A Package is a group of symbols from the same Go package.
+type Package struct {
+	// Path is the package path, as in Name.Package.
+	Path string
+
+	// Syms lists the symbols in the package, in SymID order.
+	Syms []obj.SymID
+
+	// Size is the total size of Syms.
+	Size uint64
+}
+
+// GroupByPackage groups the defined symbols in syms by package, and
+// returns the packages sorted by path. syms must be indexed by
+// obj.SymID.
+func GroupByPackage(syms []obj.Sym) []*Package {
+	pkgs := make(map[string]*Package)
+	for i, sym := range syms {
+		if sym.Kind == obj.SymUndef || sym.Kind == obj.SymSection || sym.Name == "" {
+			continue
+		}
+		path := Parse(sym.Name).Package
+		pkg := pkgs[path]
+		if pkg == nil {
+			pkg = &Package{Path: path}
+			pkgs[path] = pkg
+		}
+		pkg.Syms = append(pkg.Syms, obj.SymID(i))
+		pkg.Size += sym.Size
+	}
+
+	list := make([]*Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		list = append(list, pkg)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symname
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		sym  string
+		want Name
+	}{
+		{"main.main", Name{Package: "main", Func: "main"}},
+		{"example.com/x/y.F", Name{Package: "example.com/x/y", Func: "F"}},
+		{"example.com/x/y.(*T[go.shape.int]).Method.func2.1", Name{
+			Package: "example.com/x/y", Receiver: "T", PtrReceiver: true,
+			TypeArgs: []string{"go.shape.int"}, Func: "Method",
+			Closures: []string{"func2", "1"}}},
+		{"p.T.M", Name{Package: "p", Receiver: "T", Func: "M"}},
+		{"p.T.M.func1", Name{Package: "p", Receiver: "T", Func: "M", Closures: []string{"func1"}}},
+		{"p.F.func1", Name{Package: "p", Func: "F", Closures: []string{"func1"}}},
+		{"p.F.deferwrap1", Name{Package: "p", Func: "F", Closures: []string{"deferwrap1"}}},
+		{"p.F-range1", Name{Package: "p", Func: "F", Closures: []string{"range1"}}},
+		{"p.T.M-fm", Name{Package: "p", Receiver: "T", Func: "M", MethodValue: true}},
+		{"p.(*T).M-fm", Name{Package: "p", Receiver: "T", PtrReceiver: true, Func: "M", MethodValue: true}},
+		{"p.F[go.shape.struct { a/b.X int },go.shape.*uint8].func1", Name{
+			Package: "p", Func: "F",
+			TypeArgs: []string{"go.shape.struct { a/b.X int }", "go.shape.*uint8"},
+			Closures: []string{"func1"}}},
+		{"p.T[go.shape.int,go.shape.map[string]int].M", Name{
+			Package: "p", Receiver: "T",
+			TypeArgs: []string{"go.shape.int", "go.shape.map[string]int"}, Func: "M"}},
+		{"p.init", Name{Package: "p", Func: "init"}},
+		{"p.init.0", Name{Package: "p", Func: "init.0"}},
+		{"p.init.0.func1", Name{Package: "p", Func: "init.0", Closures: []string{"func1"}}},
+		{"p.init.func1", Name{Package: "p", Func: "init", Closures: []string{"func1"}}},
+		{"p.glob..func1", Name{Package: "p", Func: "glob.", Closures: []string{"func1"}}},
+		{"runtime.memmove.abi0", Name{Package: "runtime", Func: "memmove", ABI0: true}},
+		{"runtime·memmove", Name{Package: "runtime", Func: "memmove"}},
+		{"internal∕bytealg·IndexByte", Name{Package: "internal/bytealg", Func: "IndexByte"}},
+		{"gopkg.in/yaml%2ev2.Marshal", Name{Package: "gopkg.in/yaml.v2", Func: "Marshal"}},
+		{"go:itab.*main.T,main.I", Name{Package: "go", Func: "itab.*main.T,main.I"}},
+		{"go.itab.*main.T,main.I", Name{Package: "go", Func: "itab.*main.T,main.I"}},
+		{"type:.eq.main.T", Name{Package: "type", Func: ".eq.main.T"}},
+		{"type..eq.example.com/x.T", Name{Package: "type", Func: ".eq.example.com/x.T"}},
+		{"type.example.com/x.T", Name{Package: "type", Func: "example.com/x.T"}},
+		{"type.*example.com/x.T", Name{Package: "type", Func: "*example.com/x.T"}},
+		{"go.itab.*example.com/x.T,error", Name{Package: "go", Func: "itab.*example.com/x.T,error"}},
+		{"go.itab.example.com/x.T,example.com/y.I", Name{Package: "go", Func: "itab.example.com/x.T,example.com/y.I"}},
+		{"go.shape.struct { example.com/x.F int }", Name{Package: "go", Func: "shape.struct { example.com/x.F int }"}},
+		{"go.string.\"example.com/x\"", Name{Package: "go", Func: "string.\"example.com/x\""}},
+		{"go.uber.org/zap.(*Logger).Info", Name{Package: "go.uber.org/zap", PtrReceiver: true, Receiver: "Logger", Func: "Info"}},
+		{"go.etcd.io/bbolt.Open", Name{Package: "go.etcd.io/bbolt", Func: "Open"}},
+		{"go.etcd.io/etcd/client/v3.New.func1", Name{Package: "go.etcd.io/etcd/client/v3", Func: "New", Closures: []string{"func1"}}},
+		{"_cgo_topofstack", Name{Func: "_cgo_topofstack"}},
+	} {
+		got := Parse(test.sym)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q):\nwant %+v\ngot  %+v", test.sym, test.want, got)
+		}
+	}
+}
+
+const helloGo = `package main
+
+import "fmt"
+
+type T struct{ x int }
+
+func (t *T) String() string { return fmt.Sprint(t.x) }
+
+func main() {
+	f := (&T{42}).String
+	fmt.Println(f())
+}
+`
+
+func TestGroupByPackage(t *testing.T) {
+	f := gotest.Open(t, gotest.Build(t, helloGo))
+	syms := make([]obj.Sym, f.NumSyms())
+	for i := range syms {
+		syms[i] = f.Sym(obj.SymID(i))
+	}
+	pkgs := GroupByPackage(syms)
+	byPath := make(map[string]*Package)
+	for i, pkg := range pkgs {
+		if i > 0 && pkgs[i-1].Path >= pkg.Path {
+			t.Errorf("packages not sorted: %q before %q", pkgs[i-1].Path, pkg.Path)
+		}
+		byPath[pkg.Path] = pkg
+	}
+	for _, path := range []string{"main", "runtime", "fmt", "go", "type"} {
+		if pkg := byPath[path]; pkg == nil || len(pkg.Syms) == 0 || pkg.Size == 0 {
+			t.Errorf("package %s missing or empty", path)
+		}
+	}
+	names := make(map[string]bool)
+	for _, id := range byPath["main"].Syms {
+		names[syms[id].Name] = true
+	}
+	// T.String is inlined, but its method value wrapper isn't.
+	if !names["main.main"] || !names["main.(*T).String-fm"] {
+		t.Errorf("package main missing symbols, got %v", names)
+	}
+}
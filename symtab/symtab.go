@@ -7,6 +7,7 @@ package symtab
 
 import (
 	"sort"
+	"sync"
 
 	"github.com/aclements/go-obj/obj"
 )
@@ -170,6 +171,15 @@ func makeAddrIndex(syms []obj.Sym, ids []obj.SymID) []symAddr {
 	return out
 }
 
+// FileSyms returns the symbols of f, indexed by SymID.
+func FileSyms(f obj.File) []obj.Sym {
+	syms := make([]obj.Sym, f.NumSyms())
+	for i := range syms {
+		syms[i] = f.Sym(obj.SymID(i))
+	}
+	return syms
+}
+
 // Syms returns all symbols in Table. The returned slice can be
 // indexed by SymID. The caller must not modify the returned slice.
 func (t *Table) Syms() []obj.Sym {
@@ -215,3 +225,160 @@ func (t *Table) Addr(section *obj.Section, addr uint64) obj.SymID {
 	}
 	return id
 }
+
+// Space facilitates symbol lookup across all of the modules in an
+// obj.AddressSpace. Symbol tables are built lazily, so a Space also
+// covers modules added to the address space after NewSpace.
+//
+// A Space is safe for concurrent use, as long as modules aren't added
+// to the address space concurrently.
+type Space struct {
+	as    *obj.AddressSpace
+	extra func(f obj.File, syms []obj.Sym) []obj.Sym
+
+	mu     sync.Mutex
+	tables map[*obj.Module]*Table
+	merged *MergedTable
+}
+
+// NewSpace returns a Space for the modules in as. Symbol sizes are
+// synthesized for symbols without sizes using obj.SynthesizeSizes.
+func NewSpace(as *obj.AddressSpace) *Space {
+	return NewSpaceExtra(as, nil)
+}
+
+// NewSpaceExtra is like NewSpace, but adds the symbols returned by
+// extra to the table of each module. extra is called with the module's
+// File and its symbols, and may return nil. For example,
+// pclntab.RecoverSyms recovers function symbols from stripped Go
+// binaries.
+//
+// Extra symbols follow the module's own symbols in its Table, so their
+// IDs are the File's NumSyms and higher and don't identify symbols in
+// the File. Use the Table's Syms to look up symbols by ID.
+func NewSpaceExtra(as *obj.AddressSpace, extra func(f obj.File, syms []obj.Sym) []obj.Sym) *Space {
+	return &Space{as: as, extra: extra, tables: make(map[*obj.Module]*Table)}
+}
+
+// Table returns the symbol table for module m, or nil if m isn't in
+// the address space.
+func (s *Space) Table(m *obj.Module) *Table {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table(m)
+}
+
+// table is like Table, but must be called with s.mu held.
+func (s *Space) table(m *obj.Module) *Table {
+	if t, ok := s.tables[m]; ok {
+		return t
+	}
+	found := false
+	for _, m2 := range s.as.Modules() {
+		found = found || m2 == m
+	}
+	if !found {
+		return nil
+	}
+	syms := FileSyms(m.File)
+	if s.extra != nil {
+		syms = append(syms, s.extra(m.File, syms)...)
+	}
+	obj.SynthesizeSizes(syms)
+	t := NewTable(syms)
+	s.tables[m] = t
+	return t
+}
+
+// A MergedTable is a single symbol table for all of the modules in an
+// address space. Its symbol values are addresses in the address space,
+// and it omits undefined symbols.
+type MergedTable struct {
+	*Table
+
+	origins  []symOrigin
+	nModules int
+}
+
+type symOrigin struct {
+	m  *obj.Module
+	id obj.SymID
+}
+
+// Origin returns the module defining symbol id of t and the symbol's
+// ID in that module's Table. If id is obj.NoSym, it returns nil,
+// obj.NoSym.
+func (t *MergedTable) Origin(id obj.SymID) (*obj.Module, obj.SymID) {
+	if id == obj.NoSym {
+		return nil, obj.NoSym
+	}
+	o := t.origins[id]
+	return o.m, o.id
+}
+
+// Merged returns a merged symbol table for all of the modules in the
+// address space. As with Name, if several modules define a global
+// symbol, name lookup finds the one in the first module.
+func (s *Space) Merged() *MergedTable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mods := s.as.Modules()
+	if s.merged != nil && s.merged.nModules == len(mods) {
+		return s.merged
+	}
+
+	var syms []obj.Sym
+	var origins []symOrigin
+	for _, m := range mods {
+		for id, sym := range s.table(m).syms {
+			if sym.Kind == obj.SymUndef {
+				continue
+			}
+			if sym.Section != nil && sym.Section.Mapped() {
+				sym.Value = m.FromFile(sym.Value)
+			}
+			syms = append(syms, sym)
+			origins = append(origins, symOrigin{m, obj.SymID(id)})
+		}
+	}
+	t := NewTable(syms)
+	// NewTable prefers the last definition of a name, but earlier
+	// modules take precedence.
+	for i := len(syms) - 1; i >= 0; i-- {
+		if !syms[i].Local() {
+			t.name[syms[i].Name] = obj.SymID(i)
+		}
+	}
+	s.merged = &MergedTable{t, origins, len(mods)}
+	return s.merged
+}
+
+// Addr returns the module and symbol containing address addr in the
+// address space, or nil, obj.NoSym. The symbol's ID is in the module's
+// Table, and its value is an address in the module's File; use
+// Module.FromFile to translate it to the address space.
+func (s *Space) Addr(addr uint64) (*obj.Module, obj.SymID) {
+	m, _ := s.as.ResolveAddr(addr)
+	if m == nil {
+		return nil, obj.NoSym
+	}
+	id := s.Table(m).Addr(nil, m.ToFile(addr))
+	if id == obj.NoSym {
+		return nil, obj.NoSym
+	}
+	return m, id
+}
+
+// Name returns the module and (global) symbol with the given name, or
+// nil, obj.NoSym. Modules are searched in the order they were added to
+// the address space, so the first module that defines name wins.
+// Undefined symbols are ignored.
+func (s *Space) Name(name string) (*obj.Module, obj.SymID) {
+	for _, m := range s.as.Modules() {
+		tab := s.Table(m)
+		if id := tab.Name(name); id != obj.NoSym && tab.syms[id].Kind != obj.SymUndef {
+			return m, id
+		}
+	}
+	return nil, obj.NoSym
+}
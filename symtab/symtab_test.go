@@ -6,6 +6,8 @@ package symtab
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -166,3 +168,117 @@ func TestOverlap(t *testing.T) {
 		}
 	}
 }
+
+func TestSpace(t *testing.T) {
+	open := func(name string) obj.File {
+		fp, err := os.Open(filepath.Join("..", "obj", "testdata", name))
+		if err != nil {
+			t.Fatalf("can't open test file: %v", err)
+		}
+		t.Cleanup(func() { fp.Close() })
+		f, err := obj.Open(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(f.Close)
+		return f
+	}
+	const bias = 0x7f0000000000
+	as := obj.NewAddressSpace()
+	exe := as.Add("exe", open("hello-gcc10.3.0-AMD64-dyn"), 0)
+	lib := as.Add("lib", open("hello-gcc10.3.0-AMD64-pie"), bias)
+	space := NewSpace(as)
+
+	// Both modules define main. Name lookup finds the first.
+	m, id := space.Name("main")
+	if m != exe || id == obj.NoSym {
+		t.Fatalf("Name(main): want module exe, got %v %v", m, id)
+	}
+	libMain := space.Table(lib).Name("main")
+	if libMain == obj.NoSym {
+		t.Fatalf("lib has no main")
+	}
+
+	// Address lookup finds the right module.
+	for _, test := range []struct {
+		m  *obj.Module
+		id obj.SymID
+	}{{exe, id}, {lib, libMain}} {
+		sym := space.Table(test.m).Syms()[test.id]
+		addr := test.m.FromFile(sym.Value + 1)
+		gotM, gotID := space.Addr(addr)
+		if gotM != test.m || gotID != test.id {
+			t.Errorf("Addr(%#x): want %s/%v, got %v/%v", addr, test.m.Name, test.id, gotM, gotID)
+		}
+	}
+	if m, id := space.Addr(bias - 1); m != nil || id != obj.NoSym {
+		t.Errorf("Addr of unmapped address: want nil/NoSym, got %v/%v", m, id)
+	}
+
+	// The merged table uses address space addresses and prefers the
+	// first module's definitions.
+	merged := space.Merged()
+	mainID := merged.Name("main")
+	if gotM, gotID := merged.Origin(mainID); gotM != exe || gotID != id {
+		t.Errorf("merged Name(main): want exe/%v, got %v/%v", id, gotM, gotID)
+	}
+	sym := space.Table(lib).Syms()[libMain]
+	mergedID := merged.Addr(nil, lib.FromFile(sym.Value+1))
+	if gotM, gotID := merged.Origin(mergedID); gotM != lib || gotID != libMain {
+		t.Errorf("merged Addr: want lib/%v, got %v/%v", libMain, gotM, gotID)
+	}
+	if got := merged.Syms()[mergedID].Value; got != lib.FromFile(sym.Value) {
+		t.Errorf("merged symbol value: want %#x, got %#x", lib.FromFile(sym.Value), got)
+	}
+
+	// Modules added after NewSpace are found.
+	lib2 := as.Add("lib2", lib.File, 2*bias)
+	if m, id := space.Addr(lib2.FromFile(sym.Value + 1)); m != lib2 || id != libMain {
+		t.Errorf("Addr in module added later: want lib2/%v, got %v/%v", libMain, m, id)
+	}
+	merged = space.Merged()
+	if gotM, _ := merged.Origin(merged.Addr(nil, lib2.FromFile(sym.Value+1))); gotM != lib2 {
+		t.Errorf("merged table not rebuilt after adding a module")
+	}
+}
+
+func TestSpaceExtra(t *testing.T) {
+	fp, err := os.Open(filepath.Join("..", "obj", "testdata", "hello-gcc10.3.0-AMD64-dyn"))
+	if err != nil {
+		t.Fatalf("can't open test file: %v", err)
+	}
+	defer fp.Close()
+	f, err := obj.Open(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	as := obj.NewAddressSpace()
+	m := as.Add("exe", f, 0)
+
+	mainID := NewTable(FileSyms(f)).Name("main")
+	if mainID == obj.NoSym {
+		t.Fatalf("no main")
+	}
+	main := f.Sym(mainID)
+	extra := obj.Sym{Name: "extra", Section: main.Section, Value: main.Value, Size: 1, Kind: obj.SymText}
+	space := NewSpaceExtra(as, func(f2 obj.File, syms []obj.Sym) []obj.Sym {
+		if f2 != f || len(syms) != int(f.NumSyms()) {
+			t.Errorf("extra called with wrong file or %d symbols", len(syms))
+		}
+		return []obj.Sym{extra}
+	})
+
+	// The extra symbol follows the file's symbols, and takes priority
+	// over main because it's smaller.
+	id := space.Table(m).Name("extra")
+	if id != obj.SymID(f.NumSyms()) {
+		t.Errorf("want extra symbol ID %d, got %v", f.NumSyms(), id)
+	}
+	if gotM, gotID := space.Addr(main.Value); gotM != m || gotID != id {
+		t.Errorf("Addr(%#x): want exe/%v, got %v/%v", main.Value, id, gotM, gotID)
+	}
+	if gotM, gotID := space.Addr(main.Value + 1); gotM != m || gotID != mainID {
+		t.Errorf("Addr(%#x): want exe/%v, got %v/%v", main.Value+1, mainID, gotM, gotID)
+	}
+}
@@ -0,0 +1,20 @@
+#!/usr/bin/bash
+# build.bash builds the test binaries for the unwind package and runs
+# them to capture their stacks.
+
+set -e
+
+CFLAGS="-nostdlib -static -no-pie -fno-stack-protector -fcf-protection=none -ffile-prefix-map=$PWD=/ -Wa,--debug-prefix-map,$PWD=/"
+
+for arch in amd64 386; do
+	case $arch in
+	amd64) m=-m64 ;;
+	386) m=-m32 ;;
+	esac
+	# Unwinding with CFI (.eh_frame), with line and inline info.
+	gcc $CFLAGS $m -O2 -g -o stack-cfi-$arch stack.c
+	./stack-cfi-$arch > stack-cfi-$arch.dump
+	# Unwinding with frame pointers only.
+	gcc $CFLAGS $m -O0 -fno-omit-frame-pointer -fno-asynchronous-unwind-tables -fno-unwind-tables -o stack-fp-$arch stack.c
+	./stack-fp-$arch > stack-fp-$arch.dump
+done
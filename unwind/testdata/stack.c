@@ -0,0 +1,66 @@
+// stack.c captures its own registers and stack at a known point and
+// writes them to stdout, so tests can unwind the captured stack.
+//
+// The output is a sequence of words: the PC, SP, and frame pointer at
+// the capture point, the address just past the end of the captured
+// stack, and then the stack memory from SP to that address.
+
+typedef unsigned long word;
+
+static word regs[4];
+static word stack_top;
+int counter;
+
+#if defined(__x86_64__)
+#define CAPTURE(pc, sp, fp) \
+	__asm__ volatile("lea 0(%%rip), %0\n\tmov %%rsp, %1\n\tmov %%rbp, %2" : "=r"(pc), "=r"(sp), "=r"(fp))
+
+static long sys3(long n, long a, long b, long c) {
+	long ret;
+	__asm__ volatile("syscall" : "=a"(ret) : "a"(n), "D"(a), "S"(b), "d"(c) : "rcx", "r11", "memory");
+	return ret;
+}
+#define SYS_WRITE 1
+#define SYS_EXIT 60
+#elif defined(__i386__)
+#define CAPTURE(pc, sp, fp) \
+	__asm__ volatile("mov %%esp, %1\n\tmov %%ebp, %2\n\tcall 1f\n1:\n\tpop %0" : "=r"(pc), "=r"(sp), "=r"(fp))
+
+static long sys3(long n, long a, long b, long c) {
+	long ret;
+	__asm__ volatile("int $0x80" : "=a"(ret) : "a"(n), "b"(a), "c"(b), "d"(c) : "memory");
+	return ret;
+}
+#define SYS_WRITE 4
+#define SYS_EXIT 1
+#endif
+
+static inline __attribute__((always_inline)) void inlined(int x) {
+	word pc, sp, fp;
+	CAPTURE(pc, sp, fp);
+	regs[0] = pc;
+	regs[1] = sp;
+	regs[2] = fp;
+	regs[3] = stack_top;
+	// Dump the stack right away, before anything else overwrites it.
+	sys3(SYS_WRITE, 1, (long)regs, sizeof regs);
+	sys3(SYS_WRITE, 1, (long)regs[1], stack_top - regs[1]);
+	counter += x;
+}
+
+__attribute__((noinline)) int leaf(int x) {
+	inlined(x);
+	return counter;
+}
+
+__attribute__((noinline)) int middle(int x) {
+	volatile int buf[4];
+	buf[0] = x;
+	return leaf(buf[0]) + 1;
+}
+
+void _start(void) {
+	stack_top = (word)__builtin_frame_address(0) + 4 * sizeof(word);
+	counter = middle(1);
+	sys3(SYS_EXIT, 0, 0, 0);
+}
@@ -0,0 +1,509 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unwind recovers the call stacks of threads from their
+// registers and memory, such as from a core file or a live process.
+//
+// Frames are unwound using call frame information when it's available.
+// This covers C frames, which typically have .eh_frame, and Go frames
+// in binaries with DWARF, for which the Go linker emits .debug_frame.
+// Go frames without call frame information, such as in binaries linked
+// with -ldflags=-w, are unwound using the SP deltas in the Go pclntab.
+// Other frames are unwound by following the frame pointer chain.
+package unwind
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/cfi"
+	"github.com/aclements/go-obj/dbg"
+	"github.com/aclements/go-obj/obj"
+	"github.com/aclements/go-obj/pclntab"
+	"github.com/aclements/go-obj/symtab"
+)
+
+// An Unwinder unwinds the stacks of threads in a single address space.
+//
+// An Unwinder is safe for concurrent use.
+type Unwinder struct {
+	as   *obj.AddressSpace
+	mem  io.ReaderAt
+	arch *arch.Arch
+
+	calleeSave []arch.Reg
+
+	syms *symtab.Space
+	dbg  *dbg.Space
+
+	mu  sync.Mutex
+	cfi map[*obj.Module]*moduleCFI
+}
+
+type moduleCFI struct {
+	once sync.Once
+	tabs []*cfi.Table
+	pcln *pclntab.Table // nil if the module has no pclntab
+	err  error
+}
+
+// Registers that are preserved across calls, and hence have the same
+// value in the caller unless call frame information says otherwise.
+var calleeSave = map[*arch.Arch][]string{
+	arch.AMD64: {"rbx", "rbp", "r12", "r13", "r14", "r15"},
+	arch.I386:  {"ebx", "esi", "edi", "ebp"},
+}
+
+// New returns an Unwinder for the modules in as. Stack memory is read
+// from mem, where offsets are addresses in the address space. Memory
+// that can't be read from mem, or all memory if mem is nil, is read
+// from the modules in as.
+//
+// The architecture is taken from the first module in as. Currently,
+// only amd64 and 386 are supported.
+func New(as *obj.AddressSpace, mem io.ReaderAt) (*Unwinder, error) {
+	mods := as.Modules()
+	if len(mods) == 0 {
+		return nil, fmt.Errorf("address space has no modules")
+	}
+	a := mods[0].File.Info().Arch
+	names, ok := calleeSave[a]
+	if !ok {
+		return nil, fmt.Errorf("unwinding is not supported on architecture %s", a)
+	}
+	u := &Unwinder{
+		as:   as,
+		mem:  mem,
+		arch: a,
+		syms: symtab.NewSpaceExtra(as, pclntab.RecoverSyms),
+		dbg:  dbg.NewSpace(as),
+		cfi:  make(map[*obj.Module]*moduleCFI),
+	}
+	for _, name := range names {
+		reg, _ := a.Regs.ByName(name)
+		u.calleeSave = append(u.calleeSave, reg)
+	}
+	return u, nil
+}
+
+// Arch returns the architecture of the address space.
+func (u *Unwinder) Arch() *arch.Arch {
+	return u.arch
+}
+
+// A Method is how a frame was recovered.
+type Method uint8
+
+const (
+	// MethodRegs means the frame is the innermost frame, given by the
+	// thread's registers.
+	MethodRegs Method = iota
+	// MethodCFI means the frame was recovered from its callee using
+	// call frame information.
+	MethodCFI
+	// MethodFramePointer means the frame was recovered from its
+	// callee by following the frame pointer.
+	MethodFramePointer
+	// MethodPCSP means the frame was recovered from its callee using
+	// the SP delta table in the Go pclntab.
+	MethodPCSP
+)
+
+func (m Method) String() string {
+	switch m {
+	case MethodRegs:
+		return "regs"
+	case MethodCFI:
+		return "cfi"
+	case MethodFramePointer:
+		return "fp"
+	case MethodPCSP:
+		return "pcsp"
+	}
+	return fmt.Sprintf("Method(%d)", m)
+}
+
+// A Location is a source location within a function.
+type Location struct {
+	// Func is the name of the function, or "" if unknown.
+	Func string
+
+	// File and Line give the source position, or "" and 0 if unknown.
+	File string
+	Line int
+}
+
+// A Frame is a physical stack frame.
+type Frame struct {
+	// PC is the program counter of this frame. For the innermost
+	// frame, this is the thread's PC. For other frames, this is the
+	// return address, which is typically just after the call
+	// instruction. Symbolic information is for the call instruction.
+	PC uint64
+
+	// SP is the stack pointer of this frame.
+	SP uint64
+
+	// Regs gives the values of registers that are known in this frame.
+	Regs map[arch.Reg]uint64
+
+	// Method is how this frame was recovered.
+	Method Method
+
+	// Module is the module containing PC.
+	Module *obj.Module
+
+	// Entry is the address of the entry point of the function, or 0 if
+	// unknown.
+	Entry uint64
+
+	// Location gives the physical function of this frame and the
+	// source position of PC. If PC is in code inlined into Func, File
+	// and Line give the position of the outermost inlined call.
+	Location
+
+	// Inline gives the functions inlined at PC, from innermost to
+	// outermost. The File and Line of each give the source position
+	// within that function.
+	Inline []Location
+}
+
+// Unwind unwinds the stack of a thread whose registers are regs,
+// returning the frames from innermost to outermost. Registers are
+// numbered according to the architecture's arch.Regs; regs must
+// include at least the PC and SP. If max > 0, Unwind returns at most
+// max frames.
+//
+// Unwinding stops at a frame whose return address is 0 or not in any
+// module. If unwinding fails partway, Unwind returns the frames it
+// recovered along with an error.
+func (u *Unwinder) Unwind(regs map[arch.Reg]uint64, max int) ([]Frame, error) {
+	ar := u.arch.Regs
+	if _, ok := regs[ar.PC]; !ok {
+		return nil, fmt.Errorf("PC register %s is unknown", ar.Name(ar.PC))
+	}
+	if _, ok := regs[ar.SP]; !ok {
+		return nil, fmt.Errorf("SP register %s is unknown", ar.Name(ar.SP))
+	}
+
+	cur := make(map[arch.Reg]uint64, len(regs))
+	for reg, val := range regs {
+		cur[reg] = val
+	}
+	frame := Frame{PC: cur[ar.PC], SP: cur[ar.SP], Regs: cur, Method: MethodRegs}
+	var frames []Frame
+	for {
+		// For callers, look up the call instruction rather than the
+		// instruction after it, which may be in a different function
+		// or line, or past the end of the function.
+		pc := frame.PC
+		if frame.Method != MethodRegs {
+			pc--
+		}
+		u.symbolize(&frame, pc)
+		frames = append(frames, frame)
+		if max > 0 && len(frames) >= max {
+			return frames, nil
+		}
+
+		caller, ok, err := u.step(&frame, pc)
+		if err != nil {
+			return frames, fmt.Errorf("unwinding frame at PC %#x: %w", frame.PC, err)
+		}
+		if !ok {
+			return frames, nil
+		}
+		if caller.SP <= frame.SP {
+			return frames, fmt.Errorf("unwinding frame at PC %#x: stack pointer did not increase (%#x to %#x)", frame.PC, frame.SP, caller.SP)
+		}
+		frame = caller
+	}
+}
+
+// step recovers the caller of frame, where pc is the address to use
+// for looking up frame's function. It returns false if frame is the
+// outermost frame.
+func (u *Unwinder) step(frame *Frame, pc uint64) (Frame, bool, error) {
+	ar := u.arch.Regs
+	var caller Frame
+	ra := ar.PC
+
+	mc, err := u.moduleCFI(frame.Module)
+	if err != nil {
+		return caller, false, err
+	}
+	fde, err := mc.findFDE(frame.Module, pc)
+	if err != nil {
+		return caller, false, err
+	}
+	if fde != nil {
+		row, err := fde.RowAt(frame.Module.ToFile(pc))
+		if err != nil {
+			return caller, false, err
+		}
+		caller, err = u.stepCFI(frame, row)
+		if err != nil {
+			return caller, false, err
+		}
+		ra = fde.CIE.ReturnAddress
+	} else if fn, ok := mc.findFunc(frame.Module, pc); ok {
+		caller, err = u.stepPCSP(frame, fn, frame.Module.ToFile(pc))
+		if err != nil {
+			return caller, false, err
+		}
+	} else {
+		caller, err = u.stepFP(frame)
+		if err != nil {
+			return caller, false, err
+		}
+	}
+
+	retPC, ok := caller.Regs[ra]
+	if !ok || retPC == 0 {
+		// The return address is undefined, which marks the outermost
+		// frame.
+		return caller, false, nil
+	}
+	if m, _ := u.as.ResolveAddr(retPC - 1); m == nil {
+		return caller, false, nil
+	}
+	caller.PC = retPC
+	caller.Regs[ar.PC] = retPC
+	return caller, true, nil
+}
+
+// stepCFI recovers the caller of frame using CFI table row row.
+func (u *Unwinder) stepCFI(frame *Frame, row cfi.Row) (Frame, error) {
+	ar := u.arch.Regs
+	if row.CFA.Expr != nil {
+		return Frame{}, fmt.Errorf("CFA expressions are not supported")
+	}
+	base, ok := frame.Regs[row.CFA.Reg]
+	if !ok {
+		return Frame{}, fmt.Errorf("CFA register %s is unknown", ar.Name(row.CFA.Reg))
+	}
+	cfa := base + uint64(row.CFA.Offset)
+
+	regs := make(map[arch.Reg]uint64)
+	for _, reg := range u.calleeSave {
+		if val, ok := frame.Regs[reg]; ok {
+			regs[reg] = val
+		}
+	}
+	for reg, rule := range row.Regs {
+		delete(regs, reg)
+		switch rule.Kind {
+		case cfi.RuleSameValue:
+			if val, ok := frame.Regs[reg]; ok {
+				regs[reg] = val
+			}
+		case cfi.RuleOffset:
+			val, err := u.readWord(cfa + uint64(rule.Offset))
+			if err != nil {
+				return Frame{}, err
+			}
+			regs[reg] = val
+		case cfi.RuleValOffset:
+			regs[reg] = cfa + uint64(rule.Offset)
+		case cfi.RuleRegister:
+			if val, ok := frame.Regs[rule.Reg]; ok {
+				regs[reg] = val
+			}
+		}
+		// Other rules leave the register unknown.
+	}
+	regs[ar.SP] = cfa
+	return Frame{SP: cfa, Regs: regs, Method: MethodCFI}, nil
+}
+
+// stepPCSP recovers the caller of frame, which is in Go function fn,
+// using fn's SP delta at file address pc. This assumes the return
+// address is just above fn's frame, as it is on amd64 and 386.
+func (u *Unwinder) stepPCSP(frame *Frame, fn pclntab.Func, pc uint64) (Frame, error) {
+	ar := u.arch.Regs
+	delta, err := fn.SPDelta(pc)
+	if err != nil {
+		return Frame{}, err
+	}
+	ws := uint64(u.arch.Layout.WordSize())
+	cfa := frame.SP + uint64(delta) + ws
+	retPC, err := u.readWord(cfa - ws)
+	if err != nil {
+		return Frame{}, err
+	}
+	regs := map[arch.Reg]uint64{ar.SP: cfa, ar.PC: retPC}
+	if fp, ok := frame.Regs[ar.FP]; ok {
+		if fp == cfa-2*ws {
+			// fn saved the caller's frame pointer just below the
+			// return address and pointed the frame pointer at it.
+			fp, err = u.readWord(fp)
+			if err != nil {
+				return Frame{}, err
+			}
+		}
+		// Otherwise, fn hasn't changed the frame pointer.
+		regs[ar.FP] = fp
+	}
+	return Frame{SP: cfa, Regs: regs, Method: MethodPCSP}, nil
+}
+
+// stepFP recovers the caller of frame by following the frame pointer.
+// This assumes the frame pointer points to the saved frame pointer of
+// the caller, followed by the return address.
+func (u *Unwinder) stepFP(frame *Frame) (Frame, error) {
+	ar := u.arch.Regs
+	fp, ok := frame.Regs[ar.FP]
+	if !ok || fp == 0 {
+		// No frame pointer, so this is the end of the line.
+		return Frame{Regs: map[arch.Reg]uint64{}}, nil
+	}
+	ws := uint64(u.arch.Layout.WordSize())
+	callerFP, err := u.readWord(fp)
+	if err != nil {
+		return Frame{}, err
+	}
+	retPC, err := u.readWord(fp + ws)
+	if err != nil {
+		return Frame{}, err
+	}
+	sp := fp + 2*ws
+	regs := map[arch.Reg]uint64{ar.SP: sp, ar.FP: callerFP, ar.PC: retPC}
+	return Frame{SP: sp, Regs: regs, Method: MethodFramePointer}, nil
+}
+
+// moduleCFI returns the unwinding tables of module m, loading them on
+// first use. It returns nil if m is nil.
+func (u *Unwinder) moduleCFI(m *obj.Module) (*moduleCFI, error) {
+	if m == nil {
+		return nil, nil
+	}
+	u.mu.Lock()
+	mc := u.cfi[m]
+	if mc == nil {
+		mc = new(moduleCFI)
+		u.cfi[m] = mc
+	}
+	u.mu.Unlock()
+
+	mc.once.Do(func() {
+		for _, newTable := range []func(obj.File) (*cfi.Table, error){cfi.NewEHFrame, cfi.NewDebugFrame} {
+			tab, err := newTable(m.File)
+			if err == cfi.ErrNoCFI {
+				continue
+			} else if err != nil {
+				mc.err = fmt.Errorf("%s: %w", m.Name, err)
+				return
+			}
+			mc.tabs = append(mc.tabs, tab)
+		}
+		tab, err := pclntab.NewTable(m.File)
+		if err == nil {
+			mc.pcln = tab
+		} else if err != pclntab.ErrNoPCLNTab {
+			mc.err = fmt.Errorf("%s: %w", m.Name, err)
+		}
+	})
+	if mc.err != nil {
+		return nil, mc.err
+	}
+	return mc, nil
+}
+
+// findFDE returns the FDE covering address pc in module m, or nil if
+// there is none.
+func (mc *moduleCFI) findFDE(m *obj.Module, pc uint64) (*cfi.FDE, error) {
+	if mc == nil {
+		return nil, nil
+	}
+	for _, tab := range mc.tabs {
+		fde, err := tab.FindFDE(m.ToFile(pc))
+		if err != nil {
+			return nil, err
+		}
+		if fde != nil {
+			return fde, nil
+		}
+	}
+	return nil, nil
+}
+
+// findFunc returns the Go function containing address pc in module m,
+// if m has a pclntab.
+func (mc *moduleCFI) findFunc(m *obj.Module, pc uint64) (pclntab.Func, bool) {
+	if mc == nil || mc.pcln == nil {
+		return pclntab.Func{}, false
+	}
+	return mc.pcln.FindFunc(m.ToFile(pc))
+}
+
+// readWord reads a word of memory at address addr.
+func (u *Unwinder) readWord(addr uint64) (uint64, error) {
+	ws := u.arch.Layout.WordSize()
+	var memErr error
+	if u.mem != nil {
+		buf := make([]byte, ws)
+		if _, memErr = u.mem.ReadAt(buf, int64(addr)); memErr == nil {
+			return u.arch.Layout.Word(buf), nil
+		}
+	}
+	// Fall back to the modules.
+	d, err := u.as.Data(addr, uint64(ws))
+	if err != nil {
+		if memErr != nil {
+			return 0, memErr
+		}
+		return 0, err
+	}
+	return u.arch.Layout.Word(d.B), nil
+}
+
+// symbolize fills in the module and symbolic information of frame for
+// address pc.
+func (u *Unwinder) symbolize(frame *Frame, pc uint64) {
+	m, _ := u.as.ResolveAddr(pc)
+	frame.Module = m
+	if m == nil {
+		return
+	}
+	if _, id := u.syms.Addr(pc); id != obj.NoSym {
+		// Look up the symbol in the table rather than the file because
+		// the table may include symbols recovered from the pclntab.
+		sym := u.syms.Table(m).Syms()[id]
+		frame.Func = sym.Name
+		frame.Entry = m.FromFile(sym.Value)
+	}
+
+	_, d, filePC, err := u.dbg.Lookup(pc)
+	if err != nil || d == nil {
+		return
+	}
+	lr := d.LineReader()
+	if lr.SeekPC(filePC) != nil || lr.Line.EndSequence || lr.Line.Address > filePC {
+		return
+	}
+	file, line := lineFile(lr.Line.File), lr.Line.Line
+	for site := lr.Stack; site != nil; site = site.Caller {
+		name := d.Name(site.Entry)
+		if site.Caller == nil {
+			// This is the physical function.
+			if frame.Func == "" {
+				frame.Func = name
+			}
+			break
+		}
+		frame.Inline = append(frame.Inline, Location{name, file, line})
+		file, line = lineFile(site.CallFile), site.CallLine
+	}
+	frame.File, frame.Line = file, line
+}
+
+func lineFile(f *dwarf.LineFile) string {
+	if f == nil {
+		return ""
+	}
+	return f.Name
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unwind
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aclements/go-obj/arch"
+	"github.com/aclements/go-obj/internal/gotest"
+	"github.com/aclements/go-obj/obj"
+)
+
+// stackMem is an io.ReaderAt over a captured range of stack memory.
+type stackMem struct {
+	addr uint64
+	b    []byte
+}
+
+func (m *stackMem) ReadAt(p []byte, off int64) (int, error) {
+	addr := uint64(off)
+	if addr < m.addr || addr+uint64(len(p)) > m.addr+uint64(len(m.b)) {
+		return 0, &obj.ErrNoData{Detail: fmt.Sprintf("address %#x is not in the stack", addr)}
+	}
+	return copy(p, m.b[addr-m.addr:]), nil
+}
+
+// loadTest opens test binary path and its stack dump, and returns an
+// Unwinder and the captured registers.
+func loadTest(t *testing.T, path string) (*Unwinder, map[arch.Reg]uint64) {
+	t.Helper()
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fp.Close() })
+	f, err := obj.Open(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(f.Close)
+	dump, err := os.ReadFile(path + ".dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := obj.NewAddressSpace()
+	as.Add(path, f, 0)
+	a := f.Info().Arch
+	ws := a.Layout.WordSize()
+	word := func(i int) uint64 { return a.Layout.Word(dump[i*ws:]) }
+	mem := &stackMem{word(1), dump[4*ws:]}
+	if mem.addr+uint64(len(mem.b)) != word(3) {
+		t.Fatalf("malformed stack dump")
+	}
+	u, err := New(as, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regs := map[arch.Reg]uint64{a.Regs.PC: word(0), a.Regs.SP: word(1), a.Regs.FP: word(2)}
+	return u, regs
+}
+
+// frameString formats f for comparison with expected frames.
+func frameString(f Frame) string {
+	var buf strings.Builder
+	for _, inl := range f.Inline {
+		fmt.Fprintf(&buf, "%s %s:%d <- ", inl.Func, inl.File, inl.Line)
+	}
+	fmt.Fprintf(&buf, "%s", f.Func)
+	if f.File != "" {
+		fmt.Fprintf(&buf, " %s:%d", f.File, f.Line)
+	}
+	fmt.Fprintf(&buf, " (%s)", f.Method)
+	return buf.String()
+}
+
+func TestUnwind(t *testing.T) {
+	cfiFrames := []string{
+		"inlined /stack.c:40 <- leaf /stack.c:52 (regs)",
+		"middle /stack.c:59 (cfi)",
+		"_start /stack.c:64 (cfi)",
+	}
+	fpFrames := []string{
+		"leaf (regs)",
+		"middle (fp)",
+		"_start (fp)",
+	}
+	for _, test := range []struct {
+		path string
+		want []string
+	}{
+		{"testdata/stack-cfi-amd64", cfiFrames},
+		{"testdata/stack-cfi-386", cfiFrames},
+		{"testdata/stack-fp-amd64", fpFrames},
+		{"testdata/stack-fp-386", fpFrames},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			u, regs := loadTest(t, test.path)
+			frames, err := u.Unwind(regs, 0)
+			if err != nil {
+				t.Error(err)
+			}
+			var got []string
+			for i, f := range frames {
+				got = append(got, frameString(f))
+				if f.Module == nil || f.Entry == 0 {
+					t.Errorf("frame %d: missing module or entry: %+v", i, f)
+				} else if f.PC <= f.Entry {
+					t.Errorf("frame %d: PC %#x is not after entry %#x", i, f.PC, f.Entry)
+				}
+				if i > 0 && f.SP <= frames[i-1].SP {
+					t.Errorf("frame %d: SP %#x is not above callee SP %#x", i, f.SP, frames[i-1].SP)
+				}
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("want frames:\n%s\ngot:\n%s", strings.Join(test.want, "\n"), strings.Join(got, "\n"))
+			}
+
+			// Check the frame limit.
+			frames, err = u.Unwind(regs, 2)
+			if err != nil || len(frames) != 2 {
+				t.Errorf("with max 2, got %d frames, %v", len(frames), err)
+			}
+		})
+	}
+}
+
+const crashGo = `package main
+
+import "syscall"
+
+//go:noinline
+func crash() {
+	panic("crash")
+}
+
+func main() {
+	var lim syscall.Rlimit
+	syscall.Getrlimit(syscall.RLIMIT_CORE, &lim)
+	lim.Cur = lim.Max
+	syscall.Setrlimit(syscall.RLIMIT_CORE, &lim)
+	crash()
+}
+`
+
+// TestUnwindPCSP unwinds a Go binary linked without DWARF, which has
+// no call frame information for Go frames.
+func TestUnwindPCSP(t *testing.T) {
+	bin := gotest.Build(t, crashGo, "-ldflags=-s -w")
+	core, _ := gotest.Core(t, bin)
+	f, cf := gotest.Open(t, bin), gotest.Open(t, core)
+	threads, err := obj.CoreThreads(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := obj.CoreMemory(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := obj.NewAddressSpace()
+	as.Add(bin, f, 0)
+	u, err := New(as, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The crashing thread is in a signal handler. Find the registers
+	// it interrupted in the ucontext at the CFA of runtime.sigtramp.
+	frames, _ := u.Unwind(threads[0].Regs, 0)
+	var uc uint64
+	for i := 0; i+1 < len(frames); i++ {
+		if frames[i].Func == "runtime.sigtramp" {
+			uc = frames[i+1].SP
+			break
+		}
+	}
+	if uc == 0 {
+		t.Fatalf("runtime.sigtramp not found in signal stack")
+	}
+	ar := u.Arch().Regs
+	regs := make(map[arch.Reg]uint64)
+	for reg, off := range map[arch.Reg]uint64{ar.PC: 168, ar.SP: 160, ar.FP: 120} {
+		if regs[reg], err = u.readWord(uc + off); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// runtime.raise doesn't save the frame pointer, so following the
+	// frame pointer would skip runtime.dieFromSignal.
+	frames, err = u.Unwind(regs, 0)
+	if err != nil {
+		t.Error(err)
+	}
+	want := []string{"runtime.raise", "runtime.dieFromSignal", "runtime.fatalpanic", "runtime.gopanic", "main.crash", "main.main", "runtime.main", "runtime.goexit"}
+	var got []string
+	for i, f := range frames {
+		got = append(got, f.Func)
+		if i > 0 && f.Method != MethodPCSP {
+			t.Errorf("frame %d: want method pcsp, got %s", i, f.Method)
+		}
+	}
+	i := 0
+	for _, name := range got {
+		if i < len(want) && name == want[i] {
+			i++
+		}
+	}
+	if i < len(want) || got[0] != want[0] || got[len(got)-1] != want[len(want)-1] {
+		t.Errorf("want frames including %v, got %v", want, got)
+	}
+}